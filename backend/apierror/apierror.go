@@ -0,0 +1,148 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Code はクライアントが分岐に使うエラーの種類（メッセージは変わることがある）
+type Code string
+
+const (
+	// 400
+	CodeInvalidRequest    Code = "INVALID_REQUEST"
+	CodeInvalidJSON       Code = "INVALID_JSON"
+	CodeValidationFailed  Code = "VALIDATION_FAILED"
+	CodeInvalidToken      Code = "INVALID_TOKEN"
+	CodeInvalidAttachment Code = "INVALID_ATTACHMENT"
+
+	// 401・403
+	CodeUnauthorized       Code = "UNAUTHORIZED"
+	CodeInvalidCredentials Code = "INVALID_CREDENTIALS"
+	CodeForbidden          Code = "FORBIDDEN"
+	CodeEmailNotVerified   Code = "EMAIL_NOT_VERIFIED"
+	CodeAccountSuspended   Code = "ACCOUNT_SUSPENDED"
+	// 退会手続き中（ログインし直すと取り消される）
+	CodeAccountPendingDeletion Code = "ACCOUNT_PENDING_DELETION"
+
+	// 404
+	CodeNotFound         Code = "NOT_FOUND"
+	CodeUserNotFound     Code = "USER_NOT_FOUND"
+	CodePostNotFound     Code = "POST_NOT_FOUND"
+	CodeThreadNotFound   Code = "THREAD_NOT_FOUND"
+	CodeEventNotFound    Code = "EVENT_NOT_FOUND"
+	CodeContentNotFound  Code = "CONTENT_NOT_FOUND"
+	CodeReportNotFound   Code = "REPORT_NOT_FOUND"
+	CodeExportNotFound   Code = "EXPORT_NOT_FOUND"
+	CodeIdentityNotFound Code = "IDENTITY_NOT_FOUND"
+
+	// 409
+	CodeConflict         Code = "CONFLICT"
+	CodeAccountExists    Code = "ACCOUNT_EXISTS"
+	CodeIdentityConflict Code = "IDENTITY_CONFLICT"
+	CodeLastLoginMethod  Code = "LAST_LOGIN_METHOD"
+	CodeAlreadyVerified  Code = "ALREADY_VERIFIED"
+	CodeAlreadyReported  Code = "ALREADY_REPORTED"
+	CodeAlreadyResolved  Code = "ALREADY_RESOLVED"
+
+	// 413・415・422・429
+	CodePayloadTooLarge      Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeContentRejected      Code = "CONTENT_REJECTED"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodeAccountLocked        Code = "ACCOUNT_LOCKED"
+
+	// 5xx
+	CodeInternal           Code = "INTERNAL"
+	CodeServiceUnavailable Code = "SERVICE_UNAVAILABLE"
+	CodeTimeout            Code = "TIMEOUT"
+)
+
+// FieldError は入力項目毎の検証エラー
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error は HTTP ステータスとコードを持つエラー
+// ハンドラーは Abort で返し、Middleware がレスポンスに変換する
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details []FieldError
+	// 原因（ログにのみ出力し、レスポンスには含めない）
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is はコードが同じなら同じエラーとみなす（errors.Is でセンチネルと比較するため）
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap は原因を付けたコピーを返す
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// WithMessage はメッセージを差し替えたコピーを返す
+func (e *Error) WithMessage(format string, args ...interface{}) *Error {
+	c := *e
+	c.Message = fmt.Sprintf(format, args...)
+	return &c
+}
+
+// WithDetails は項目毎のエラーを追加したコピーを返す
+func (e *Error) WithDetails(details ...FieldError) *Error {
+	c := *e
+	c.Details = append(append([]FieldError(nil), e.Details...), details...)
+	return &c
+}
+
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(code Code, message string) *Error {
+	return New(http.StatusBadRequest, code, message)
+}
+
+func Unauthorized(code Code, message string) *Error {
+	return New(http.StatusUnauthorized, code, message)
+}
+
+func Forbidden(code Code, message string) *Error {
+	return New(http.StatusForbidden, code, message)
+}
+
+func NotFound(code Code, message string) *Error {
+	return New(http.StatusNotFound, code, message)
+}
+
+func Conflict(code Code, message string) *Error {
+	return New(http.StatusConflict, code, message)
+}
+
+// Validation は入力項目の検証エラー（field は JSON の項目名）
+func Validation(field, message string) *Error {
+	return BadRequest(CodeValidationFailed, "validation failed").WithDetails(FieldError{Field: field, Message: message})
+}
+
+// Internal は 500。err はログにのみ出力する
+func Internal(message string, err error) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, message).Wrap(err)
+}
@@ -0,0 +1,202 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Body はエラーレスポンスの本体
+//
+//	{"error": {"code": "POST_NOT_FOUND", "message": "post not found", "request_id": "..."}}
+type Body struct {
+	Code      Code         `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// Abort はエラーを記録してハンドラーの連鎖を止める（レスポンスは Middleware が書く）
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Middleware はハンドラーが記録したエラーをレスポンスに変換する
+// 全てのルートより前（gin.Engine.Use）に登録する
+func Middleware() gin.HandlerFunc {
+	SetupValidator()
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		Write(c, c.Errors.Last().Err)
+	}
+}
+
+// Recovery は panic を 500 のエラーレスポンスにする
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		Write(c, Internal("internal server error", fmt.Errorf("panic: %v", recovered)))
+	})
+}
+
+// Write は err をエラーレスポンスとして書き込む
+func Write(c *gin.Context, err error) {
+	e := From(err)
+	ctx := c.Request.Context()
+	if e.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(ctx, "request failed", "code", e.Code, "message", e.Message, "error", e.Err)
+	} else if e.Err != nil {
+		slog.DebugContext(ctx, "request rejected", "code", e.Code, "error", e.Err)
+	}
+	c.AbortWithStatusJSON(e.Status, gin.H{"error": Body{
+		Code:      e.Code,
+		Message:   e.Message,
+		Details:   e.Details,
+		RequestID: c.GetString("request_id"),
+	}})
+}
+
+// From は err を *Error に変換する。既知のエラー以外は 500 にする
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	var (
+		validationErrs validator.ValidationErrors
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+		maxBytesErr    *http.MaxBytesError
+	)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return NotFound(CodeNotFound, "resource not found").Wrap(err)
+	case errors.As(err, &validationErrs):
+		return fromValidation(validationErrs)
+	case errors.As(err, &typeErr):
+		return BadRequest(CodeValidationFailed, "validation failed").
+			WithDetails(FieldError{Field: typeErr.Field, Message: "must be " + typeErr.Type.String()}).Wrap(err)
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return BadRequest(CodeInvalidJSON, "invalid json format").Wrap(err)
+	case errors.As(err, &maxBytesErr):
+		return New(http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "request body is too large").Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(http.StatusGatewayTimeout, CodeTimeout, "request timed out").Wrap(err)
+	case errors.Is(err, context.Canceled):
+		return New(http.StatusServiceUnavailable, CodeServiceUnavailable, "request canceled").Wrap(err)
+	}
+	return Internal("internal server error", err)
+}
+
+var setupValidator sync.Once
+
+// SetupValidator は binding の検証ルールを追加し、検証エラーの項目名を JSON の名前にする
+// Middleware が呼ぶため、通常は明示的に呼ぶ必要はない
+func SetupValidator() {
+	setupValidator.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(jsonFieldName)
+		// future: 現在より後の日時（イベントの開催日時等）
+		_ = v.RegisterValidation("future", func(fl validator.FieldLevel) bool {
+			t, ok := fl.Field().Interface().(time.Time)
+			return ok && t.After(time.Now())
+		})
+	})
+}
+
+// jsonFieldName は検証エラーの項目名に使う名前（json / form / uri タグ）
+func jsonFieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.Split(f.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+func fromValidation(errs validator.ValidationErrors) *Error {
+	e := BadRequest(CodeValidationFailed, "validation failed").Wrap(errs)
+	for _, fe := range errs {
+		// 入れ子の項目は coordinate.lat の形にする（先頭の構造体名は除く）
+		field := fe.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		e = e.WithDetails(FieldError{Field: field, Message: ruleMessage(fe)})
+	}
+	return e
+}
+
+// ruleMessage は検証ルールの違反を説明する
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return "must be at least " + fe.Param() + unit(fe)
+	case "max", "lte":
+		return "must be at most " + fe.Param() + unit(fe)
+	case "oneof":
+		return "must be one of " + fe.Param()
+	case "email":
+		return "must be a valid email address"
+	case "future":
+		return "must be in the future"
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed the %s=%s rule", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
+
+// Bind はリクエストの読み込み（ShouldBind 等）のエラーを 400 に変換する
+func Bind(err error) *Error {
+	e := From(err)
+	if e.Status >= http.StatusInternalServerError {
+		return BadRequest(CodeInvalidRequest, "invalid request").Wrap(err)
+	}
+	return e
+}
+
+// Lookup は gorm.ErrRecordNotFound を notFound に、それ以外を 500 に変換する
+func Lookup(err error, notFound *Error) *Error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound.Wrap(err)
+	}
+	return Internal("internal server error", err)
+}
+
+// unit は文字列・配列の長さの制限の単位
+func unit(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	}
+	return ""
+}
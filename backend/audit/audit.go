@@ -0,0 +1,187 @@
+// Package audit は状態を変更する操作（投稿等の作成・編集・削除、モデレーション、管理者の操作）を監査ログ（audit_logs）に記録する
+//
+// 監査ログは追記のみで、変更・削除はデータベースのトリガーで拒否する（db.AutoMigrate で作成する）
+// 記録は操作と同じトランザクションで行い、記録できなければ操作も取り消す
+package audit
+
+import (
+	"api/types"
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 操作の種類（"対象.操作"）
+const (
+	ActionUserSuspend   = "user.suspend"
+	ActionUserBan       = "user.ban"
+	ActionUserReinstate = "user.reinstate"
+	ActionUserRole      = "user.role"
+	ActionUserUpdate    = "user.update"
+	ActionUserDelete    = "user.delete" // 退会の手続き
+	ActionContentExport = "content.export"
+	ActionContentImport = "content.import"
+	ActionReportCreate  = "report.create"
+	ActionReportDismiss = "report.dismiss"
+)
+
+// パスワード・ログイン方法・アバターの変更（パスワードのハッシュは記録しない）
+const (
+	ActionUserPassword      = "user.password"
+	ActionUserPasswordReset = "user.password_reset"
+	ActionUserLink          = "user.identity_link"
+	ActionUserUnlink        = "user.identity_unlink"
+	ActionUserAvatar        = "user.avatar"
+)
+
+// 投稿・スレッド・イベント・コメントへの操作（ContentAction で "post.create" 等にする）
+const (
+	OpCreate   = "create"
+	OpUpdate   = "update"
+	OpDelete   = "delete"
+	OpHide     = "hide"     // モデレーターによる非表示・通報による自動非表示
+	OpRestore  = "restore"  // モデレーターによる再表示
+	OpUndelete = "undelete" // 管理者による削除の取り消し
+)
+
+// 操作の対象の種類（投稿等は post / thread / event / comment）
+const (
+	TargetUser    = "user"
+	TargetReport  = "report"
+	TargetContent = "content" // 一括エクスポート・取り込み
+)
+
+// ContentAction は投稿等への操作の種類を返す（例: post.update）
+func ContentAction(targetType, op string) string {
+	return targetType + "." + op
+}
+
+// ignoredFields は変更の差分に含めない項目（操作の度に変わるもの）
+var ignoredFields = map[string]bool{"updated_at": true}
+
+// Entry は1件の操作
+type Entry struct {
+	// 操作したユーザー（uuid.Nil の場合は CLI・定期実行・自動の処理）
+	Actor      uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	// 変更前・変更後の対象（JSON にできる値）
+	// 両方ある場合は異なる項目のみ、作成は After のみ、削除は Before のみを保存する
+	Before any
+	After  any
+	// 操作の補足（JSON にして保存する）
+	Detail    any
+	IP        string
+	RequestID string
+}
+
+// Record は tx で監査ログを1件追加する
+func Record(tx *gorm.DB, e Entry) error {
+	log := types.AuditLog{
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		IP:         e.IP,
+		RequestID:  e.RequestID,
+	}
+	if e.Actor != uuid.Nil {
+		actor := e.Actor
+		log.ActorID = &actor
+	}
+
+	var err error
+	if e.Before != nil && e.After != nil {
+		log.Before, log.After, err = Diff(e.Before, e.After)
+	} else {
+		if log.Before, err = encode(e.Before); err == nil {
+			log.After, err = encode(e.After)
+		}
+	}
+	if err == nil {
+		log.Detail, err = encode(e.Detail)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode audit log: %w", err)
+	}
+
+	if err := tx.Create(&log).Error; err != nil {
+		return fmt.Errorf("failed to record audit log: %w", err)
+	}
+	return nil
+}
+
+// Diff は before と after を JSON のオブジェクトにして比べ、値が異なる項目のみを返す
+// 一方にのみある項目は、もう一方では null とする
+func Diff(before, after any) (json.RawMessage, json.RawMessage, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changedBefore := map[string]json.RawMessage{}
+	changedAfter := map[string]json.RawMessage{}
+	for key, av := range a {
+		if bv, ok := b[key]; !ignoredFields[key] && (!ok || !bytes.Equal(bv, av)) {
+			changedBefore[key] = orNull(bv)
+			changedAfter[key] = av
+		}
+	}
+	for key, bv := range b {
+		if _, ok := a[key]; !ok && !ignoredFields[key] {
+			changedBefore[key] = bv
+			changedAfter[key] = orNull(nil)
+		}
+	}
+
+	rb, err := json.Marshal(changedBefore)
+	if err != nil {
+		return nil, nil, err
+	}
+	ra, err := json.Marshal(changedAfter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rb, ra, nil
+}
+
+// fields は v を JSON のオブジェクトにして項目毎の値を返す（値は比較のため正規化する）
+func fields(v any) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("%T is not a JSON object: %w", v, err)
+	}
+	for key, value := range m {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, value); err != nil {
+			return nil, err
+		}
+		m[key] = buf.Bytes()
+	}
+	return m, nil
+}
+
+func orNull(v json.RawMessage) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+func encode(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
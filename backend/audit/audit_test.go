@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type snapshot struct {
+	Content   string   `json:"content"`
+	Tags      []string `json:"tags"`
+	Valid     bool     `json:"valid"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// 値が異なる項目のみを返し、updated_at は無視すること
+func TestDiff(t *testing.T) {
+	before := snapshot{Content: "a", Tags: []string{"x"}, Valid: true, UpdatedAt: "1"}
+	after := snapshot{Content: "b", Tags: []string{"x"}, Valid: true, UpdatedAt: "2"}
+
+	b, a, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"content":"a"}` || string(a) != `{"content":"b"}` {
+		t.Errorf("diff = %s -> %s", b, a)
+	}
+}
+
+// 一方にのみある項目は、もう一方を null にすること
+func TestDiffMissingFields(t *testing.T) {
+	b, a, err := Diff(map[string]any{"valid": true, "old": 1}, map[string]any{"valid": true, "new": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var before, after map[string]any
+	if err := json.Unmarshal(b, &before); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(a, &after); err != nil {
+		t.Fatal(err)
+	}
+	if len(before) != 2 || before["old"] != float64(1) || before["new"] != nil {
+		t.Errorf("before = %v", before)
+	}
+	if len(after) != 2 || after["new"] != "x" || after["old"] != nil {
+		t.Errorf("after = %v", after)
+	}
+}
+
+// JSON のオブジェクトにならない値は比較できないこと
+func TestDiffNotObject(t *testing.T) {
+	if _, _, err := Diff("a", "b"); err == nil {
+		t.Error("diff of strings should fail")
+	}
+}
@@ -0,0 +1,146 @@
+// Package bulk は投稿・スレッド・イベントを GeoJSON・NDJSON・CSV で一括エクスポート・インポートする
+//
+// 管理者用の API（GET /admin/export・POST /admin/import）と CLI（api export・api import）で使う
+// どの形式も Record と同じ項目を持つため、エクスポートしたファイルはそのまま取り込める（同じ内容は重複として飛ばす）
+package bulk
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ファイルの形式
+const (
+	FormatGeoJSON = "geojson" // FeatureCollection（Point の geometry と properties）
+	FormatNDJSON  = "ndjson"  // 1行に1件の JSON
+	FormatCSV     = "csv"     // ヘッダー行のある CSV（tags は | 区切り）
+)
+
+// Formats は指定できる形式
+var Formats = []string{FormatGeoJSON, FormatNDJSON, FormatCSV}
+
+// 取り込み・書き出しの対象の種類
+const (
+	KindPost   = "post"
+	KindThread = "thread"
+	KindEvent  = "event"
+)
+
+// Kinds は指定できる種類
+var Kinds = []string{KindPost, KindThread, KindEvent}
+
+// MaxRows は1回に取り込める行数
+const MaxRows = 10000
+
+// ContentType は形式の MIME タイプ
+func ContentType(format string) string {
+	switch format {
+	case FormatGeoJSON:
+		return "application/geo+json"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+// ValidFormat は形式の値が正しいか判定する
+func ValidFormat(format string) bool {
+	return contains(Formats, format)
+}
+
+// FormatOf はファイル名の拡張子から形式を判定する（判定できない場合は空文字）
+func FormatOf(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".geojson", ".json":
+		return FormatGeoJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	case ".csv":
+		return FormatCSV
+	}
+	return ""
+}
+
+// Extension は形式のファイルの拡張子
+func Extension(format string) string {
+	return "." + format
+}
+
+// ValidKind は種類の値が正しいか判定する
+func ValidKind(kind string) bool {
+	return contains(Kinds, kind)
+}
+
+// Record は1件の投稿・スレッド・イベント
+// 取り込み時は type・content・category・lat・lng・precision・tags・event_date のみ使い、他の項目は無視する
+type Record struct {
+	Type      string     `json:"type"`
+	ID        uint       `json:"id,omitempty"`
+	UserID    string     `json:"user_id,omitempty"`
+	Username  string     `json:"username,omitempty"`
+	Content   string     `json:"content"`
+	Category  string     `json:"category"`
+	Lat       *float64   `json:"lat,omitempty"` // GeoJSON では geometry に入れる
+	Lng       *float64   `json:"lng,omitempty"`
+	Precision string     `json:"precision,omitempty"`
+	Tags      []string   `json:"tags"`
+	Like      int        `json:"like"`
+	Valid     bool       `json:"valid"`
+	EventDate *time.Time `json:"event_date,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// BBox は範囲（GeoJSON と同じ 西端の経度,南端の緯度,東端の経度,北端の緯度 の順）
+type BBox struct {
+	MinLng float64 `json:"min_lng"`
+	MinLat float64 `json:"min_lat"`
+	MaxLng float64 `json:"max_lng"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// ParseBBox は "minLng,minLat,maxLng,maxLat" を読み取る
+func ParseBBox(s string) (*BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox must be minLng,minLat,maxLng,maxLat")
+	}
+	var v [4]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bbox must be minLng,minLat,maxLng,maxLat")
+		}
+		v[i] = f
+	}
+	b := &BBox{MinLng: v[0], MinLat: v[1], MaxLng: v[2], MaxLat: v[3]}
+	if b.MinLng < -180 || b.MaxLng > 180 || b.MinLat < -90 || b.MaxLat > 90 || b.MinLng > b.MaxLng || b.MinLat > b.MaxLat {
+		return nil, fmt.Errorf("bbox is out of range")
+	}
+	return b, nil
+}
+
+// ParseList はカンマ区切りの値を読み取る（空の値は除く）
+func ParseList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
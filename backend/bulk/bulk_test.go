@@ -0,0 +1,219 @@
+package bulk
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func float(f float64) *float64 { return &f }
+
+func sampleRecords() []Record {
+	date := time.Date(2026, 11, 3, 10, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	return []Record{
+		{
+			Type: KindPost, ID: 1, UserID: "6f1c0a4e-0000-4000-8000-000000000001", Username: "さくら",
+			Content: "駅前の桜が満開です, \"見頃\"", Category: "community", Lat: float(35.6812), Lng: float(139.7671),
+			Precision: "exact", Tags: []string{"桜", "季節"}, Like: 3, Valid: true, CreatedAt: &created, UpdatedAt: &created,
+		},
+		{
+			Type: KindEvent, ID: 2, Content: "防災訓練\n消火器の使い方を体験できます", Category: "disaster",
+			Lat: float(34.7025), Lng: float(135.4959), Precision: "city", Tags: []string{}, EventDate: &date,
+			CreatedAt: &created, UpdatedAt: &created,
+		},
+	}
+}
+
+// 書き出したファイルを読み取ると同じ内容になる
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := newEncoder(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := sampleRecords()
+			for _, r := range want {
+				if err := enc.encode(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := enc.close(); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, errs, err := Decode(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(errs) > 0 {
+				t.Fatalf("unexpected row errors: %+v", errs)
+			}
+			if len(rows) != len(want) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(want))
+			}
+			for i, row := range rows {
+				got, w := row.Record, want[i]
+				if got.Type != w.Type || got.Content != w.Content || got.Category != w.Category || got.Precision != w.Precision {
+					t.Errorf("row %d: got %+v, want %+v", i, got, w)
+				}
+				if *got.Lat != *w.Lat || *got.Lng != *w.Lng {
+					t.Errorf("row %d: coordinate %v,%v, want %v,%v", i, *got.Lat, *got.Lng, *w.Lat, *w.Lng)
+				}
+				if len(got.Tags)+len(w.Tags) > 0 && !reflect.DeepEqual(got.Tags, w.Tags) {
+					t.Errorf("row %d: tags %v, want %v", i, got.Tags, w.Tags)
+				}
+				if (got.EventDate == nil) != (w.EventDate == nil) || (w.EventDate != nil && !got.EventDate.Equal(*w.EventDate)) {
+					t.Errorf("row %d: event_date %v, want %v", i, got.EventDate, w.EventDate)
+				}
+			}
+		})
+	}
+}
+
+func TestEmptyGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	enc, _ := newEncoder(&buf, FormatGeoJSON)
+	if err := enc.close(); err != nil {
+		t.Fatal(err)
+	}
+	rows, errs, err := Decode(&buf, FormatGeoJSON)
+	if err != nil || len(rows) != 0 || len(errs) != 0 {
+		t.Fatalf("got rows=%v errs=%v err=%v", rows, errs, err)
+	}
+}
+
+// 行単位の誤りは行番号付きで返し、他の行は読み取る
+func TestDecodeRowErrors(t *testing.T) {
+	cases := []struct {
+		format string
+		input  string
+		rows   int
+		errs   []RowError
+	}{
+		{
+			format: FormatCSV,
+			input:  "content,lat,lng,event_date\nok,35,139,\nbad lat,north,139,\nbad date,35,139,tomorrow\n",
+			rows:   1,
+			errs:   []RowError{{Line: 3, Field: "lat"}, {Line: 4, Field: "event_date"}},
+		},
+		{
+			format: FormatNDJSON,
+			input:  "{\"content\":\"ok\",\"lat\":35,\"lng\":139}\n\n{\"content\":\n{\"content\":1}\n",
+			rows:   1,
+			errs:   []RowError{{Line: 3}, {Line: 4}},
+		},
+		{
+			format: FormatGeoJSON,
+			input: `{"type":"FeatureCollection","features":[
+				{"type":"Feature","geometry":{"type":"Point","coordinates":[139,35]},"properties":{"content":"ok"}},
+				{"type":"Feature","geometry":{"type":"LineString","coordinates":[[139,35],[140,36]]},"properties":{"content":"line"}},
+				{"type":"Feature","geometry":null,"properties":{"content":"none"}}
+			]}`,
+			rows: 1,
+			errs: []RowError{{Line: 2, Field: "geometry"}, {Line: 3, Field: "geometry"}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			rows, errs, err := Decode(strings.NewReader(tc.input), tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != tc.rows {
+				t.Errorf("got %d rows, want %d", len(rows), tc.rows)
+			}
+			if len(errs) != len(tc.errs) {
+				t.Fatalf("got errors %+v, want %+v", errs, tc.errs)
+			}
+			for i, e := range errs {
+				if e.Line != tc.errs[i].Line || e.Field != tc.errs[i].Field || e.Message == "" {
+					t.Errorf("error %d: got %+v, want line %d field %q", i, e, tc.errs[i].Line, tc.errs[i].Field)
+				}
+			}
+		})
+	}
+}
+
+// ファイル全体を読めない場合は FileError を返す
+func TestDecodeFileErrors(t *testing.T) {
+	cases := []struct{ format, input string }{
+		{FormatCSV, ""},
+		{FormatCSV, "title,lat,lng\nx,1,2\n"},
+		{FormatGeoJSON, `{"type":"Feature"}`},
+		{FormatGeoJSON, `not json`},
+	}
+	for _, tc := range cases {
+		_, _, err := Decode(strings.NewReader(tc.input), tc.format)
+		var fileErr *FileError
+		if !errors.As(err, &fileErr) {
+			t.Errorf("%s %q: got %v, want FileError", tc.format, tc.input, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	date := time.Now().Add(48 * time.Hour)
+	valid := Record{Type: KindEvent, Content: "マルシェ", Lat: float(35), Lng: float(139), EventDate: &date}
+
+	cases := []struct {
+		name   string
+		edit   func(r *Record)
+		opts   ImportOptions
+		fields []string
+	}{
+		{"valid", func(r *Record) {}, ImportOptions{}, nil},
+		{"default type", func(r *Record) { r.Type = "" }, ImportOptions{Kind: KindEvent}, nil},
+		{"missing type", func(r *Record) { r.Type = "" }, ImportOptions{}, []string{"type"}},
+		{"unknown type", func(r *Record) { r.Type = "comment" }, ImportOptions{}, []string{"type"}},
+		{"blank content", func(r *Record) { r.Content = "  " }, ImportOptions{}, []string{"content"}},
+		{"long content", func(r *Record) { r.Content = strings.Repeat("あ", 2001) }, ImportOptions{}, []string{"content"}},
+		{"missing coordinate", func(r *Record) { r.Lat = nil }, ImportOptions{}, []string{"coordinate"}},
+		{"lat out of range", func(r *Record) { r.Lat = float(91) }, ImportOptions{}, []string{"lat"}},
+		{"unknown category", func(r *Record) { r.Category = "sports" }, ImportOptions{}, []string{"category"}},
+		{"invalid precision", func(r *Record) { r.Precision = "street" }, ImportOptions{}, []string{"precision"}},
+		{"too many tags", func(r *Record) { r.Tags = make([]string, 11) }, ImportOptions{}, []string{"tags", "tags"}},
+		{"missing event date", func(r *Record) { r.EventDate = nil }, ImportOptions{}, []string{"event_date"}},
+		{"post without event date", func(r *Record) { r.Type, r.EventDate = KindPost, nil }, ImportOptions{}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := valid
+			tc.edit(&rec)
+			c, errs := validate(Row{Line: 7, Record: rec}, tc.opts)
+			var fields []string
+			for _, e := range errs {
+				if e.Line != 7 {
+					t.Errorf("line %d, want 7", e.Line)
+				}
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tc.fields) {
+				t.Errorf("got errors %+v, want fields %v", errs, tc.fields)
+			}
+			if len(errs) == 0 && (c.category.ID == "" || c.precision == "") {
+				t.Errorf("defaults not applied: %+v", c)
+			}
+		})
+	}
+}
+
+func TestParseBBox(t *testing.T) {
+	b, err := ParseBBox("139.5, 35.5, 140, 36")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *b != (BBox{MinLng: 139.5, MinLat: 35.5, MaxLng: 140, MaxLat: 36}) {
+		t.Errorf("got %+v", b)
+	}
+	for _, s := range []string{"", "1,2,3", "140,35,139,36", "a,b,c,d", "-181,0,0,0"} {
+		if _, err := ParseBBox(s); err == nil {
+			t.Errorf("%q should be rejected", s)
+		}
+	}
+}
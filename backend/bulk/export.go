@@ -0,0 +1,130 @@
+package bulk
+
+import (
+	"api/db"
+	"api/geo"
+	"api/types"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// exportBatch は一度に DB から読み込む行数
+const exportBatch = 500
+
+// Filter はエクスポートの条件（ゼロ値の項目は絞り込まない）
+// 監査ログに条件を残すため JSON にできるようにしている
+type Filter struct {
+	Kinds      []string  `json:"kinds,omitempty"` // 空の場合は全ての種類
+	BBox       *BBox     `json:"bbox,omitempty"`
+	From       time.Time `json:"from"` // 作成日時がこれ以降
+	To         time.Time `json:"to"`   // 作成日時がこれより前
+	Categories []string  `json:"categories,omitempty"`
+	// 非表示（モデレーター確認待ち・非表示にしたもの）も含める
+	IncludeHidden bool `json:"include_hidden"`
+}
+
+// Export は条件に合う投稿・スレッド・イベントを format の形式で w に書き出し、件数を返す
+// 座標は公開 API と同じく投稿者が選んだ精度でぼかす（範囲の絞り込みは DB 上の正確な座標で行う）
+func Export(ctx context.Context, w io.Writer, format string, f Filter) (int, error) {
+	enc, err := newEncoder(w, format)
+	if err != nil {
+		return 0, err
+	}
+	kinds := f.Kinds
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+
+	count := 0
+	for _, kind := range kinds {
+		var n int
+		switch kind {
+		case KindPost:
+			n, err = exportRows(ctx, enc, f, func(p *types.Post) Record {
+				return record(KindPost, p.ID, p.UserID, p.Username, p.Content, p.Category, p.Coordinate, p.Precision, p.Tags, p.Like, p.Valid, p.CreatedAt, p.UpdatedAt)
+			})
+		case KindThread:
+			n, err = exportRows(ctx, enc, f, func(t *types.Thread) Record {
+				return record(KindThread, t.ID, t.UserID, t.Username, t.Content, t.Category, t.Coordinate, t.Precision, t.Tags, t.Like, t.Valid, t.CreatedAt, t.UpdatedAt)
+			})
+		case KindEvent:
+			n, err = exportRows(ctx, enc, f, func(e *types.Event) Record {
+				r := record(KindEvent, e.ID, e.UserID, e.Username, e.Content, e.Category, e.Coordinate, e.Precision, e.Tags, e.Like, e.Valid, e.CreatedAt, e.UpdatedAt)
+				date := e.EventDate
+				r.EventDate = &date
+				return r
+			})
+		default:
+			err = fmt.Errorf("unknown type %q", kind)
+		}
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, enc.close()
+}
+
+// exportRows は M の行を ID 順に exportBatch 件ずつ読み込んで書き出す
+func exportRows[M any](ctx context.Context, enc encoder, f Filter, toRecord func(*M) Record) (int, error) {
+	var batch []M
+	count := 0
+	result := filtered(db.Ctx(ctx), f).FindInBatches(&batch, exportBatch, func(tx *gorm.DB, n int) error {
+		for i := range batch {
+			if err := enc.encode(toRecord(&batch[i])); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, result.Error
+}
+
+func filtered(tx *gorm.DB, f Filter) *gorm.DB {
+	if !f.IncludeHidden {
+		tx = tx.Scopes(db.Visible)
+	}
+	if f.BBox != nil {
+		tx = tx.Where("lat BETWEEN ? AND ? AND lng BETWEEN ? AND ?", f.BBox.MinLat, f.BBox.MaxLat, f.BBox.MinLng, f.BBox.MaxLng)
+	}
+	if !f.From.IsZero() {
+		tx = tx.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		tx = tx.Where("created_at < ?", f.To)
+	}
+	if len(f.Categories) > 0 {
+		tx = tx.Where("category IN ?", f.Categories)
+	}
+	return tx
+}
+
+func record(kind string, id uint, userID uuid.UUID, username, content, category string, at types.Coordinate,
+	precision string, tags []string, like int, valid bool, created, updated time.Time) Record {
+	lat, lng := geo.Fuzz(at.Lat, at.Lng, precision, fmt.Sprintf("%s:%d", kind, id))
+	if tags == nil {
+		tags = []string{}
+	}
+	return Record{
+		Type:      kind,
+		ID:        id,
+		UserID:    userID.String(),
+		Username:  username,
+		Content:   content,
+		Category:  category,
+		Lat:       &lat,
+		Lng:       &lng,
+		Precision: precision,
+		Tags:      tags,
+		Like:      like,
+		Valid:     valid,
+		CreatedAt: &created,
+		UpdatedAt: &updated,
+	}
+}
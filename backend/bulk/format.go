@@ -0,0 +1,386 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// columns は CSV の列（エクスポートはこの順で書き出す。取り込みは列名で読み取り、順序は問わない）
+var columns = []string{
+	"type", "id", "user_id", "username", "content", "category", "lat", "lng",
+	"precision", "tags", "like", "valid", "event_date", "created_at", "updated_at",
+}
+
+// tagSeparator は CSV の tags の区切り文字
+const tagSeparator = "|"
+
+// jst は CSV の日時にタイムゾーンが無い場合に使う（自治体のカレンダー等は日本時間で書かれている）
+var jst = time.FixedZone("JST", 9*60*60)
+
+// localLayouts は CSV で受け付けるタイムゾーン無しの日時の書式
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006/01/02 15:04"}
+
+// encoder は1件ずつ書き出す
+type encoder interface {
+	encode(r Record) error
+	close() error
+}
+
+func newEncoder(w io.Writer, format string) (encoder, error) {
+	switch format {
+	case FormatGeoJSON:
+		return &geojsonEncoder{w: w}, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{w: cw}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type point struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"` // 経度・緯度の順
+}
+
+type feature struct {
+	Type       string `json:"type"`
+	ID         uint   `json:"id,omitempty"`
+	Geometry   *point `json:"geometry"`
+	Properties Record `json:"properties"`
+}
+
+// geojsonEncoder は FeatureCollection を1件ずつ書き出す（全件をメモリに載せない）
+type geojsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *geojsonEncoder) encode(r Record) error {
+	f := feature{Type: "Feature", ID: r.ID, Properties: r}
+	if r.Lat != nil && r.Lng != nil {
+		f.Geometry = &point{Type: "Point", Coordinates: []float64{*r.Lng, *r.Lat}}
+	}
+	f.Properties.Lat, f.Properties.Lng = nil, nil
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	prefix := ",\n"
+	if e.count == 0 {
+		prefix = `{"type":"FeatureCollection","features":[` + "\n"
+	}
+	e.count++
+	_, err = io.WriteString(e.w, prefix+string(data))
+	return err
+}
+
+func (e *geojsonEncoder) close() error {
+	end := "\n]}\n"
+	if e.count == 0 {
+		end = `{"type":"FeatureCollection","features":[]}` + "\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) encode(r Record) error { return e.enc.Encode(r) }
+func (e *ndjsonEncoder) close() error          { return nil }
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) encode(r Record) error {
+	row := make([]string, len(columns))
+	for i, col := range columns {
+		switch col {
+		case "type":
+			row[i] = r.Type
+		case "id":
+			row[i] = strconv.FormatUint(uint64(r.ID), 10)
+		case "user_id":
+			row[i] = r.UserID
+		case "username":
+			row[i] = r.Username
+		case "content":
+			row[i] = r.Content
+		case "category":
+			row[i] = r.Category
+		case "lat":
+			row[i] = formatFloat(r.Lat)
+		case "lng":
+			row[i] = formatFloat(r.Lng)
+		case "precision":
+			row[i] = r.Precision
+		case "tags":
+			row[i] = strings.Join(r.Tags, tagSeparator)
+		case "like":
+			row[i] = strconv.Itoa(r.Like)
+		case "valid":
+			row[i] = strconv.FormatBool(r.Valid)
+		case "event_date":
+			row[i] = formatTime(r.EventDate)
+		case "created_at":
+			row[i] = formatTime(r.CreatedAt)
+		case "updated_at":
+			row[i] = formatTime(r.UpdatedAt)
+		}
+	}
+	return e.w.Write(row)
+}
+
+func (e *csvEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Row は読み取った1行（Line は行の位置。CSV・NDJSON はファイルの行番号、GeoJSON は features の何番目か）
+type Row struct {
+	Line   int
+	Record Record
+}
+
+// Decode は r から全ての行を読み取る
+// 行単位の誤り（値の形式等）は RowError として返し、ファイル全体を読めない場合は error を返す
+func Decode(r io.Reader, format string) ([]Row, []RowError, error) {
+	switch format {
+	case FormatGeoJSON:
+		return decodeGeoJSON(r)
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	case FormatCSV:
+		return decodeCSV(r)
+	}
+	return nil, nil, fmt.Errorf("unknown format %q", format)
+}
+
+// FileError はファイル全体を読み取れない場合のエラー（形式の誤り・行数の超過等）
+type FileError struct {
+	Message string
+}
+
+func (e *FileError) Error() string { return e.Message }
+
+func fileError(format string, args ...any) error {
+	return &FileError{Message: fmt.Sprintf(format, args...)}
+}
+
+var errTooManyRows = fileError("too many rows (max %d)", MaxRows)
+
+func decodeGeoJSON(r io.Reader) ([]Row, []RowError, error) {
+	var fc struct {
+		Type     string            `json:"type"`
+		Features []json.RawMessage `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, nil, fileError("invalid GeoJSON: %s", jsonMessage(err))
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, nil, fileError("invalid GeoJSON: type must be FeatureCollection")
+	}
+	if len(fc.Features) > MaxRows {
+		return nil, nil, errTooManyRows
+	}
+
+	var rows []Row
+	var errs []RowError
+	for i, raw := range fc.Features {
+		line := i + 1
+		// Point 以外の geometry も読めるよう、座標は種類を確かめてから読み取る
+		var f struct {
+			Geometry *struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties Record `json:"properties"`
+		}
+		if err := json.Unmarshal(raw, &f); err != nil {
+			errs = append(errs, RowError{Line: line, Message: "invalid feature: " + jsonMessage(err)})
+			continue
+		}
+		var coordinates []float64
+		if f.Geometry == nil || f.Geometry.Type != "Point" ||
+			json.Unmarshal(f.Geometry.Coordinates, &coordinates) != nil || len(coordinates) < 2 {
+			errs = append(errs, RowError{Line: line, Field: "geometry", Message: "must be a Point"})
+			continue
+		}
+		rec := f.Properties
+		lng, lat := coordinates[0], coordinates[1]
+		rec.Lat, rec.Lng = &lat, &lng
+		rows = append(rows, Row{Line: line, Record: rec})
+	}
+	return rows, errs, nil
+}
+
+func decodeNDJSON(r io.Reader) ([]Row, []RowError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []Row
+	var errs []RowError
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(rows)+len(errs) >= MaxRows {
+			return nil, nil, errTooManyRows
+		}
+		var rec Record
+		if err := json.Unmarshal(text, &rec); err != nil {
+			errs = append(errs, RowError{Line: line, Message: "invalid JSON: " + jsonMessage(err)})
+			continue
+		}
+		rows = append(rows, Row{Line: line, Record: rec})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fileError("invalid NDJSON: %v", err)
+	}
+	return rows, errs, nil
+}
+
+func decodeCSV(r io.Reader) ([]Row, []RowError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, fileError("invalid CSV: missing header row")
+	}
+	if err != nil {
+		return nil, nil, fileError("invalid CSV: %v", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		index[name] = i
+	}
+	for _, required := range []string{"content", "lat", "lng"} {
+		if _, ok := index[required]; !ok {
+			return nil, nil, fileError("invalid CSV: missing %s column", required)
+		}
+	}
+
+	var rows []Row
+	var errs []RowError
+	for {
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				errs = append(errs, RowError{Line: parseErr.Line, Message: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(rows)+len(errs) >= MaxRows {
+			return nil, nil, errTooManyRows
+		}
+		line, _ := cr.FieldPos(0)
+		value := func(col string) string {
+			if i, ok := index[col]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		rec := Record{
+			Type:      value("type"),
+			Content:   value("content"),
+			Category:  value("category"),
+			Precision: value("precision"),
+		}
+		var rowErrs []RowError
+		for _, f := range []struct {
+			col string
+			dst **float64
+		}{{"lat", &rec.Lat}, {"lng", &rec.Lng}} {
+			v := value(f.col)
+			if v == "" {
+				continue
+			}
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				rowErrs = append(rowErrs, RowError{Line: line, Field: f.col, Message: "must be a number"})
+				continue
+			}
+			*f.dst = &n
+		}
+		if tags := value("tags"); tags != "" {
+			for _, tag := range strings.Split(tags, tagSeparator) {
+				rec.Tags = append(rec.Tags, strings.TrimSpace(tag))
+			}
+		}
+		if v := value("event_date"); v != "" {
+			t, err := parseTime(v)
+			if err != nil {
+				rowErrs = append(rowErrs, RowError{Line: line, Field: "event_date", Message: "must be RFC 3339 or YYYY-MM-DD HH:MM"})
+			} else {
+				rec.EventDate = &t
+			}
+		}
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		rows = append(rows, Row{Line: line, Record: rec})
+	}
+	return rows, errs, nil
+}
+
+// parseTime は RFC 3339、またはタイムゾーン無しの日時（日本時間として扱う）を読み取る
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, s, jst); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// jsonMessage は JSON の読み取りエラーを利用者向けの文にする
+func jsonMessage(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("%s must be %s", typeErr.Field, typeErr.Type)
+	}
+	return err.Error()
+}
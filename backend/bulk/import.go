@@ -0,0 +1,319 @@
+package bulk
+
+import (
+	"api/audit"
+	"api/category"
+	"api/db"
+	"api/geo"
+	"api/types"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// importBatch は一度に INSERT する行数（重複の確認で一度に問い合わせる本文の数も同じ）
+const importBatch = 500
+
+// RowError は取り込まなかった行とその理由
+type RowError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportOptions は取り込みの条件
+type ImportOptions struct {
+	Format string
+	// type が無い・空の行の種類（空の場合は各行に type が必要）
+	Kind string
+	// 取り込んだ投稿等の投稿者（自治体の公式アカウント等）
+	Author types.User
+	// 検証と重複の確認のみ行い、保存しない
+	DryRun bool
+	// 保存と同じトランザクションで記録する監査ログ（Detail は取り込みの結果で上書きする。nil の場合は記録しない）
+	Audit *audit.Entry
+}
+
+// ImportResult は取り込みの結果
+type ImportResult struct {
+	DryRun bool `json:"dry_run"`
+	// 読み取った行数
+	Total int `json:"total"`
+	// 保存した行数（dry run の場合は保存できる行数）
+	Imported int `json:"imported"`
+	// 既存の投稿等、またはファイル内の前の行と同じ内容のため飛ばした行
+	Duplicates []int `json:"duplicates"`
+	// 誤りのため飛ばした行
+	Errors []RowError `json:"errors"`
+}
+
+// candidate は検証を通過した行
+type candidate struct {
+	line      int
+	kind      string
+	content   string
+	category  types.Category
+	at        types.Coordinate
+	precision string
+	tags      []string
+	eventDate time.Time
+}
+
+// key は重複の判定に使う値（種類・本文・座標・開催日時が同じものを重複とみなす）
+func (c candidate) key() string {
+	return dedupKey(c.kind, c.content, c.at.Lat, c.at.Lng, c.eventDate)
+}
+
+func dedupKey(kind, content string, lat, lng float64, eventDate time.Time) string {
+	var date int64
+	if kind == KindEvent {
+		date = eventDate.Unix()
+	}
+	return fmt.Sprintf("%s\x00%s\x00%.6f\x00%.6f\x00%d", kind, content, lat, lng, date)
+}
+
+// Import は r の各行を検証し、誤りが無く重複しない行を1つのトランザクションで保存する
+// 誤りのある行・重複する行は飛ばして ImportResult で報告する（先に DryRun で確認できる）
+// 管理者による取り込みのためコンテンツフィルタにはかけない（review のカテゴリは非表示で保存する）
+func Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	if opts.Kind != "" && !ValidKind(opts.Kind) {
+		return nil, fmt.Errorf("unknown type %q", opts.Kind)
+	}
+	rows, errs, err := Decode(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ImportResult{DryRun: opts.DryRun, Total: len(rows) + countLines(errs), Duplicates: []int{}}
+	var candidates []candidate
+	for _, row := range rows {
+		c, rowErrs := validate(row, opts)
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+
+	existing, err := existingKeys(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	var accepted []candidate
+	for _, c := range candidates {
+		key := c.key()
+		if existing[key] {
+			res.Duplicates = append(res.Duplicates, c.line)
+			continue
+		}
+		existing[key] = true
+		accepted = append(accepted, c)
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	if !opts.DryRun && len(accepted) > 0 {
+		var entry *audit.Entry
+		if opts.Audit != nil {
+			e := *opts.Audit
+			e.Detail = map[string]any{
+				"format":     opts.Format,
+				"author_id":  opts.Author.ID,
+				"total":      res.Total,
+				"imported":   len(accepted),
+				"duplicates": len(res.Duplicates),
+				"errors":     countLines(errs),
+			}
+			entry = &e
+		}
+		if err := insert(ctx, accepted, opts.Author, entry); err != nil {
+			return nil, err
+		}
+	}
+	res.Errors = errs
+	if res.Errors == nil {
+		res.Errors = []RowError{}
+	}
+	res.Imported = len(accepted)
+	return res, nil
+}
+
+// countLines は誤りのある行の数（1行に複数の誤りがある場合は1と数える）
+func countLines(errs []RowError) int {
+	lines := map[int]bool{}
+	for _, e := range errs {
+		lines[e.Line] = true
+	}
+	return len(lines)
+}
+
+// validate は API の作成リクエストと同じ規則で行を検証する
+func validate(row Row, opts ImportOptions) (candidate, []RowError) {
+	rec := row.Record
+	var errs []RowError
+	fail := func(field, message string) {
+		errs = append(errs, RowError{Line: row.Line, Field: field, Message: message})
+	}
+
+	c := candidate{line: row.Line, kind: rec.Type, content: rec.Content}
+	if c.kind == "" {
+		c.kind = opts.Kind
+	}
+	switch {
+	case c.kind == "":
+		fail("type", "is required")
+	case !ValidKind(c.kind):
+		fail("type", "must be one of "+strings.Join(Kinds, ", "))
+	}
+
+	switch {
+	case strings.TrimSpace(rec.Content) == "":
+		fail("content", "is required")
+	case utf8.RuneCountInString(rec.Content) > types.MaxContentLength:
+		fail("content", fmt.Sprintf("must be at most %d characters", types.MaxContentLength))
+	}
+
+	switch {
+	case rec.Lat == nil || rec.Lng == nil:
+		fail("coordinate", "lat and lng are required")
+	case *rec.Lat < -90 || *rec.Lat > 90:
+		fail("lat", "must be between -90 and 90")
+	case *rec.Lng < -180 || *rec.Lng > 180:
+		fail("lng", "must be between -180 and 180")
+	default:
+		c.at = types.Coordinate{Lat: *rec.Lat, Lng: *rec.Lng}
+	}
+
+	registry := category.Get()
+	id := rec.Category
+	if id == "" {
+		id = registry.Default()
+	}
+	cat, ok := registry.Lookup(id)
+	if !ok {
+		fail("category", "unknown category")
+	}
+	c.category = cat
+
+	c.precision = rec.Precision
+	if c.precision == "" {
+		c.precision = opts.Author.DefaultPrecision
+	}
+	if c.precision == "" {
+		c.precision = geo.DefaultPrecision
+	}
+	if !geo.ValidPrecision(c.precision) {
+		fail("precision", "must be one of exact, neighborhood, city")
+	}
+
+	if len(rec.Tags) > types.MaxTags {
+		fail("tags", fmt.Sprintf("must have at most %d tags", types.MaxTags))
+	}
+	for _, tag := range rec.Tags {
+		if n := utf8.RuneCountInString(tag); n == 0 || n > types.MaxTagLength {
+			fail("tags", fmt.Sprintf("each tag must be 1 to %d characters", types.MaxTagLength))
+			break
+		}
+	}
+	c.tags = rec.Tags
+
+	if c.kind == KindEvent {
+		if rec.EventDate == nil || rec.EventDate.IsZero() {
+			fail("event_date", "is required for events")
+		} else {
+			c.eventDate = *rec.EventDate
+		}
+	}
+	return c, errs
+}
+
+// existingKeys は candidates と同じ内容の既存の投稿等（削除済みは除く）の重複の判定の値を返す
+func existingKeys(ctx context.Context, candidates []candidate) (map[string]bool, error) {
+	contents := map[string][]string{}
+	for _, c := range candidates {
+		contents[c.kind] = append(contents[c.kind], c.content)
+	}
+
+	keys := map[string]bool{}
+	for kind, values := range contents {
+		model, columns := modelOf(kind), "content, lat, lng"
+		if kind == KindEvent {
+			columns += ", event_date"
+		}
+		for start := 0; start < len(values); start += importBatch {
+			var found []struct {
+				Content   string
+				Lat       float64
+				Lng       float64
+				EventDate time.Time
+			}
+			chunk := values[start:min(start+importBatch, len(values))]
+			if err := db.Ctx(ctx).Model(model).Select(columns).Where("content IN ?", chunk).Scan(&found).Error; err != nil {
+				return nil, err
+			}
+			for _, f := range found {
+				keys[dedupKey(kind, f.Content, f.Lat, f.Lng, f.EventDate)] = true
+			}
+		}
+	}
+	return keys, nil
+}
+
+func modelOf(kind string) any {
+	switch kind {
+	case KindThread:
+		return &types.Thread{}
+	case KindEvent:
+		return &types.Event{}
+	}
+	return &types.Post{}
+}
+
+// insert は candidates を author の投稿等として保存し、entry が nil でなければ監査ログを記録する
+func insert(ctx context.Context, candidates []candidate, author types.User, entry *audit.Entry) error {
+	var posts []types.Post
+	var threads []types.Thread
+	var events []types.Event
+	for _, c := range candidates {
+		valid := c.category.Moderation != types.ModerationReview
+		switch c.kind {
+		case KindPost:
+			posts = append(posts, types.Post{
+				Type: KindPost, UserID: author.ID, Username: author.Name, Coordinate: c.at, Precision: c.precision,
+				Content: c.content, Category: c.category.ID, Valid: valid, Tags: c.tags,
+			})
+		case KindThread:
+			threads = append(threads, types.Thread{
+				Type: KindThread, UserID: author.ID, Username: author.Name, Coordinate: c.at, Precision: c.precision,
+				Content: c.content, Category: c.category.ID, Valid: valid, Tags: c.tags,
+			})
+		case KindEvent:
+			events = append(events, types.Event{
+				Type: KindEvent, UserID: author.ID, Username: author.Name, Coordinate: c.at, Precision: c.precision,
+				Content: c.content, Category: c.category.ID, Valid: valid, Tags: c.tags, EventDate: c.eventDate,
+			})
+		}
+	}
+
+	return db.SafeTransaction(ctx, func(tx *gorm.DB) error {
+		for _, rows := range []any{posts, threads, events} {
+			if reflect.ValueOf(rows).Len() == 0 {
+				continue
+			}
+			if err := tx.Omit(clause.Associations).CreateInBatches(rows, importBatch).Error; err != nil {
+				return fmt.Errorf("failed to insert %T: %w", rows, err)
+			}
+		}
+		if entry == nil {
+			return nil
+		}
+		return audit.Record(tx, *entry)
+	})
+}
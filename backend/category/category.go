@@ -0,0 +1,202 @@
+package category
+
+import (
+	"api/db"
+	"api/geo"
+	"api/types"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 緯度1度あたりの距離（m）。表示範囲の半径を緯度経度の幅に換算する
+const metersPerDegree = 111320
+
+// Defaults は初回起動時に登録するカテゴリ
+// 登録済みのカテゴリは上書きしないため、表示名や範囲は DB 上で変更できる
+var Defaults = []types.Category{
+	{
+		ID:         types.CategoryEntertainment,
+		LabelJA:    "エンターテイメント",
+		LabelEN:    "Entertainment",
+		Icon:       "music",
+		Scope:      types.ScopeNationwide,
+		Moderation: types.ModerationStandard,
+		Position:   1,
+		Enabled:    true,
+	},
+	{
+		ID:           types.CategoryCommunity,
+		LabelJA:      "地域住民コミュニケーション",
+		LabelEN:      "Community",
+		Icon:         "users",
+		Scope:        types.ScopeLocal,
+		RadiusMeters: 1100,
+		Moderation:   types.ModerationStandard,
+		Position:     2,
+		Enabled:      true,
+	},
+	{
+		ID:         types.CategoryDisaster,
+		LabelJA:    "災害情報",
+		LabelEN:    "Disaster",
+		Icon:       "alert-triangle",
+		Scope:      types.ScopeNationwide,
+		Moderation: types.ModerationStandard,
+		Position:   3,
+		Enabled:    true,
+	},
+	{
+		ID:           types.CategoryOther,
+		LabelJA:      "その他",
+		LabelEN:      "Other",
+		Icon:         "map-pin",
+		Scope:        types.ScopeLocal,
+		RadiusMeters: 1100,
+		Moderation:   types.ModerationStandard,
+		Position:     4,
+		Enabled:      true,
+	},
+}
+
+// Registry は有効なカテゴリの一覧
+type Registry struct {
+	list []types.Category
+	byID map[string]types.Category
+}
+
+var (
+	mu       sync.RWMutex
+	registry = NewRegistry(Defaults)
+)
+
+// NewRegistry は categories から Registry を作る（無効なカテゴリは除く）
+func NewRegistry(categories []types.Category) *Registry {
+	r := &Registry{byID: map[string]types.Category{}}
+	for _, c := range categories {
+		if !c.Enabled {
+			continue
+		}
+		r.list = append(r.list, c)
+		r.byID[c.ID] = c
+	}
+	return r
+}
+
+// Initialize は既定のカテゴリを登録し、DB からカテゴリを読み込む
+func Initialize(ctx context.Context) error {
+	if err := db.Ctx(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&Defaults).Error; err != nil {
+		return fmt.Errorf("failed to seed categories: %w", err)
+	}
+	return Reload(ctx)
+}
+
+// Reload は DB からカテゴリを読み込み直す
+func Reload(ctx context.Context) error {
+	var categories []types.Category
+	if err := db.Ctx(ctx).Order("position, id").Find(&categories).Error; err != nil {
+		return fmt.Errorf("failed to load categories: %w", err)
+	}
+	for _, c := range categories {
+		if err := validate(c); err != nil {
+			return err
+		}
+	}
+
+	r := NewRegistry(categories)
+	mu.Lock()
+	registry = r
+	mu.Unlock()
+
+	slog.Info("Categories loaded", "count", len(r.list))
+	return nil
+}
+
+// Get returns the current registry
+func Get() *Registry {
+	mu.RLock()
+	defer mu.RUnlock()
+	return registry
+}
+
+func validate(c types.Category) error {
+	switch c.Scope {
+	case types.ScopeNationwide:
+	case types.ScopeLocal:
+		if c.RadiusMeters <= 0 {
+			return fmt.Errorf("category %q: radius_meters must be positive for local scope", c.ID)
+		}
+	default:
+		return fmt.Errorf("category %q: unknown scope %q", c.ID, c.Scope)
+	}
+	if c.Moderation != types.ModerationStandard && c.Moderation != types.ModerationReview {
+		return fmt.Errorf("category %q: unknown moderation policy %q", c.ID, c.Moderation)
+	}
+	return nil
+}
+
+// All は有効なカテゴリを表示順に返す
+func (r *Registry) All() []types.Category {
+	return r.list
+}
+
+// Lookup は id のカテゴリを返す（無効・未登録の場合は false）
+func (r *Registry) Lookup(id string) (types.Category, bool) {
+	c, ok := r.byID[id]
+	return c, ok
+}
+
+// Default はカテゴリが指定されなかった場合のカテゴリ
+func (r *Registry) Default() string {
+	return types.CategoryOther
+}
+
+// cellInRange は公開用のセル（geo.CellSQL）が緯度・経度の範囲に掛かる地域のカテゴリの投稿の条件
+var cellInRange = func() string {
+	lat, size := geo.CellSQL("lat", "precision")
+	lng, _ := geo.CellSQL("lng", "precision")
+	return fmt.Sprintf("(category = ? AND %[1]s + %[3]s >= ? AND %[1]s <= ? AND %[2]s + %[3]s >= ? AND %[2]s <= ?)", lat, lng, size)
+}()
+
+// span は緯度 lat の地点から半径 radiusMeters の範囲の緯度・経度の幅（度）を返す
+// 経度1度あたりの距離は cos(緯度) に比例して短くなる
+func span(lat float64, radiusMeters int) (float64, float64) {
+	dLat := float64(radiusMeters) / metersPerDegree
+	return dLat, math.Min(dLat/math.Cos(lat*math.Pi/180), 180)
+}
+
+// Scope は一覧に表示する投稿の条件を返す
+// 全国のカテゴリは常に、地域のカテゴリは at から各カテゴリの半径以内に公開用のセルが掛かるもののみ含める
+// radiusMeters が正の場合は各カテゴリの半径の代わりに使う。at が nil の場合は全国のカテゴリのみ
+func (r *Registry) Scope(at *types.Coordinate, radiusMeters int) func(*gorm.DB) *gorm.DB {
+	nationwide := []string{}
+	var local []string
+	var localArgs []any
+	for _, c := range r.list {
+		switch {
+		case c.Scope == types.ScopeNationwide:
+			nationwide = append(nationwide, c.ID)
+		case at != nil:
+			radius := c.RadiusMeters
+			if radiusMeters > 0 {
+				radius = radiusMeters
+			}
+			dLat, dLng := span(at.Lat, radius)
+			local = append(local, cellInRange)
+			localArgs = append(localArgs, c.ID, at.Lat-dLat, at.Lat+dLat, at.Lng-dLng, at.Lng+dLng)
+		}
+	}
+	conds := append([]string{"category IN ?"}, local...)
+	args := append([]any{nationwide}, localArgs...)
+
+	where := "(" + strings.Join(conds, " OR ") + ")"
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(where, args...)
+	}
+}
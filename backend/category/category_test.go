@@ -0,0 +1,25 @@
+package category
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpan(t *testing.T) {
+	tests := []struct {
+		lat        float64
+		radius     int
+		dLat, dLng float64
+	}{
+		{0, 1000, 1000.0 / metersPerDegree, 1000.0 / metersPerDegree},
+		{60, 1000, 1000.0 / metersPerDegree, 2 * 1000.0 / metersPerDegree},
+		{-60, 1000, 1000.0 / metersPerDegree, 2 * 1000.0 / metersPerDegree},
+		{90, 1000, 1000.0 / metersPerDegree, 180},
+	}
+	for _, tt := range tests {
+		dLat, dLng := span(tt.lat, tt.radius)
+		if math.Abs(dLat-tt.dLat) > 1e-9 || math.Abs(dLng-tt.dLng) > 1e-9 {
+			t.Errorf("span(%v, %d) = %v, %v, want %v, %v", tt.lat, tt.radius, dLat, dLng, tt.dLat, tt.dLng)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// 実行環境（APP_ENV）。環境毎に既定値が異なる（profiles.go）
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// Config はアプリケーション全体の設定
+// 既定値 < 設定ファイル（YAML） < 環境変数 の順に上書きされる
+type Config struct {
+	Env           string        `yaml:"env" env:"APP_ENV"`
+	App           App           `yaml:"app"`
+	Server        Server        `yaml:"server"`
+	Database      Database      `yaml:"database"`
+	Auth          Auth          `yaml:"auth"`
+	Log           Log           `yaml:"log"`
+	Telemetry     Telemetry     `yaml:"telemetry"`
+	Gemini        Gemini        `yaml:"gemini"`
+	Storage       Storage       `yaml:"storage"`
+	RateLimit     RateLimit     `yaml:"rate_limit"`
+	Mail          Mail          `yaml:"mail"`
+	ContentFilter ContentFilter `yaml:"content_filter"`
+	Moderation    Moderation    `yaml:"moderation"`
+	Account       Account       `yaml:"account"`
+
+	files []string
+}
+
+type App struct {
+	// メール内のリンク先（フロントエンドのURL）
+	BaseURL string `yaml:"base_url" env:"APP_BASE_URL"`
+}
+
+type Server struct {
+	Addr              string        `yaml:"addr" env:"SERVER_ADDR"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	// 長時間の応答（SSE・大きなダウンロード）は server.ExtendWriteDeadline で個別に延長する
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// SIGTERM を受けてから新しい接続の受け付けをやめるまでの待ち時間
+	// この間に /readyz が 503 を返し、ロードバランサーの振り分け先から外れる
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY"`
+	// 処理中のリクエストの完了を待つ最大時間
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	// 両方指定した場合は HTTPS で待ち受ける
+	TLSCertFile    string   `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile     string   `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+	CORSOrigins    []string `yaml:"cors_origins" env:"CORS_ALLOWED_ORIGINS"`
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	// /api/v1 の提供終了予定日（YYYY-MM-DD）。v1 の応答の Sunset ヘッダーに使う（空の場合は付けない）
+	V1Sunset string `yaml:"v1_sunset" env:"API_V1_SUNSET"`
+}
+
+// TLS は HTTPS で待ち受けるか
+func (s Server) TLS() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
+}
+
+// V1SunsetTime は V1Sunset の日時（UTC の 0 時）。未設定・不正な場合はゼロ値
+func (s Server) V1SunsetTime() time.Time {
+	t, err := time.Parse(time.DateOnly, s.V1Sunset)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+type Database struct {
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+	// disable / require / verify-ca / verify-full
+	SSLMode         string        `yaml:"sslmode" env:"DB_SSLMODE"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+}
+
+// DSN は PostgreSQL の接続文字列（パスワードを含むためログに出さないこと）
+func (d Database) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode)
+}
+
+type Auth struct {
+	// HS256 の署名鍵（32バイト以上）
+	JWTSecret string `yaml:"jwt_secret" env:"JWT_SECRET"`
+	// アクセストークンからユーザー情報を取得する Google のエンドポイント（試験では差し替える）
+	GoogleUserInfoURL string `yaml:"google_userinfo_url" env:"GOOGLE_USERINFO_URL"`
+}
+
+type Log struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+type Telemetry struct {
+	// OTEL_EXPORTER_OTLP_ENDPOINT 等は OpenTelemetry の SDK が直接読み込む
+	TracingEnabled bool `yaml:"tracing_enabled" env:"OTEL_TRACING_ENABLED"`
+}
+
+type Gemini struct {
+	APIKey string `yaml:"api_key" env:"GEMINI_API_KEY"`
+	Model  string `yaml:"model" env:"GEMINI_MODEL"`
+}
+
+type Storage struct {
+	// local / s3
+	Driver    string `yaml:"driver" env:"STORAGE_DRIVER"`
+	LocalDir  string `yaml:"local_dir" env:"STORAGE_LOCAL_DIR"`
+	PublicURL string `yaml:"public_url" env:"STORAGE_PUBLIC_URL"`
+	S3        S3     `yaml:"s3"`
+}
+
+type S3 struct {
+	Endpoint  string `yaml:"endpoint" env:"S3_ENDPOINT"`
+	Region    string `yaml:"region" env:"S3_REGION"`
+	Bucket    string `yaml:"bucket" env:"S3_BUCKET"`
+	AccessKey string `yaml:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"S3_SECRET_KEY"`
+	UseSSL    bool   `yaml:"use_ssl" env:"S3_USE_SSL"`
+}
+
+type RateLimit struct {
+	// memory / redis
+	Backend  string `yaml:"backend" env:"RATE_LIMIT_BACKEND"`
+	RedisURL string `yaml:"redis_url" env:"REDIS_URL"`
+	// <回数>/<期間>。空の場合は ratelimit の既定値
+	Auth             string `yaml:"auth" env:"RATE_LIMIT_AUTH"`
+	Write            string `yaml:"write" env:"RATE_LIMIT_WRITE"`
+	Mail             string `yaml:"mail" env:"RATE_LIMIT_MAIL"`
+	LockoutThreshold int    `yaml:"lockout_threshold" env:"LOGIN_LOCKOUT_THRESHOLD"`
+}
+
+// Limits はグループ毎の制限の指定（空のものは含めない）
+func (r RateLimit) Limits() map[string]string {
+	limits := map[string]string{}
+	for group, spec := range map[string]string{"auth": r.Auth, "write": r.Write, "mail": r.Mail} {
+		if spec != "" {
+			limits[group] = spec
+		}
+	}
+	return limits
+}
+
+type Mail struct {
+	// file / smtp
+	Driver string `yaml:"driver" env:"MAIL_DRIVER"`
+	From   string `yaml:"from" env:"MAIL_FROM"`
+	Dir    string `yaml:"dir" env:"MAIL_DIR"`
+	SMTP   SMTP   `yaml:"smtp"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+}
+
+type ContentFilter struct {
+	NGWordsFile  string   `yaml:"ng_words_file" env:"NG_WORDS_FILE"`
+	URLBlocklist []string `yaml:"url_blocklist" env:"URL_BLOCKLIST"`
+	// Gemini による判定（Gemini.APIKey が必要）
+	LLM bool `yaml:"llm" env:"CONTENT_FILTER_LLM"`
+}
+
+type Moderation struct {
+	// この人数から通報されると自動で非表示にする
+	ReportAutoHideThreshold int `yaml:"report_auto_hide_threshold" env:"REPORT_AUTO_HIDE_THRESHOLD"`
+}
+
+type Account struct {
+	// 退会から削除までの猶予期間（日）
+	DeletionGraceDays int `yaml:"deletion_grace_days" env:"ACCOUNT_DELETION_GRACE_DAYS"`
+	// 退会時の投稿の扱い（例: posts=delete,comments=anonymize）
+	Retention string `yaml:"retention" env:"ACCOUNT_RETENTION"`
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// 設定ファイルを指定しない場合に探すファイル
+const defaultConfigFile = "config.yaml"
+
+// Load は設定を読み込んで検証する
+//
+//  1. 既定値と APP_ENV 毎の既定値（profiles.go）
+//  2. CONFIG_FILE（未指定なら config.yaml があれば）と、同じディレクトリの config.<APP_ENV>.yaml
+//  3. .env と環境変数
+func Load() (*Config, error) {
+	// .envファイルから環境変数を読み込む（既に設定されている環境変数は上書きしない）
+	envLoaded := godotenv.Load(".env") == nil
+
+	path, required := os.Getenv("CONFIG_FILE"), true
+	if path == "" {
+		path, required = defaultConfigFile, false
+	}
+	base, err := readFile(path, required)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := defaults()
+	cfg.Env = firstNonEmpty(os.Getenv("APP_ENV"), yamlEnv(base), cfg.Env)
+	applyProfile(&cfg)
+
+	if base != nil {
+		if err := yaml.Unmarshal(base, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		cfg.files = append(cfg.files, path)
+	}
+	profilePath := filepath.Join(filepath.Dir(path), "config."+cfg.Env+".yaml")
+	profile, err := readFile(profilePath, false)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		if err := yaml.Unmarshal(profile, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", profilePath, err)
+		}
+		cfg.files = append(cfg.files, profilePath)
+	}
+	if envLoaded {
+		cfg.files = append(cfg.files, ".env")
+	}
+
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem()); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Files は読み込んだ設定ファイル（ログ出力用）
+func (c *Config) Files() []string {
+	return c.files
+}
+
+func readFile(path string, required bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return data, nil
+}
+
+// yamlEnv は設定ファイルの env を返す（プロファイルの選択に使う）
+func yamlEnv(data []byte) string {
+	var head struct {
+		Env string `yaml:"env"`
+	}
+	_ = yaml.Unmarshal(data, &head)
+	return head.Env
+}
+
+// applyEnv は env タグの環境変数が設定されていればフィールドを上書きする
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			if err := applyEnv(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		raw, ok := os.LookupEnv(key)
+		if !ok || strings.TrimSpace(raw) == "" {
+			continue
+		}
+		if err := setValue(value, strings.TrimSpace(raw)); err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, raw string) error {
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(raw)
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package config
+
+import "time"
+
+// defaults は全環境共通の既定値
+func defaults() Config {
+	return Config{
+		Env:  EnvProduction,
+		App:  App{BaseURL: "http://localhost:3000"},
+		Auth: Auth{GoogleUserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo"},
+		Server: Server{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			CORSOrigins:       []string{"http://localhost:3000", "https://chap-app.jp", "https://www.chap-app.jp"},
+			// nginx 等の同一ホスト・プライベートネットワークのプロキシ
+			TrustedProxies: []string{"127.0.0.1", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"},
+			// フロントエンドの /api/v2 への移行期間
+			V1Sunset: "2027-04-01",
+		},
+		Database: Database{
+			Host:    "localhost",
+			Port:    5432,
+			User:    "postgres",
+			Name:    "db",
+			SSLMode: "require",
+			// Supabase のプーラーの上限に合わせて小さくしている
+			MaxOpenConns:    5,
+			MaxIdleConns:    2,
+			ConnMaxLifetime: 15 * time.Minute,
+			ConnMaxIdleTime: 2 * time.Minute,
+		},
+		Log:     Log{Level: "info", Format: "text"},
+		Gemini:  Gemini{Model: "gemini-2.0-flash-001"},
+		Storage: Storage{Driver: "local", LocalDir: "./uploads", S3: S3{UseSSL: true}},
+		RateLimit: RateLimit{
+			Backend:          "memory",
+			LockoutThreshold: 5,
+		},
+		Mail: Mail{
+			Driver: "file",
+			From:   "no-reply@chap-app.jp",
+			Dir:    "./mail",
+			SMTP:   SMTP{Port: 587},
+		},
+		Moderation: Moderation{ReportAutoHideThreshold: 3},
+		Account:    Account{DeletionGraceDays: 30},
+	}
+}
+
+// applyProfile は APP_ENV 毎の既定値を適用する（設定ファイル・環境変数より前）
+func applyProfile(cfg *Config) {
+	switch cfg.Env {
+	case EnvDevelopment:
+		// ローカルの PostgreSQL は TLS なしで動かすことが多い
+		cfg.Database.SSLMode = "disable"
+		cfg.Log.Level = "debug"
+	case EnvTest:
+		cfg.Database.SSLMode = "disable"
+		cfg.Log.Level = "warn"
+		cfg.Server.ShutdownTimeout = time.Second
+	case EnvProduction:
+		// ログ収集基盤向け
+		cfg.Log.Format = "json"
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JWT の署名鍵の最小長（HS256 の鍵長）
+const MinJWTSecretLength = 32
+
+// Validate は設定の誤りをまとめて返す（起動時に検出するため）
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(oneOf(c.Env, EnvDevelopment, EnvTest, EnvProduction), "APP_ENV must be one of development / test / production: %q", c.Env)
+
+	check(len(c.Auth.JWTSecret) >= MinJWTSecretLength, "JWT_SECRET must be at least %d characters", MinJWTSecretLength)
+
+	check(c.Database.Host != "", "DB_HOST is required")
+	check(c.Database.Name != "", "DB_NAME is required")
+	check(c.Database.Port > 0, "DB_PORT must be positive")
+	check(oneOf(c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+		"DB_SSLMODE is invalid: %q", c.Database.SSLMode)
+	check(c.Database.MaxOpenConns > 0, "DB_MAX_OPEN_CONNS must be positive")
+	if c.Env == EnvProduction {
+		check(c.Database.SSLMode != "disable", "DB_SSLMODE=disable is not allowed in production")
+	}
+
+	check(c.Server.Addr != "", "SERVER_ADDR is required")
+	check((c.Server.TLSCertFile == "") == (c.Server.TLSKeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	for name, d := range map[string]int64{
+		"SERVER_READ_HEADER_TIMEOUT": int64(c.Server.ReadHeaderTimeout),
+		"SERVER_READ_TIMEOUT":        int64(c.Server.ReadTimeout),
+		"SERVER_WRITE_TIMEOUT":       int64(c.Server.WriteTimeout),
+		"SERVER_IDLE_TIMEOUT":        int64(c.Server.IdleTimeout),
+		"SERVER_SHUTDOWN_DELAY":      int64(c.Server.ShutdownDelay),
+		"SERVER_SHUTDOWN_TIMEOUT":    int64(c.Server.ShutdownTimeout),
+	} {
+		check(d >= 0, "%s must not be negative", name)
+	}
+	check(len(c.Server.CORSOrigins) > 0, "CORS_ALLOWED_ORIGINS is required")
+	if c.Server.V1Sunset != "" {
+		_, err := time.Parse(time.DateOnly, c.Server.V1Sunset)
+		check(err == nil, "API_V1_SUNSET must be a date (YYYY-MM-DD): %q", c.Server.V1Sunset)
+	}
+
+	check(oneOf(strings.ToLower(c.Log.Level), "debug", "info", "warn", "error"), "LOG_LEVEL is invalid: %q", c.Log.Level)
+	check(oneOf(strings.ToLower(c.Log.Format), "text", "json"), "LOG_FORMAT must be text or json: %q", c.Log.Format)
+
+	switch c.Storage.Driver {
+	case "local":
+		check(c.Storage.LocalDir != "", "STORAGE_LOCAL_DIR is required for local storage")
+	case "s3":
+		check(c.Storage.S3.Endpoint != "" && c.Storage.S3.Bucket != "", "S3_ENDPOINT and S3_BUCKET are required for s3 storage")
+	default:
+		check(false, "unknown STORAGE_DRIVER: %s", c.Storage.Driver)
+	}
+
+	switch c.RateLimit.Backend {
+	case "memory":
+	case "redis":
+		check(c.RateLimit.RedisURL != "", "REDIS_URL is required for redis rate limiting")
+	default:
+		check(false, "unknown RATE_LIMIT_BACKEND: %s", c.RateLimit.Backend)
+	}
+	check(c.RateLimit.LockoutThreshold >= 1, "LOGIN_LOCKOUT_THRESHOLD must be at least 1")
+
+	switch c.Mail.Driver {
+	case "file":
+		check(c.Mail.Dir != "", "MAIL_DIR is required for file mail")
+	case "smtp":
+		check(c.Mail.SMTP.Host != "", "SMTP_HOST is required for smtp mail")
+		check(c.Mail.SMTP.Port > 0, "SMTP_PORT must be positive")
+	default:
+		check(false, "unknown MAIL_DRIVER: %s", c.Mail.Driver)
+	}
+
+	check(c.Moderation.ReportAutoHideThreshold >= 1, "REPORT_AUTO_HIDE_THRESHOLD must be at least 1")
+	check(c.Account.DeletionGraceDays >= 0, "ACCOUNT_DELETION_GRACE_DAYS must not be negative")
+
+	return errors.Join(errs...)
+}
+
+func oneOf(v string, options ...string) bool {
+	for _, o := range options {
+		if v == o {
+			return true
+		}
+	}
+	return false
+}
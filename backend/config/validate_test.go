@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validConfig は検証を通る設定を返す
+func validConfig(env string) Config {
+	cfg := defaults()
+	cfg.Env = env
+	applyProfile(&cfg)
+	cfg.Auth.JWTSecret = strings.Repeat("s", MinJWTSecretLength)
+	return cfg
+}
+
+func TestValidateProfiles(t *testing.T) {
+	for _, env := range []string{EnvDevelopment, EnvTest, EnvProduction} {
+		cfg := validConfig(env)
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s: %v", env, err)
+		}
+	}
+}
+
+func TestValidateInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string
+		modify func(*Config)
+		want   string
+	}{
+		{"unknown env", EnvProduction, func(c *Config) { c.Env = "staging" }, "APP_ENV"},
+		{"short jwt secret", EnvDevelopment, func(c *Config) { c.Auth.JWTSecret = "short" }, "JWT_SECRET"},
+		{"no tls in production", EnvProduction, func(c *Config) { c.Database.SSLMode = "disable" }, "DB_SSLMODE=disable"},
+		{"unknown sslmode", EnvDevelopment, func(c *Config) { c.Database.SSLMode = "maybe" }, "DB_SSLMODE"},
+		{"tls cert without key", EnvProduction, func(c *Config) { c.Server.TLSCertFile = "cert.pem" }, "TLS_CERT_FILE"},
+		{"negative timeout", EnvProduction, func(c *Config) { c.Server.WriteTimeout = -1 }, "SERVER_WRITE_TIMEOUT"},
+		{"no cors origins", EnvProduction, func(c *Config) { c.Server.CORSOrigins = nil }, "CORS_ALLOWED_ORIGINS"},
+		{"bad sunset", EnvProduction, func(c *Config) { c.Server.V1Sunset = "next year" }, "API_V1_SUNSET"},
+		{"bad log format", EnvDevelopment, func(c *Config) { c.Log.Format = "xml" }, "LOG_FORMAT"},
+		{"unknown storage", EnvDevelopment, func(c *Config) { c.Storage.Driver = "ftp" }, "STORAGE_DRIVER"},
+		{"s3 without bucket", EnvDevelopment, func(c *Config) { c.Storage.Driver = "s3" }, "S3_BUCKET"},
+		{"redis without url", EnvDevelopment, func(c *Config) { c.RateLimit.Backend = "redis" }, "REDIS_URL"},
+		{"zero lockout", EnvTest, func(c *Config) { c.RateLimit.LockoutThreshold = 0 }, "LOGIN_LOCKOUT_THRESHOLD"},
+		{"smtp without host", EnvProduction, func(c *Config) { c.Mail.Driver = "smtp" }, "SMTP_HOST"},
+		{"zero auto hide", EnvProduction, func(c *Config) { c.Moderation.ReportAutoHideThreshold = 0 }, "REPORT_AUTO_HIDE_THRESHOLD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(tt.env)
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
+
+// 誤りはまとめて返すこと
+func TestValidateJoinsErrors(t *testing.T) {
+	cfg := validConfig(EnvDevelopment)
+	cfg.Auth.JWTSecret = ""
+	cfg.Database.Host = ""
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "JWT_SECRET") || !strings.Contains(err.Error(), "DB_HOST") {
+		t.Errorf("Validate() = %v, want both errors", err)
+	}
+}
+
+// 設定ファイルの env とプロファイル毎の設定ファイルの誤りを起動時に検出すること
+func TestLoadInvalidProfile(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"unknown env", map[string]string{"config.yaml": "env: staging\n"}, "APP_ENV"},
+		{"profile disables tls", map[string]string{
+			"config.yaml":            "env: production\n",
+			"config.production.yaml": "database:\n  sslmode: disable\n",
+		}, "DB_SSLMODE=disable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("CONFIG_FILE", filepath.Join(dir, "config.yaml"))
+			t.Setenv("APP_ENV", "")
+			t.Setenv("JWT_SECRET", strings.Repeat("s", MinJWTSecretLength))
+			t.Setenv("DB_SSLMODE", "")
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package contentfilter
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// Decision はフィルタの判定結果
+type Decision int
+
+const (
+	Allow  Decision = iota // そのまま公開
+	Hold                   // 非表示で保存し、モデレーターの確認を待つ
+	Reject                 // 保存しない
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+// Input はフィルタにかける投稿内容
+type Input struct {
+	UserID     uuid.UUID
+	TargetType string
+	TargetID   uint // 編集の場合は対象の ID（新規は 0）
+	Content    string
+	Tags       []string
+}
+
+// Result は1つのフィルタの判定
+type Result struct {
+	Filter   string
+	Decision Decision
+	Reason   string
+}
+
+// Filter は投稿内容を判定する
+type Filter interface {
+	Name() string
+	Check(ctx context.Context, in Input) (Result, error)
+}
+
+// Verdict はパイプライン全体の判定
+type Verdict struct {
+	Decision Decision
+	// Allow 以外の判定をしたフィルタの結果
+	Results []Result
+}
+
+// Pipeline は複数のフィルタを順番に実行する
+type Pipeline struct {
+	filters []Filter
+}
+
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+// Run は全フィルタを実行し、最も厳しい判定を返す。Reject が出た時点で打ち切る。
+// フィルタ自体のエラーでは投稿を止めない（エラーはログに残す）
+func (p *Pipeline) Run(ctx context.Context, in Input) Verdict {
+	verdict := Verdict{Decision: Allow}
+	for _, f := range p.filters {
+		result, err := f.Check(ctx, in)
+		if err != nil {
+			slog.ErrorContext(ctx, "content filter failed", "filter", f.Name(), "error", err)
+			continue
+		}
+		if result.Decision == Allow {
+			continue
+		}
+		result.Filter = f.Name()
+		verdict.Results = append(verdict.Results, result)
+		if result.Decision > verdict.Decision {
+			verdict.Decision = result.Decision
+		}
+		if verdict.Decision == Reject {
+			break
+		}
+	}
+	return verdict
+}
@@ -0,0 +1,159 @@
+package contentfilter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func check(t *testing.T, f Filter, in Input) Decision {
+	t.Helper()
+	result, err := f.Check(context.Background(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Decision
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"シ ね", "しね"},
+		{"ｼﾈ", "しね"},
+		{"し-ね！", "しね"},
+		{"ＡＢＣ abc", "abcabc"},
+		{"ラーメン", "らーめん"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNGWordFilter(t *testing.T) {
+	f, err := LoadNGWordFilter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		content string
+		tags    []string
+		want    Decision
+	}{
+		// 他の語の一部に含まれるかなの禁止語は一致としない
+		{"駅前のシネマで映画を見た", nil, Allow},
+		{"このところすごく暑い", nil, Allow},
+		{"しねまの上映時間", nil, Allow},
+		// 前後がかな以外のかなの禁止語
+		{"しね", nil, Reject},
+		{"お前しね", nil, Reject},
+		{"うるさい、シネ！", nil, Reject},
+		{"ｺﾛｽ", nil, Reject},
+		// 漢字を含む語は記号・空白を挟んでも一致とする
+		{"死 ね", nil, Reject},
+		{"殺してやる", nil, Reject},
+		{"今日は晴れ", []string{"死ね"}, Reject},
+		{"出会い系サイト", nil, Hold},
+		{"今日は晴れ", nil, Allow},
+	}
+	for _, tt := range tests {
+		if got := check(t, f, Input{Content: tt.content, Tags: tt.tags}); got != tt.want {
+			t.Errorf("%q %v = %s, want %s", tt.content, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestNGWordDictionary(t *testing.T) {
+	f, err := NewNGWordFilter(strings.NewReader("# comment\n\nばか\t hold\n禁止\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := check(t, f, Input{Content: "禁止語"}); got != Reject {
+		t.Errorf("reject word = %s", got)
+	}
+	if got := check(t, f, Input{Content: "バカ"}); got != Hold {
+		t.Errorf("hold word = %s", got)
+	}
+	if got := check(t, f, Input{Content: "comment"}); got != Allow {
+		t.Errorf("comment line = %s", got)
+	}
+}
+
+func TestURLBlocklistFilter(t *testing.T) {
+	f := NewURLBlocklistFilter([]string{" Spam.example ", ""})
+	tests := []struct {
+		content string
+		want    Decision
+	}{
+		{"詳細は https://spam.example/page", Reject},
+		{"詳細は https://www.spam.example/page", Reject},
+		{"詳細は https://notspam.example/page", Allow},
+		{"詳細は https://bit.ly/abc", Hold},
+		{"spam.example", Allow}, // URL でなければ対象外
+	}
+	for _, tt := range tests {
+		if got := check(t, f, Input{Content: tt.content}); got != tt.want {
+			t.Errorf("%q = %s, want %s", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestLinkDensityFilter(t *testing.T) {
+	f := &LinkDensityFilter{MaxLinks: 3, MaxRatio: 0.6}
+	tests := []struct {
+		content string
+		want    Decision
+	}{
+		{"今日のイベントの詳細はこちらに載っています https://example.com", Allow},
+		{"https://example.com/a https://example.com/b https://example.com/c https://example.com/d", Hold},
+		{"見て https://example.com/very/long/path/to/some/page", Hold},
+		{"リンクなし", Allow},
+	}
+	for _, tt := range tests {
+		if got := check(t, f, Input{Content: tt.content}); got != tt.want {
+			t.Errorf("%q = %s, want %s", tt.content, got, tt.want)
+		}
+	}
+}
+
+// fakeHistory は exclude を除いた最近の投稿内容を返す
+type fakeHistory map[Target]string
+
+func (h fakeHistory) RecentContents(ctx context.Context, userID uuid.UUID, since time.Time, exclude Target) ([]string, error) {
+	var contents []string
+	for target, content := range h {
+		if target != exclude {
+			contents = append(contents, content)
+		}
+	}
+	return contents, nil
+}
+
+func TestDuplicateFilter(t *testing.T) {
+	f := &DuplicateFilter{
+		History: fakeHistory{{Type: "post", ID: 1}: "駅前の桜が満開です"},
+		Window:  time.Hour,
+	}
+	tests := []struct {
+		name string
+		in   Input
+		want Decision
+	}{
+		{"same content", Input{TargetType: "post", Content: "駅前の桜が満開です"}, Reject},
+		{"normalized", Input{TargetType: "thread", Content: "駅前の 桜が満開です！"}, Reject},
+		{"different content", Input{TargetType: "post", Content: "駅前の桜が散りました"}, Allow},
+		{"editing itself", Input{TargetType: "post", TargetID: 1, Content: "駅前の桜が満開です"}, Allow},
+		{"same id of another type", Input{TargetType: "event", TargetID: 1, Content: "駅前の桜が満開です"}, Reject},
+		{"empty", Input{TargetType: "post", Content: "！！"}, Allow},
+	}
+	for _, tt := range tests {
+		if got := check(t, f, tt.in); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package contentfilter
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// History はユーザーの最近の投稿内容を返す
+// 編集の場合は対象自体と比べないよう、exclude（種類と ID）のものを除く
+type History interface {
+	RecentContents(ctx context.Context, userID uuid.UUID, since time.Time, exclude Target) ([]string, error)
+}
+
+// Target は投稿・スレッド・イベント・コメントのいずれか1件（ID が 0 の場合はどれにも該当しない）
+type Target struct {
+	Type string
+	ID   uint
+}
+
+// DuplicateFilter は同じユーザーが短時間に同じ内容を繰り返し投稿するのを拒否する
+type DuplicateFilter struct {
+	History History
+	Window  time.Duration
+}
+
+func (f *DuplicateFilter) Name() string { return "duplicate" }
+
+func (f *DuplicateFilter) Check(ctx context.Context, in Input) (Result, error) {
+	text := Normalize(in.Content)
+	if text == "" {
+		return Result{Decision: Allow}, nil
+	}
+
+	recent, err := f.History.RecentContents(ctx, in.UserID, time.Now().Add(-f.Window), Target{Type: in.TargetType, ID: in.TargetID})
+	if err != nil {
+		return Result{}, err
+	}
+	for _, content := range recent {
+		if Normalize(content) == text {
+			return Result{Decision: Reject, Reason: "duplicate of a recent post"}, nil
+		}
+	}
+	return Result{Decision: Allow}, nil
+}
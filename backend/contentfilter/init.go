@@ -0,0 +1,76 @@
+package contentfilter
+
+import (
+	"api/config"
+	"api/db"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var pipeline = NewPipeline()
+
+// Initialize は設定から既定のフィルタ構成を組み立てる
+//
+//	NG_WORDS_FILE         禁止語辞書のパス（未指定なら組み込み辞書）
+//	URL_BLOCKLIST         拒否するドメイン（カンマ区切り）
+//	CONTENT_FILTER_LLM    "true" なら Gemini による判定を有効にする（GEMINI_API_KEY が必要）
+func Initialize(cfg config.ContentFilter, gemini config.Gemini) error {
+	ngword, err := LoadNGWordFilter(cfg.NGWordsFile)
+	if err != nil {
+		return err
+	}
+
+	filters := []Filter{
+		ngword,
+		NewURLBlocklistFilter(cfg.URLBlocklist),
+		&LinkDensityFilter{MaxLinks: 3, MaxRatio: 0.6},
+		&DuplicateFilter{History: dbHistory{}, Window: time.Hour},
+	}
+
+	if cfg.LLM {
+		if gemini.APIKey != "" {
+			filters = append(filters, &LLMFilter{Classifier: &GeminiClassifier{
+				APIKey: gemini.APIKey,
+				Model:  gemini.Model,
+			}})
+		} else {
+			slog.Warn("CONTENT_FILTER_LLM is enabled but GEMINI_API_KEY is not set; skipping LLM filter")
+		}
+	}
+
+	pipeline = NewPipeline(filters...)
+	return nil
+}
+
+// Get returns the configured pipeline
+func Get() *Pipeline {
+	return pipeline
+}
+
+// dbHistory は投稿・スレッド・イベント・コメントからユーザーの最近の投稿内容を取得する
+type dbHistory struct{}
+
+func (dbHistory) RecentContents(ctx context.Context, userID uuid.UUID, since time.Time, exclude Target) ([]string, error) {
+	// 対象の種類のテーブルのみ exclude.ID を除く（ID は 1 から始まるため 0 は何も除かない）
+	excluded := func(targetType string) uint {
+		if targetType == exclude.Type {
+			return exclude.ID
+		}
+		return 0
+	}
+	var contents []string
+	err := db.Ctx(ctx).Raw(`
+        SELECT content FROM posts WHERE user_id = ? AND created_at > ? AND deleted_at IS NULL AND id <> ?
+        UNION ALL
+        SELECT content FROM threads WHERE user_id = ? AND created_at > ? AND deleted_at IS NULL AND id <> ?
+        UNION ALL
+        SELECT content FROM events WHERE user_id = ? AND created_at > ? AND deleted_at IS NULL AND id <> ?
+        UNION ALL
+        SELECT content FROM comments WHERE user_id = ? AND created_at > ? AND deleted_at IS NULL AND id <> ?
+    `, userID, since, excluded("post"), userID, since, excluded("thread"),
+		userID, since, excluded("event"), userID, since, excluded("comment")).Scan(&contents).Error
+	return contents, err
+}
@@ -0,0 +1,35 @@
+package contentfilter
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+)
+
+// LinkDensityFilter はリンクの数や本文に占めるリンクの割合が多すぎる投稿を保留にする
+type LinkDensityFilter struct {
+	MaxLinks int     // これを超える数のリンクを含む場合は保留
+	MaxRatio float64 // 本文の文字数に占めるURLの割合の上限
+}
+
+func (f *LinkDensityFilter) Name() string { return "link_density" }
+
+func (f *LinkDensityFilter) Check(ctx context.Context, in Input) (Result, error) {
+	urls := extractURLs(in.Content)
+	if len(urls) == 0 {
+		return Result{Decision: Allow}, nil
+	}
+	if len(urls) > f.MaxLinks {
+		return Result{Decision: Hold, Reason: fmt.Sprintf("too many links (%d)", len(urls))}, nil
+	}
+
+	linkChars := 0
+	for _, u := range urls {
+		linkChars += utf8.RuneCountInString(u)
+	}
+	total := utf8.RuneCountInString(in.Content)
+	if total > 0 && float64(linkChars)/float64(total) > f.MaxRatio {
+		return Result{Decision: Hold, Reason: "mostly links"}, nil
+	}
+	return Result{Decision: Allow}, nil
+}
@@ -0,0 +1,95 @@
+package contentfilter
+
+import (
+	"api/telemetry"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// Classifier は外部のモデルで投稿内容を分類する
+type Classifier interface {
+	Classify(ctx context.Context, text string) (Decision, string, error)
+}
+
+// LLMFilter は Classifier の判定をそのまま使うフィルタ
+type LLMFilter struct {
+	Classifier Classifier
+}
+
+func (f *LLMFilter) Name() string { return "llm" }
+
+func (f *LLMFilter) Check(ctx context.Context, in Input) (Result, error) {
+	decision, reason, err := f.Classifier.Classify(ctx, in.Content)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Decision: decision, Reason: reason}, nil
+}
+
+// GeminiClassifier は Gemini でスパム・誹謗中傷などを判定する
+type GeminiClassifier struct {
+	APIKey string
+	Model  string
+}
+
+const classifyPrompt = `
+あなたは地域SNSのモデレーターです。次の投稿を判定し、JSONのみで回答してください。
+- 明らかなスパム、犯罪予告、差別・誹謗中傷: "reject"
+- 判断に迷う、宣伝の可能性がある: "hold"
+- 問題なし: "allow"
+出力形式: {"decision": "allow|hold|reject", "reason": "理由を短く"}
+投稿: `
+
+var jsonObjectPattern = regexp.MustCompile(`\{[\s\S]*\}`)
+
+func (g *GeminiClassifier) Classify(ctx context.Context, text string) (Decision, string, error) {
+	client, err := genai.NewClient(ctx, option.WithAPIKey(g.APIKey))
+	if err != nil {
+		return Allow, "", err
+	}
+	defer client.Close()
+
+	model := client.GenerativeModel(g.Model)
+	var resp *genai.GenerateContentResponse
+	err = telemetry.ObserveLLM(ctx, "content_filter", g.Model, func(ctx context.Context) error {
+		resp, err = model.GenerateContent(ctx, genai.Text(classifyPrompt+text))
+		return err
+	})
+	if err != nil {
+		return Allow, "", err
+	}
+
+	raw := ""
+	for _, candidate := range resp.Candidates {
+		if candidate.Content == nil {
+			continue
+		}
+		for _, part := range candidate.Content.Parts {
+			if t, ok := part.(genai.Text); ok {
+				raw += string(t)
+			}
+		}
+	}
+
+	var result struct {
+		Decision string `json:"decision"`
+		Reason   string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(jsonObjectPattern.FindString(raw)), &result); err != nil {
+		return Allow, "", err
+	}
+
+	switch strings.ToLower(result.Decision) {
+	case "reject":
+		return Reject, result.Reason, nil
+	case "hold":
+		return Hold, result.Reason, nil
+	default:
+		return Allow, result.Reason, nil
+	}
+}
@@ -0,0 +1,129 @@
+package contentfilter
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+//go:embed ngwords.txt
+var defaultNGWords string
+
+// NGWordFilter は禁止語を含む投稿を判定する
+type NGWordFilter struct {
+	reject []ngWord
+	hold   []ngWord
+}
+
+// ngWord は正規化した禁止語
+// かなのみの語（しね・ころす等）は「シネマ」「このところすごく」のような他の語の一部に含まれやすいため、
+// 前後がかな以外（漢字・英数字・記号・空白・文頭・文末）の場合のみ一致とする
+type ngWord struct {
+	text     string
+	kanaOnly bool
+}
+
+// NewNGWordFilter は辞書を読み込む。
+// 辞書は1行1語で、行末に「<TAB>hold」を付けた語は拒否ではなく保留にする。「#」で始まる行はコメント。
+func NewNGWordFilter(r io.Reader) (*NGWordFilter, error) {
+	f := &NGWordFilter{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		word, action, _ := strings.Cut(line, "\t")
+		word = Normalize(word)
+		if word == "" {
+			continue
+		}
+		w := ngWord{text: word, kanaOnly: isKanaOnly(word)}
+		if strings.TrimSpace(action) == "hold" {
+			f.hold = append(f.hold, w)
+		} else {
+			f.reject = append(f.reject, w)
+		}
+	}
+	return f, scanner.Err()
+}
+
+// LoadNGWordFilter は path の辞書を読み込む。path が空なら組み込みの辞書を使う
+func LoadNGWordFilter(path string) (*NGWordFilter, error) {
+	if path == "" {
+		return NewNGWordFilter(strings.NewReader(defaultNGWords))
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewNGWordFilter(file)
+}
+
+func (f *NGWordFilter) Name() string { return "ngword" }
+
+func (f *NGWordFilter) Check(ctx context.Context, in Input) (Result, error) {
+	raw := in.Content + " " + strings.Join(in.Tags, " ")
+	text := Normalize(raw)
+	runs := kanaRuns(raw)
+	for _, w := range f.reject {
+		if w.match(text, runs) {
+			return Result{Decision: Reject, Reason: "contains prohibited word"}, nil
+		}
+	}
+	for _, w := range f.hold {
+		if w.match(text, runs) {
+			return Result{Decision: Hold, Reason: "contains sensitive word"}, nil
+		}
+	}
+	return Result{Decision: Allow}, nil
+}
+
+// match は正規化した本文 text（かなのみの語は本文のかなの並び runs）に禁止語が含まれるか判定する
+func (w ngWord) match(text string, runs map[string]bool) bool {
+	if w.kanaOnly {
+		return runs[w.text]
+	}
+	return strings.Contains(text, w.text)
+}
+
+// kanaRuns は s を Normalize と同様に正規化し、前後をかな以外で区切られたかなの並びを返す
+// 空白・記号も区切りとして扱う
+func kanaRuns(s string) map[string]bool {
+	runs := map[string]bool{}
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			runs[b.String()] = true
+			b.Reset()
+		}
+	}
+	for _, r := range norm.NFKC.String(s) {
+		if r = toHiragana(r); isKana(r) {
+			b.WriteRune(r)
+		} else {
+			flush()
+		}
+	}
+	flush()
+	return runs
+}
+
+func isKanaOnly(word string) bool {
+	for _, r := range word {
+		if !isKana(r) {
+			return false
+		}
+	}
+	return word != ""
+}
+
+func isKana(r rune) bool {
+	return (r >= 'ぁ' && r <= 'ゖ') || r == 'ー'
+}
@@ -0,0 +1,38 @@
+package contentfilter
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize は表記ゆれを吸収した比較用の文字列を返す。
+// 全角・半角の統一（NFKC）、小文字化、カタカナのひらがな化を行い、空白・記号を取り除く。
+// 「シ ね」「ｼﾈ」「し-ね」などを同じ文字列として扱うために使う。
+func Normalize(s string) string {
+	s = norm.NFKC.String(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'ァ' && r <= 'ヶ':
+			b.WriteRune(toHiragana(r))
+		case r == 'ー':
+			b.WriteRune(r)
+		case unicode.IsSpace(r), unicode.IsPunct(r), unicode.IsSymbol(r):
+			continue
+		default:
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// toHiragana はカタカナをひらがなにする（それ以外はそのまま返す）
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - 0x60
+	}
+	return r
+}
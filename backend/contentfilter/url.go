@@ -0,0 +1,69 @@
+package contentfilter
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"'）」]+`)
+
+// 短縮URLはリンク先が分からないため保留にする
+var defaultShortenerDomains = []string{"bit.ly", "t.co", "tinyurl.com", "goo.gl", "ow.ly", "is.gd"}
+
+// URLBlocklistFilter はブロック対象のドメインへのリンクを含む投稿を判定する
+type URLBlocklistFilter struct {
+	blocked    []string
+	shorteners []string
+}
+
+func NewURLBlocklistFilter(blocked []string) *URLBlocklistFilter {
+	f := &URLBlocklistFilter{shorteners: defaultShortenerDomains}
+	for _, d := range blocked {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			f.blocked = append(f.blocked, d)
+		}
+	}
+	return f
+}
+
+func (f *URLBlocklistFilter) Name() string { return "url_blocklist" }
+
+func (f *URLBlocklistFilter) Check(ctx context.Context, in Input) (Result, error) {
+	for _, host := range extractHosts(in.Content) {
+		if matchDomain(host, f.blocked) {
+			return Result{Decision: Reject, Reason: "links to blocked domain " + host}, nil
+		}
+		if matchDomain(host, f.shorteners) {
+			return Result{Decision: Hold, Reason: "contains shortened url " + host}, nil
+		}
+	}
+	return Result{Decision: Allow}, nil
+}
+
+func extractURLs(text string) []string {
+	return urlPattern.FindAllString(text, -1)
+}
+
+func extractHosts(text string) []string {
+	var hosts []string
+	for _, raw := range extractURLs(text) {
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		hosts = append(hosts, strings.ToLower(u.Hostname()))
+	}
+	return hosts
+}
+
+// matchDomain は host がドメインそのものかサブドメインなら true を返す
+func matchDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,81 +1,68 @@
 package db
 
 import (
+	"api/config"
+	"api/logging"
+	"context"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
-	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var db *gorm.DB
 
-func init() {
-	// .envファイルから環境変数を読み込む
-	if err := godotenv.Load(".env"); err != nil {
-		log.Println("No .env file found, using system environment variables")
-	}
-
-	host := os.Getenv("DB_HOST")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-	port := os.Getenv("DB_PORT")
-
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=require",
-		host, user, password, dbname, port)
-	fmt.Println("Using DSN:", dsn)
-
+// connect は cfg の接続先に接続する
+func connect(cfg config.Database) error {
 	// DBに接続（タイムアウト設定追加）
-	fmt.Println("Attempting to connect to database...")
+	slog.Info("Attempting to connect to database", "host", cfg.Host, "dbname", cfg.Name, "port", cfg.Port, "sslmode", cfg.SSLMode)
 	var err error
 
 	// PostgreSQLドライバーの設定を強化
-	config := postgres.Config{
-		DSN:                  dsn,
+	pgConfig := postgres.Config{
+		DSN:                  cfg.DSN(),
 		PreferSimpleProtocol: true, // Simple protocolを使用してprepared statementを回避
 	}
 
-	db, err = gorm.Open(postgres.New(config), &gorm.Config{
+	db, err = gorm.Open(postgres.New(pgConfig), &gorm.Config{
 		PrepareStmt:                              false, // prepared statementを無効化
 		DisableForeignKeyConstraintWhenMigrating: true,
 		SkipDefaultTransaction:                   true, // デフォルトトランザクションをスキップ
+		Logger:                                   logging.GormLogger{},
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
 	})
 	if err != nil {
-		log.Fatalf("failed to connect database: %v", err)
+		return fmt.Errorf("failed to connect database: %w", err)
 	}
 
 	// コネクションプールの設定
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("failed to get database instance: %v", err)
+		return fmt.Errorf("failed to get database instance: %w", err)
 	}
 
 	// 既存のprepared statementをクリア
 	if _, err := sqlDB.Exec("DEALLOCATE ALL"); err != nil {
-		log.Printf("Warning: Failed to deallocate prepared statements: %v", err)
+		slog.Warn("Failed to deallocate prepared statements", "error", err)
 	}
 
 	// PostgreSQL固有の設定でprepared statementを無効化
 	if _, err := sqlDB.Exec("SET SESSION prepared_statements = false"); err != nil {
-		log.Printf("Warning: Failed to disable prepared statements: %v", err)
+		slog.Warn("Failed to disable prepared statements", "error", err)
 	}
 
 	// コネクションプールの詳細設定
-	sqlDB.SetMaxIdleConns(2)                   // アイドル接続数をさらに減らす
-	sqlDB.SetMaxOpenConns(5)                   // 最大接続数をさらに制限
-	sqlDB.SetConnMaxLifetime(time.Minute * 15) // 接続の生存時間をさらに短く
-	sqlDB.SetConnMaxIdleTime(time.Minute * 2)  // アイドル時間をさらに短く
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
-	fmt.Println("Database connection successful!")
-
-	log.Println("Successfully connected to Supabase database via GORM")
+	slog.Info("Successfully connected to Supabase database via GORM")
+	return nil
 }
 
 // GetDB returns the gorm.DB instance
@@ -83,13 +70,38 @@ func GetDB() *gorm.DB {
 	return db
 }
 
-// Initialize initializes the database connection
-func Initialize() error {
+// Initialize はデータベースに接続してマイグレーションを実行する
+func Initialize(cfg config.Database) error {
+	if err := connect(cfg); err != nil {
+		return err
+	}
+	// 失敗しても起動は続ける（/readyz でスキーマが古いことを検知する）
 	AutoMigrate()
+	return nil
+}
+
+// Close はコネクションプールを閉じる（終了時）
+func Close() error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Ping はデータベースに接続できるか確認する
+func Ping(ctx context.Context) error {
 	if db == nil {
 		return fmt.Errorf("database not initialized")
 	}
-	return nil
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
 }
 
 // SafeDB は prepared statement エラーを回避するためのヘルパー関数
@@ -100,9 +112,15 @@ func SafeDB() *gorm.DB {
 	})
 }
 
+// Ctx は ctx を引き継いだ SafeDB を返す
+// リクエスト処理中は c.Request.Context() を渡すと、クエリのログにリクエストIDが付く
+func Ctx(ctx context.Context) *gorm.DB {
+	return SafeDB().WithContext(ctx)
+}
+
 // SafeTransaction は安全なトランザクションを実行する
-func SafeTransaction(fn func(*gorm.DB) error) error {
-	tx := SafeDB().Begin()
+func SafeTransaction(ctx context.Context, fn func(*gorm.DB) error) error {
+	tx := Ctx(ctx).Begin()
 	if tx.Error != nil {
 		return tx.Error
 	}
@@ -120,3 +138,8 @@ func SafeTransaction(fn func(*gorm.DB) error) error {
 
 	return tx.Commit().Error
 }
+
+// Visible はモデレーションで非表示にされた（valid = false の）行を除外するスコープ
+func Visible(tx *gorm.DB) *gorm.DB {
+	return tx.Where("valid = ?", true)
+}
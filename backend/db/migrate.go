@@ -2,12 +2,23 @@ package db
 
 import (
 	"api/types"
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
 )
 
+// SchemaVersion はこのビルドが前提とするスキーマのバージョン
+// モデルやマイグレーションを変更したら上げる
+const SchemaVersion = 5
+
+// identitiesBackfillVersion はログイン方法を identities に移行し終えたスキーマのバージョン
+const identitiesBackfillVersion = 5
+
 func AutoMigrate() error {
 	// 既存のLikesテーブルを削除（構造変更のため）
-	log.Println("Dropping existing likes tables for schema update...")
+	slog.Info("Dropping existing likes tables for schema update")
 	db.Exec("DROP TABLE IF EXISTS post_likes CASCADE;")
 	db.Exec("DROP TABLE IF EXISTS thread_likes CASCADE;")
 	db.Exec("DROP TABLE IF EXISTS event_likes CASCADE;")
@@ -20,17 +31,117 @@ func AutoMigrate() error {
 		&types.PostLikes{},
 		&types.ThreadLikes{},
 		&types.EventLikes{},
-		&types.EmailLogin{},
-		&types.GoogleLogin{},
+		&types.Identity{},
+		&types.DataExport{},
 		&types.Comment{},
 		&types.ThreadTable{},
+		&types.Attachment{},
+		&types.ContentAttachment{},
+		&types.Report{},
+		&types.FilterResult{},
+		&types.UserToken{},
+		&types.Category{},
+		&types.AuditLog{},
+		&types.SchemaMigration{},
 	)
 
 	if err != nil {
-		log.Printf("Migration failed: %v", err)
+		slog.Error("Migration failed", "error", err)
+		return err
+	}
+
+	// 旧 email_logins 相当のログイン方法を users から移行する（identitiesBackfillVersion より前のスキーマのみ）
+	// 毎回実行すると解除したログイン方法が古いパスワードで復活するため、移行後に users.password を空にする
+	// Google のログイン方法は Google のユーザーIDが保存されていないため、次回ログイン時に紐付ける
+	var applied int
+	if err := db.Model(&types.SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&applied).Error; err != nil {
+		slog.Error("Failed to read schema version", "error", err)
+		return err
+	}
+	if applied < identitiesBackfillVersion {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+                INSERT INTO identities (user_id, provider, subject, email, password_hash, created_at, updated_at)
+                SELECT id, 'email', LOWER(email), email, password, created_at, NOW()
+                FROM users
+                WHERE login_type = 'email' AND password <> ''
+                ON CONFLICT DO NOTHING
+            `).Error; err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE users SET password = '' WHERE password <> ''").Error
+		}); err != nil {
+			slog.Error("Identity backfill failed", "error", err)
+			return err
+		}
+	}
+
+	// users.deleted_at は以前 time.Time だったため、未削除のユーザーにゼロ値が入っている
+	if err := db.Exec("UPDATE users SET deleted_at = NULL WHERE deleted_at < '0002-01-01'").Error; err != nil {
+		slog.Error("Failed to clear zero deleted_at on users", "error", err)
+		return err
+	}
+
+	// カテゴリの既定値は category パッケージで設定するようにしたため、未設定のものは other にする
+	// テストデータの 'communication' は 'community' の誤り
+	for _, table := range []string{"posts", "threads", "events"} {
+		if err := db.Exec("UPDATE " + table + " SET category = 'community' WHERE category = 'communication'").Error; err != nil {
+			slog.Error("Failed to normalize categories", "table", table, "error", err)
+			return err
+		}
+		if err := db.Exec("UPDATE " + table + " SET category = 'other' WHERE category IS NULL OR category = ''").Error; err != nil {
+			slog.Error("Failed to normalize categories", "table", table, "error", err)
+			return err
+		}
+	}
+
+	// 監査ログは追記のみ（UPDATE・DELETE をトリガーで拒否する）
+	for _, stmt := range []string{
+		`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+        BEGIN
+            RAISE EXCEPTION 'audit_logs is append-only';
+        END;
+        $$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs",
+		`CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+            FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			slog.Error("Failed to create audit log trigger", "error", err)
+			return err
+		}
+	}
+
+	// 退会したユーザーの投稿の付け替え先
+	if err := db.Exec(`
+        INSERT INTO users (id, name, email, password, login_type, valid, created_at, updated_at)
+        VALUES (?, ?, 'deleted-user@invalid', '', 'email', false, NOW(), NOW())
+        ON CONFLICT DO NOTHING
+    `, types.DeletedUserID, types.DeletedUserName).Error; err != nil {
+		slog.Error("Failed to create deleted user placeholder", "error", err)
+		return err
+	}
+
+	if err := db.Exec(
+		"INSERT INTO schema_migrations (version, applied_at) VALUES (?, NOW()) ON CONFLICT DO NOTHING",
+		SchemaVersion,
+	).Error; err != nil {
+		slog.Error("Failed to record schema version", "error", err)
 		return err
 	}
 
-	log.Println("Database migration completed with schema updates")
+	slog.Info("Database migration completed with schema updates", "version", SchemaVersion)
+	return nil
+}
+
+// CheckSchema は適用済みのスキーマがこのビルドの前提より古くないか確認する
+func CheckSchema(ctx context.Context) error {
+	var version int
+	if err := Ctx(ctx).Model(&types.SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return err
+	}
+	if version < SchemaVersion {
+		return fmt.Errorf("schema version %d is older than required version %d", version, SchemaVersion)
+	}
 	return nil
 }
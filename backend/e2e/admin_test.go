@@ -0,0 +1,235 @@
+package e2e
+
+import (
+	"api/apierror"
+	"api/audit"
+	"api/db"
+	"api/handlers"
+	"api/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// auditActions は対象の監査ログの操作を古い順に返す
+func auditActions(t *testing.T, targetType, targetID string) []string {
+	t.Helper()
+	var actions []string
+	if err := db.Ctx(context.Background()).Model(&types.AuditLog{}).
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Order("id").Pluck("action", &actions).Error; err != nil {
+		t.Fatal(err)
+	}
+	return actions
+}
+
+// 利用停止中は発行済みのトークンもログインも 403 になり、解除・期限切れで元に戻ること
+func TestAdminSuspension(t *testing.T) {
+	requireEnv(t)
+	adminTok := tokenFor(t, createUser(t, withRole(types.RoleAdmin)))
+	user := createUser(t)
+	tok := tokenFor(t, user)
+	base := "/api/v2/admin/users/" + user.ID.String()
+	login := obj{"email": user.Email, "password": testPassword}
+
+	suspended := func(t *testing.T, status string) {
+		t.Helper()
+		if code := request(t, http.MethodGet, "/api/v2/auth/me", nil, tok).expect(t, http.StatusForbidden).errorCode(t); code != apierror.CodeAccountSuspended {
+			t.Errorf("code = %s, want %s", code, apierror.CodeAccountSuspended)
+		}
+		request(t, http.MethodPost, "/api/v2/auth/login", login, "").expect(t, http.StatusForbidden)
+
+		var page struct {
+			Items []types.AdminUserResponse `json:"items"`
+		}
+		request(t, http.MethodGet, "/api/v2/admin/users?status="+status+"&q="+user.Email, nil, adminTok).
+			expect(t, http.StatusOK).decode(t, &page)
+		if len(page.Items) != 1 || page.Items[0].ID != user.ID {
+			t.Errorf("users with status %s = %+v, want only %s", status, page.Items, user.ID)
+		}
+	}
+
+	request(t, http.MethodPost, base+"/suspend", obj{"reason": "spam", "until": time.Now().Add(time.Hour)}, adminTok).expect(t, http.StatusOK)
+	suspended(t, types.UserSuspended)
+
+	request(t, http.MethodPost, base+"/reinstate", nil, adminTok).expect(t, http.StatusOK)
+	request(t, http.MethodGet, "/api/v2/auth/me", nil, tok).expect(t, http.StatusOK)
+
+	request(t, http.MethodPost, base+"/ban", obj{"reason": "spam"}, adminTok).expect(t, http.StatusOK)
+	suspended(t, types.UserBanned)
+
+	// 期限を過ぎた利用停止は解除前でも通し、定期実行で valid に戻す
+	if err := db.Ctx(context.Background()).Model(&types.User{}).Where("id = ?", user.ID).
+		Update("suspended_until", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	request(t, http.MethodGet, "/api/v2/auth/me", nil, tok).expect(t, http.StatusOK)
+	if err := handlers.LiftExpiredSuspensions(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	request(t, http.MethodGet, "/api/v2/users/"+user.ID.String(), nil, "").expect(t, http.StatusOK)
+
+	want := []string{audit.ActionUserSuspend, audit.ActionUserReinstate, audit.ActionUserBan}
+	if got := auditActions(t, audit.TargetUser, user.ID.String()); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("audit actions = %v, want %v", got, want)
+	}
+}
+
+// 権限の変更は即座に反映され、自分自身の権限は変更できないこと
+func TestAdminUpdateRole(t *testing.T) {
+	requireEnv(t)
+	admin := createUser(t, withRole(types.RoleAdmin))
+	adminTok := tokenFor(t, admin)
+	user := createUser(t)
+	tok := tokenFor(t, user)
+
+	request(t, http.MethodGet, "/api/v2/moderation/reports", nil, tok).expect(t, http.StatusForbidden)
+	var res types.AdminUserResponse
+	request(t, http.MethodPut, "/api/v2/admin/users/"+user.ID.String()+"/role", obj{"role": types.RoleModerator}, adminTok).
+		expect(t, http.StatusOK).decode(t, &res)
+	if res.Role != types.RoleModerator {
+		t.Errorf("role = %s, want %s", res.Role, types.RoleModerator)
+	}
+	request(t, http.MethodGet, "/api/v2/moderation/reports", nil, tok).expect(t, http.StatusOK)
+
+	request(t, http.MethodPut, "/api/v2/admin/users/"+admin.ID.String()+"/role", obj{"role": types.RoleUser}, adminTok).
+		expect(t, http.StatusBadRequest)
+	request(t, http.MethodGet, "/api/v2/admin/stats", nil, adminTok).expect(t, http.StatusOK)
+}
+
+// 削除した投稿を一覧で確認して戻せること（退会で消去したものは戻せない）
+func TestAdminRestoreDeletedContent(t *testing.T) {
+	requireEnv(t)
+	adminTok := tokenFor(t, createUser(t, withRole(types.RoleAdmin)))
+	author := createUser(t)
+	post := createPost(t, author)
+	path := fmt.Sprintf("/api/v2/posts/%d", post.ID)
+
+	request(t, http.MethodDelete, path, nil, tokenFor(t, author)).expect(t, http.StatusOK)
+	request(t, http.MethodGet, path, nil, "").expect(t, http.StatusNotFound)
+
+	var page struct {
+		Items []types.DeletedContentResponse `json:"items"`
+	}
+	request(t, http.MethodGet, "/api/v2/admin/deleted/post?limit=100", nil, adminTok).expect(t, http.StatusOK).decode(t, &page)
+	found := false
+	for _, item := range page.Items {
+		if item.ID == post.ID {
+			found = item.Type == "post" && item.Content == post.Content && item.UserID == author.ID
+		}
+	}
+	if !found {
+		t.Errorf("deleted post %d is not listed: %+v", post.ID, page.Items)
+	}
+
+	request(t, http.MethodPost, fmt.Sprintf("/api/v2/admin/deleted/post/%d/restore", post.ID), nil, adminTok).expect(t, http.StatusOK)
+	request(t, http.MethodGet, path, nil, "").expect(t, http.StatusOK)
+	if got := auditActions(t, "post", fmt.Sprint(post.ID)); len(got) == 0 || got[len(got)-1] != "post.undelete" {
+		t.Errorf("audit actions = %v, want [... post.undelete]", got)
+	}
+
+	purged := createPost(t, author)
+	if err := db.Ctx(context.Background()).Model(&purged).Updates(map[string]any{"user_id": types.DeletedUserID, "content": ""}).Error; err != nil {
+		t.Fatal(err)
+	}
+	mustDelete(t, &purged)
+	request(t, http.MethodPost, fmt.Sprintf("/api/v2/admin/deleted/post/%d/restore", purged.ID), nil, adminTok).expect(t, http.StatusConflict)
+}
+
+// 監査ログは変更・削除できないこと
+func TestAuditLogAppendOnly(t *testing.T) {
+	requireEnv(t)
+	ctx := context.Background()
+	if err := audit.Record(db.Ctx(ctx), audit.Entry{Action: "test.append_only", TargetType: "test", Detail: obj{"n": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	var log types.AuditLog
+	if err := db.Ctx(ctx).Where("action = ?", "test.append_only").Last(&log).Error; err != nil {
+		t.Fatal(err)
+	}
+	if string(log.Detail) != `{"n": 1}` && string(log.Detail) != `{"n":1}` {
+		t.Errorf("detail = %s", log.Detail)
+	}
+
+	if err := db.Ctx(ctx).Model(&log).Update("action", "test.changed").Error; err == nil {
+		t.Error("updating an audit log should fail")
+	}
+	if err := db.Ctx(ctx).Delete(&log).Error; err == nil {
+		t.Error("deleting an audit log should fail")
+	}
+}
+
+// 投稿の作成・編集・削除が操作したユーザー・変更した項目・リクエストIDと共に記録され、管理者が検索できること
+func TestAuditLogContentChanges(t *testing.T) {
+	requireEnv(t)
+	adminTok := tokenFor(t, createUser(t, withRole(types.RoleAdmin)))
+	author := createUser(t)
+	tok := tokenFor(t, author)
+
+	original, changed := unique(t, "before"), unique(t, "after")
+
+	var post types.PostResponse
+	request(t, http.MethodPost, "/api/v2/posts", obj{"content": original, "coordinate": tokyo, "category": types.CategoryCommunity}, tok).
+		expect(t, http.StatusCreated).decode(t, &post)
+	path := fmt.Sprintf("/api/v2/posts/%d", post.ID)
+	edited := request(t, http.MethodPatch, path, obj{"content": changed}, tok).expect(t, http.StatusOK)
+	request(t, http.MethodDelete, path, nil, tok).expect(t, http.StatusOK)
+
+	want := []string{"post.create", "post.update", "post.delete"}
+	if got := auditActions(t, "post", fmt.Sprint(post.ID)); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("audit actions = %v, want %v", got, want)
+	}
+
+	var page struct {
+		Items []types.AuditLog `json:"items"`
+		Total int64            `json:"total"`
+	}
+	requestID := edited.Header.Get("X-Request-ID")
+	request(t, http.MethodGet, "/api/v2/admin/audit-logs?request_id="+requestID, nil, adminTok).
+		expect(t, http.StatusOK).decode(t, &page)
+	if page.Total != 1 || len(page.Items) != 1 {
+		t.Fatalf("audit logs for request %s = %+v", requestID, page.Items)
+	}
+	log := page.Items[0]
+	if log.Action != "post.update" || log.ActorID == nil || *log.ActorID != author.ID {
+		t.Errorf("audit log = %+v, want post.update by %s", log, author.ID)
+	}
+	var before, after map[string]any
+	if err := json.Unmarshal(log.Before, &before); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(log.After, &after); err != nil {
+		t.Fatal(err)
+	}
+	if len(before) != 1 || before["content"] != original || len(after) != 1 || after["content"] != changed {
+		t.Errorf("diff = %v -> %v, want only content", before, after)
+	}
+
+	// 利用者は監査ログを参照できない
+	request(t, http.MethodGet, "/api/v2/admin/audit-logs", nil, tok).expect(t, http.StatusForbidden)
+}
+
+// パスワードの変更は監査ログに記録し、パスワードやハッシュは含めないこと
+func TestAuditLogPasswordChange(t *testing.T) {
+	requireEnv(t)
+	user := createUser(t)
+	const newPassword = "new-password-123"
+	request(t, http.MethodPut, "/api/v2/me/password", obj{"current_password": testPassword, "new_password": newPassword}, tokenFor(t, user)).
+		expect(t, http.StatusOK)
+
+	var logs []types.AuditLog
+	if err := db.Ctx(context.Background()).Where("target_type = ? AND target_id = ?", audit.TargetUser, user.ID.String()).Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Action != audit.ActionUserPassword || logs[0].ActorID == nil || *logs[0].ActorID != user.ID {
+		t.Fatalf("audit logs = %+v, want one %s by %s", logs, audit.ActionUserPassword, user.ID)
+	}
+	recorded := string(logs[0].Before) + string(logs[0].After) + string(logs[0].Detail)
+	if strings.Contains(recorded, newPassword) || strings.Contains(recorded, "$2a$") {
+		t.Errorf("audit log contains the password: %s", recorded)
+	}
+}
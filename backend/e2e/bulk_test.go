@@ -0,0 +1,148 @@
+package e2e
+
+import (
+	"api/bulk"
+	"api/types"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// exported は書き出した内容を "種類:ID" 毎に返す
+func exported(t *testing.T, res response, format string) map[string]bulk.Record {
+	t.Helper()
+	res.expect(t, http.StatusOK)
+	rows, errs, err := bulk.Decode(bytes.NewReader(res.Body), format)
+	if err != nil || len(errs) > 0 {
+		t.Fatalf("failed to decode export: %v %+v", err, errs)
+	}
+	records := map[string]bulk.Record{}
+	for _, row := range rows {
+		records[key(row.Record.Type, row.Record.ID)] = row.Record
+	}
+	return records
+}
+
+func key(kind string, id uint) string {
+	return fmt.Sprintf("%s:%d", kind, id)
+}
+
+func TestBulkExport(t *testing.T) {
+	requireEnv(t)
+	// 他の試験の投稿と重ならない地点（紀伊水道）
+	center := types.Coordinate{Lat: 33.95, Lng: 134.95}
+	bbox := fmt.Sprintf("%f,%f,%f,%f", center.Lng-0.01, center.Lat-0.01, center.Lng+0.01, center.Lat+0.01)
+	author := createUser(t)
+	adminTok := tokenFor(t, createUser(t, withRole(types.RoleAdmin)))
+
+	event := createEvent(t, author, at(center), inCategory(types.CategoryCommunity))
+	post := createPost(t, author, at(center), inCategory(types.CategoryDisaster))
+	hiddenPost := createPost(t, author, at(center), hidden())
+	outside := createPost(t, author, at(offset(center, 5000)))
+
+	for _, format := range bulk.Formats {
+		t.Run(format, func(t *testing.T) {
+			records := exported(t, request(t, http.MethodGet, "/api/v2/admin/export?format="+format+"&bbox="+bbox, nil, adminTok), format)
+			if _, ok := records[key(bulk.KindPost, post.ID)]; !ok {
+				t.Errorf("post %d should be exported", post.ID)
+			}
+			if r, ok := records[key(bulk.KindEvent, event.ID)]; !ok || r.Type != bulk.KindEvent || r.EventDate == nil {
+				t.Errorf("event %d should be exported with its date: %+v", event.ID, r)
+			}
+			if _, ok := records[key(bulk.KindPost, hiddenPost.ID)]; ok {
+				t.Errorf("hidden post %d should not be exported", hiddenPost.ID)
+			}
+			if _, ok := records[key(bulk.KindPost, outside.ID)]; ok {
+				t.Errorf("post %d outside the bbox should not be exported", outside.ID)
+			}
+		})
+	}
+
+	// 種類・カテゴリ・非表示の絞り込み
+	records := exported(t, request(t, http.MethodGet,
+		"/api/v2/admin/export?format=ndjson&type=post&category=other&include_hidden=true&bbox="+bbox, nil, adminTok), bulk.FormatNDJSON)
+	for k, r := range records {
+		if r.Type != bulk.KindPost || r.Category != types.CategoryOther {
+			t.Errorf("%s should not be exported: %+v", k, r)
+		}
+	}
+	if _, ok := records[key(bulk.KindPost, hiddenPost.ID)]; !ok {
+		t.Errorf("hidden post %d should be exported with include_hidden", hiddenPost.ID)
+	}
+	if _, ok := records[key(bulk.KindPost, post.ID)]; ok {
+		t.Errorf("post %d in another category should not be exported", post.ID)
+	}
+}
+
+func TestBulkImport(t *testing.T) {
+	requireEnv(t)
+	admin := createUser(t, withRole(types.RoleAdmin))
+	adminTok := tokenFor(t, admin)
+	official := createUser(t)
+	existing := createEvent(t, official, at(osaka))
+
+	newEvent := unique(t, "市民マラソン")
+	csv := strings.Join([]string{
+		"type,content,category,lat,lng,tags,event_date",
+		fmt.Sprintf("event,%s,community,34.70,135.50,スポーツ|マラソン,2030-03-01 09:00", newEvent),
+		// 前の行と同じ内容
+		fmt.Sprintf("event,%s,community,34.70,135.50,,2030-03-01 09:00", newEvent),
+		// 既存のイベントと同じ内容
+		fmt.Sprintf("event,%s,%s,%f,%f,,%s", existing.Content, existing.Category, existing.Coordinate.Lat, existing.Coordinate.Lng,
+			existing.EventDate.Format("2006-01-02T15:04:05Z07:00")),
+		// 開催日時が無い
+		"event," + unique(t, "日時なし") + ",community,34.70,135.50,,",
+		// 範囲外の緯度
+		"post," + unique(t, "範囲外") + ",other,95,135.50,,",
+	}, "\n")
+	path := "/api/v2/admin/import?user_id=" + official.ID.String()
+
+	var res bulk.ImportResult
+	request(t, http.MethodPost, path+"&dry_run=true", upload{"file", "calendar.csv", []byte(csv)}, adminTok).
+		expect(t, http.StatusOK).decode(t, &res)
+	want := func(dryRun bool) {
+		t.Helper()
+		if res.DryRun != dryRun || res.Total != 5 || res.Imported != 1 {
+			t.Errorf("got %+v", res)
+		}
+		if fmt.Sprint(res.Duplicates) != "[3 4]" {
+			t.Errorf("duplicates %v, want [3 4]", res.Duplicates)
+		}
+		if len(res.Errors) != 2 || res.Errors[0].Line != 5 || res.Errors[0].Field != "event_date" ||
+			res.Errors[1].Line != 6 || res.Errors[1].Field != "lat" {
+			t.Errorf("errors %+v", res.Errors)
+		}
+	}
+	want(true)
+	count := func() int {
+		var page struct {
+			Items []types.EventResponse `json:"items"`
+		}
+		request(t, http.MethodGet, "/api/v2/users/"+official.ID.String()+"/events?limit=100", nil, "").
+			expect(t, http.StatusOK).decode(t, &page)
+		return len(page.Items)
+	}
+	if n := count(); n != 1 {
+		t.Fatalf("dry run should not save: %d events", n)
+	}
+
+	request(t, http.MethodPost, path, upload{"file", "calendar.csv", []byte(csv)}, adminTok).
+		expect(t, http.StatusOK).decode(t, &res)
+	want(false)
+	if n := count(); n != 2 {
+		t.Fatalf("got %d events, want 2", n)
+	}
+
+	// 同じファイルをもう一度取り込むと全て重複になる
+	request(t, http.MethodPost, path, upload{"file", "calendar.csv", []byte(csv)}, adminTok).
+		expect(t, http.StatusOK).decode(t, &res)
+	if res.Imported != 0 || len(res.Duplicates) != 3 {
+		t.Errorf("re-import: got %+v", res)
+	}
+
+	// ファイル全体を読めない場合
+	request(t, http.MethodPost, path, upload{"file", "calendar.geojson", []byte(`{"type":"Feature"}`)}, adminTok).
+		expect(t, http.StatusBadRequest)
+}
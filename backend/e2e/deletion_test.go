@@ -0,0 +1,33 @@
+package e2e
+
+import (
+	"api/apierror"
+	"net/http"
+	"testing"
+)
+
+// 退会手続き中は書き込み等を 403 で拒否し、個人データのエクスポートのみ利用できること
+// ログインし直すと退会が取り消され、再び書き込めること
+func TestPendingDeletion(t *testing.T) {
+	requireEnv(t)
+	user := createUser(t)
+	tok := tokenFor(t, user)
+	request(t, http.MethodDelete, "/api/v2/me", obj{"password": testPassword}, tok).expect(t, http.StatusOK)
+
+	post := obj{"content": unique(t, "post"), "coordinate": tokyo}
+	for _, path := range []string{"/api/v1/create/post", "/api/v2/posts"} {
+		res := request(t, http.MethodPost, path, post, tok).expect(t, http.StatusForbidden)
+		if code := res.errorCode(t); code != apierror.CodeAccountPendingDeletion {
+			t.Errorf("%s: code = %s, want %s", path, code, apierror.CodeAccountPendingDeletion)
+		}
+	}
+	request(t, http.MethodPut, "/api/v2/me", obj{"bio": "still here"}, tok).expect(t, http.StatusForbidden)
+	request(t, http.MethodGet, "/api/v2/me/export", nil, tok).expect(t, http.StatusAccepted)
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	request(t, http.MethodPost, "/api/v2/auth/login", obj{"email": user.Email, "password": testPassword}, "").
+		expect(t, http.StatusOK).decode(t, &auth)
+	request(t, http.MethodPost, "/api/v2/posts", post, auth.Token).expect(t, http.StatusCreated)
+}
@@ -0,0 +1,27 @@
+package e2e
+
+import (
+	"api/types"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// 作成済みのエクスポートのダウンロード先はリクエストと同じバージョンのルートになること
+func TestExportDownloadURL(t *testing.T) {
+	requireEnv(t)
+	user := createUser(t)
+	tok := tokenFor(t, user)
+	expires := time.Now().Add(time.Hour)
+	mustCreate(t, &types.DataExport{UserID: user.ID, Status: types.ExportReady, ExpiresAt: &expires})
+
+	for _, prefix := range []string{"/api/v1", "/api/v2"} {
+		var res struct {
+			DownloadURL string `json:"download_url"`
+		}
+		request(t, http.MethodGet, prefix+"/me/export", nil, tok).expect(t, http.StatusOK).decode(t, &res)
+		if want := prefix + "/me/export/download"; res.DownloadURL != want {
+			t.Errorf("download_url = %s, want %s", res.DownloadURL, want)
+		}
+	}
+}
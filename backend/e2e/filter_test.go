@@ -0,0 +1,32 @@
+package e2e
+
+import (
+	"api/types"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// 作成直後に本文を変えずにタグ・カテゴリのみ編集しても、自分自身との重複として拒否されないこと
+func TestEditMetadataOnly(t *testing.T) {
+	requireEnv(t)
+	tok := tokenFor(t, createUser(t))
+	content := unique(t, "post")
+
+	var post types.PostResponse
+	request(t, http.MethodPost, "/api/v2/posts", obj{"content": content, "coordinate": tokyo, "category": types.CategoryCommunity}, tok).
+		expect(t, http.StatusCreated).decode(t, &post)
+
+	var res struct {
+		Post types.PostResponse `json:"post"`
+	}
+	request(t, http.MethodPatch, fmt.Sprintf("/api/v2/posts/%d", post.ID), obj{"tags": []string{"桜"}}, tok).
+		expect(t, http.StatusOK).decode(t, &res)
+	if res.Post.Content != content || len(res.Post.Tags) != 1 {
+		t.Errorf("edited post = %+v", res.Post)
+	}
+
+	// 別の投稿として同じ本文を投稿した場合は拒否する
+	request(t, http.MethodPost, "/api/v2/posts", obj{"content": content, "coordinate": tokyo, "category": types.CategoryCommunity}, tok).
+		expect(t, http.StatusUnprocessableEntity)
+}
@@ -0,0 +1,285 @@
+package e2e
+
+import (
+	"api/db"
+	"api/geo"
+	"api/types"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testPassword は createUser で作るユーザーのパスワード
+const testPassword = "correct-horse-battery"
+
+// 座標の例（カテゴリの表示範囲より十分に離す）
+var (
+	tokyo   = types.Coordinate{Lat: 35.6812, Lng: 139.7671}
+	osaka   = types.Coordinate{Lat: 34.7025, Lng: 135.4959}
+	sapporo = types.Coordinate{Lat: 43.0687, Lng: 141.3508}
+)
+
+var seq atomic.Int64
+
+// unique は試験毎に重複しない文字列を返す（重複投稿フィルタに掛からないよう本文に使う）
+func unique(t *testing.T, prefix string) string {
+	return fmt.Sprintf("%s %s #%d", prefix, t.Name(), seq.Add(1))
+}
+
+// userOption は createUser の既定値を変更する
+type userOption func(*types.User)
+
+func withRole(role string) userOption {
+	return func(u *types.User) { u.Role = role }
+}
+
+func unverified() userOption {
+	return func(u *types.User) { u.EmailVerified = false }
+}
+
+// createUser はメールアドレスでログインできるユーザー（パスワードは testPassword）を作る
+func createUser(t *testing.T, opts ...userOption) types.User {
+	t.Helper()
+	id := uuid.New()
+	user := types.User{
+		ID:            id,
+		Name:          "user-" + id.String()[:8],
+		Email:         id.String() + "@example.com",
+		EmailVerified: true,
+		Valid:         true,
+		LoginType:     types.ProviderEmail,
+		Role:          types.RoleUser,
+	}
+	for _, opt := range opts {
+		opt(&user)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustCreate(t, &user)
+	mustCreate(t, &types.Identity{
+		UserID:       user.ID,
+		Provider:     types.ProviderEmail,
+		Subject:      strings.ToLower(user.Email),
+		Email:        user.Email,
+		PasswordHash: string(hash),
+	})
+	return user
+}
+
+// linkGoogle はユーザーに Google のログイン方法を紐付ける
+func linkGoogle(t *testing.T, user types.User) {
+	t.Helper()
+	mustCreate(t, &types.Identity{
+		UserID:   user.ID,
+		Provider: types.ProviderGoogle,
+		Subject:  "google-" + user.ID.String(),
+		Email:    user.Email,
+	})
+}
+
+// googleToken はスタブの userinfo エンドポイントで使えるアクセストークンを発行する
+func googleToken(t *testing.T, sub, email string) string {
+	t.Helper()
+	token := "google-token-" + uuid.NewString()
+	googleProfiles.Store(token, map[string]any{
+		"sub":            sub,
+		"email":          email,
+		"email_verified": true,
+		"name":           "Google User",
+	})
+	return token
+}
+
+// tokenFor は handlers.generateJWT と同じ形式のトークンを作る
+func tokenFor(t *testing.T, user types.User) string {
+	t.Helper()
+	return tokenIssuedAt(t, user, time.Now())
+}
+
+// tokenIssuedAt は iat を指定して JWT を発行する
+func tokenIssuedAt(t *testing.T, user types.User, iat time.Time) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"exp":     iat.Add(time.Hour).Unix(),
+		"iat":     iat.Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.Auth.JWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// issueUserToken はメール確認・パスワード再設定用のトークンを発行して平文を返す（メールの代わり）
+func issueUserToken(t *testing.T, user types.User, purpose string) string {
+	t.Helper()
+	raw := uuid.NewString()
+	sum := sha256.Sum256([]byte(raw))
+	mustCreate(t, &types.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hex.EncodeToString(sum[:]),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	return raw
+}
+
+// content は投稿・スレッド・イベントの共通の項目
+type content struct {
+	At        types.Coordinate
+	Category  string
+	Valid     bool
+	Precision string
+}
+
+// contentOption は createPost 等の既定値（東京・その他・表示・座標をそのまま公開）を変更する
+type contentOption func(*content)
+
+func at(c types.Coordinate) contentOption {
+	return func(o *content) { o.At = c }
+}
+
+func inCategory(id string) contentOption {
+	return func(o *content) { o.Category = id }
+}
+
+func hidden() contentOption {
+	return func(o *content) { o.Valid = false }
+}
+
+func withPrecision(precision string) contentOption {
+	return func(o *content) { o.Precision = precision }
+}
+
+func newContent(opts []contentOption) content {
+	o := content{At: tokyo, Category: types.CategoryOther, Valid: true, Precision: geo.PrecisionExact}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func createPost(t *testing.T, author types.User, opts ...contentOption) types.Post {
+	t.Helper()
+	o := newContent(opts)
+	post := types.Post{
+		UserID:     author.ID,
+		Username:   author.Name,
+		Coordinate: o.At,
+		Precision:  o.Precision,
+		Content:    unique(t, "post"),
+		Category:   o.Category,
+		Valid:      o.Valid,
+	}
+	mustCreate(t, &post)
+	return post
+}
+
+func createThread(t *testing.T, author types.User, opts ...contentOption) types.Thread {
+	t.Helper()
+	o := newContent(opts)
+	thread := types.Thread{
+		UserID:     author.ID,
+		Username:   author.Name,
+		Coordinate: o.At,
+		Precision:  o.Precision,
+		Content:    unique(t, "thread"),
+		Category:   o.Category,
+		Valid:      o.Valid,
+	}
+	mustCreate(t, &thread)
+	return thread
+}
+
+func createEvent(t *testing.T, author types.User, opts ...contentOption) types.Event {
+	t.Helper()
+	o := newContent(opts)
+	event := types.Event{
+		UserID:     author.ID,
+		Username:   author.Name,
+		Coordinate: o.At,
+		Precision:  o.Precision,
+		Content:    unique(t, "event"),
+		Category:   o.Category,
+		Valid:      o.Valid,
+		EventDate:  time.Now().Add(24 * time.Hour),
+	}
+	mustCreate(t, &event)
+	return event
+}
+
+func createComment(t *testing.T, author types.User, thread types.Thread) types.Comment {
+	t.Helper()
+	comment := types.Comment{
+		UserID:     author.ID,
+		Username:   author.Name,
+		ThreadID:   thread.ID,
+		Coordinate: thread.Coordinate,
+		Precision:  "exact",
+		Content:    unique(t, "comment"),
+		Valid:      true,
+	}
+	mustCreate(t, &comment)
+	return comment
+}
+
+// createReport は未対応の通報を作る
+func createReport(t *testing.T, reporter types.User, targetType string, targetID uint) types.Report {
+	t.Helper()
+	report := types.Report{
+		ReporterID: reporter.ID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     "spam",
+		Status:     types.ReportOpen,
+	}
+	mustCreate(t, &report)
+	return report
+}
+
+func mustCreate(t *testing.T, v any) {
+	t.Helper()
+	if err := db.Ctx(context.Background()).Create(v).Error; err != nil {
+		t.Fatalf("failed to create %T: %v", v, err)
+	}
+}
+
+// mustDelete は v を論理削除する
+func mustDelete(t *testing.T, v any) {
+	t.Helper()
+	if err := db.Ctx(context.Background()).Delete(v).Error; err != nil {
+		t.Fatalf("failed to delete %T: %v", v, err)
+	}
+}
+
+// pngImage はアップロード用の小さな PNG 画像を返す
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 32), G: uint8(y * 32), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
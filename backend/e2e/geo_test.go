@@ -0,0 +1,230 @@
+package e2e
+
+import (
+	"api/db"
+	"api/geo"
+	"api/types"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// 地域のカテゴリ（その他: 半径 1100m）と全国のカテゴリ（防災）の表示範囲
+// DB は他の試験と共有するため、件数ではなく作成した ID が含まれるかで確認する
+
+// listIDs は一覧の応答に含まれる ID を返す
+func listIDs(t *testing.T, res response) map[uint]bool {
+	t.Helper()
+	var items []struct {
+		ID uint `json:"id"`
+	}
+	res.expect(t, http.StatusOK).decode(t, &items)
+	ids := map[uint]bool{}
+	for _, item := range items {
+		ids[item.ID] = true
+	}
+	return ids
+}
+
+// offset は c から北に meters だけ離れた座標を返す
+func offset(c types.Coordinate, meters float64) types.Coordinate {
+	return types.Coordinate{Lat: c.Lat + meters/111_000, Lng: c.Lng}
+}
+
+// geoFixture は各種類の一覧の試験に使う投稿等
+type geoFixture struct {
+	near, edge, far, nationwide, hiddenNear uint
+}
+
+// kinds は投稿・スレッド・イベントで同じ試験を行うための作成関数
+var kinds = []struct {
+	name   string
+	create func(t *testing.T, author types.User, opts ...contentOption) uint
+}{
+	{"posts", func(t *testing.T, u types.User, opts ...contentOption) uint { return createPost(t, u, opts...).ID }},
+	{"threads", func(t *testing.T, u types.User, opts ...contentOption) uint { return createThread(t, u, opts...).ID }},
+	{"events", func(t *testing.T, u types.User, opts ...contentOption) uint { return createEvent(t, u, opts...).ID }},
+}
+
+func TestListGeoFiltering(t *testing.T) {
+	requireEnv(t)
+	// 他の試験の投稿と重ならない地点（大阪の沖合）
+	center := types.Coordinate{Lat: 34.30, Lng: 135.10}
+	author := createUser(t)
+
+	for _, kind := range kinds {
+		t.Run(kind.name, func(t *testing.T) {
+			f := geoFixture{
+				near:       kind.create(t, author, at(center)),
+				edge:       kind.create(t, author, at(offset(center, 900))),
+				far:        kind.create(t, author, at(offset(center, 5000))),
+				nationwide: kind.create(t, author, at(sapporo), inCategory(types.CategoryDisaster)),
+				hiddenNear: kind.create(t, author, at(center), hidden()),
+			}
+			v1Path := "/api/v1/getall/" + kind.name[:len(kind.name)-1]
+			v2Path := "/api/v2/" + kind.name
+			near := fmt.Sprintf("lat=%f&lng=%f", center.Lat, center.Lng)
+
+			cases := []struct {
+				name     string
+				res      func() response
+				included []uint
+				excluded []uint
+			}{
+				{
+					name:     "v1 body coordinate",
+					res:      func() response { return request(t, http.MethodPost, v1Path, center, "") },
+					included: []uint{f.near, f.edge, f.nationwide},
+					excluded: []uint{f.far, f.hiddenNear},
+				},
+				{
+					name:     "v1 without coordinate",
+					res:      func() response { return request(t, http.MethodPost, v1Path, nil, "") },
+					included: []uint{f.nationwide},
+					excluded: []uint{f.near, f.far},
+				},
+				{
+					name:     "v2 category radius",
+					res:      func() response { return request(t, http.MethodGet, v2Path+"?"+near, nil, "") },
+					included: []uint{f.near, f.edge, f.nationwide},
+					excluded: []uint{f.far, f.hiddenNear},
+				},
+				{
+					name:     "v2 narrower radius",
+					res:      func() response { return request(t, http.MethodGet, v2Path+"?"+near+"&radius=500", nil, "") },
+					included: []uint{f.near, f.nationwide},
+					excluded: []uint{f.edge, f.far},
+				},
+				{
+					name:     "v2 wider radius",
+					res:      func() response { return request(t, http.MethodGet, v2Path+"?"+near+"&radius=10000", nil, "") },
+					included: []uint{f.near, f.edge, f.far, f.nationwide},
+					excluded: []uint{f.hiddenNear},
+				},
+				{
+					name:     "v2 without coordinate",
+					res:      func() response { return request(t, http.MethodGet, v2Path, nil, "") },
+					included: []uint{f.nationwide},
+					excluded: []uint{f.near, f.edge, f.far},
+				},
+				{
+					name: "v2 far away",
+					res: func() response {
+						return request(t, http.MethodGet, fmt.Sprintf("%s?lat=%f&lng=%f", v2Path, tokyo.Lat, tokyo.Lng), nil, "")
+					},
+					included: []uint{f.nationwide},
+					excluded: []uint{f.near, f.edge},
+				},
+			}
+			for _, tc := range cases {
+				t.Run(tc.name, func(t *testing.T) {
+					ids := listIDs(t, tc.res())
+					for _, id := range tc.included {
+						if !ids[id] {
+							t.Errorf("id %d should be included", id)
+						}
+					}
+					for _, id := range tc.excluded {
+						if ids[id] {
+							t.Errorf("id %d should not be included", id)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+// ?since= は指定した日時より後に作成・更新されたもののみ返す
+func TestListSince(t *testing.T) {
+	requireEnv(t)
+	center := types.Coordinate{Lat: 33.90, Lng: 134.60}
+	author := createUser(t)
+
+	old := createThread(t, author, at(center))
+	hourAgo := time.Now().Add(-time.Hour)
+	if err := db.Ctx(context.Background()).Model(&old).
+		UpdateColumns(map[string]any{"created_at": hourAgo, "updated_at": hourAgo}).Error; err != nil {
+		t.Fatal(err)
+	}
+	recent := createThread(t, author, at(center))
+
+	since := time.Now().Add(-30 * time.Minute).Unix()
+	ids := listIDs(t, request(t, http.MethodGet,
+		fmt.Sprintf("/api/v2/threads?lat=%f&lng=%f&since=%d", center.Lat, center.Lng, since), nil, ""))
+	if !ids[recent.ID] {
+		t.Errorf("recent thread %d should be included", recent.ID)
+	}
+	if ids[old.ID] {
+		t.Errorf("old thread %d should not be included", old.ID)
+	}
+
+	// 古いスレッドを編集すると含まれる
+	request(t, http.MethodPatch, fmt.Sprintf("/api/v2/threads/%d", old.ID),
+		obj{"content": unique(t, "edited")}, tokenFor(t, author)).expect(t, http.StatusOK)
+	ids = listIDs(t, request(t, http.MethodGet,
+		fmt.Sprintf("/api/v2/threads?lat=%f&lng=%f&since=%d", center.Lat, center.Lng, since), nil, ""))
+	if !ids[old.ID] {
+		t.Errorf("edited thread %d should be included", old.ID)
+	}
+}
+
+// 範囲は公開用のセルで判定し、セル内のどこを中心に狭い範囲で検索しても元の座標を絞り込めないこと
+func TestListMatchesPublicCell(t *testing.T) {
+	requireEnv(t)
+	// 近隣（0.005度四方）のセル [33.300, 33.305] x [133.300, 133.305] の中央（四国の山間部）
+	point := types.Coordinate{Lat: 33.3025, Lng: 133.3025}
+	author := createUser(t)
+	fuzzed := createPost(t, author, at(point), withPrecision(geo.PrecisionNeighborhood))
+	exact := createPost(t, author, at(point))
+
+	cases := []struct {
+		name     string
+		at       types.Coordinate
+		included []uint
+		excluded []uint
+	}{
+		{"original point", point, []uint{fuzzed.ID, exact.ID}, nil},
+		{"elsewhere in the cell", types.Coordinate{Lat: 33.3045, Lng: 133.3005}, []uint{fuzzed.ID}, []uint{exact.ID}},
+		{"next cell", types.Coordinate{Lat: 33.3065, Lng: 133.3025}, nil, []uint{fuzzed.ID, exact.ID}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ids := listIDs(t, request(t, http.MethodGet, fmt.Sprintf("/api/v2/posts?lat=%f&lng=%f&radius=1", tc.at.Lat, tc.at.Lng), nil, ""))
+			for _, id := range tc.included {
+				if !ids[id] {
+					t.Errorf("id %d should be included", id)
+				}
+			}
+			for _, id := range tc.excluded {
+				if ids[id] {
+					t.Errorf("id %d should not be included", id)
+				}
+			}
+		})
+	}
+}
+
+// 経度の幅は緯度に応じて広げること（その他: 半径 1100m）
+func TestListLongitudeSpan(t *testing.T) {
+	requireEnv(t)
+	// 北緯45.5度（オホーツク海）では経度1度が約78km
+	center := types.Coordinate{Lat: 45.50, Lng: 146.00}
+	author := createUser(t)
+	east := func(meters float64) types.Coordinate {
+		return types.Coordinate{Lat: center.Lat, Lng: center.Lng + meters/(111_320*math.Cos(center.Lat*math.Pi/180))}
+	}
+	inside := createPost(t, author, at(east(1000)))
+	outside := createPost(t, author, at(east(1300)))
+
+	ids := listIDs(t, request(t, http.MethodGet, fmt.Sprintf("/api/v2/posts?lat=%f&lng=%f", center.Lat, center.Lng), nil, ""))
+	if !ids[inside.ID] {
+		t.Errorf("post 1000m east should be included")
+	}
+	if ids[outside.ID] {
+		t.Errorf("post 1300m east should not be included")
+	}
+}
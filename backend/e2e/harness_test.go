@@ -0,0 +1,133 @@
+package e2e
+
+import (
+	"api/apierror"
+	"api/routes"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// response は1回のリクエストの結果
+type response struct {
+	Code   int
+	Header http.Header
+	Body   []byte
+	// 一致したルートのパス（c.FullPath()。NoRoute の場合は空）
+	Route string
+}
+
+// upload は multipart/form-data で送るファイル
+type upload struct {
+	Field    string
+	Filename string
+	Data     []byte
+}
+
+type routeKey struct{}
+
+// recordRoute は一致したルートのパスを request の context に記録する（応答をドキュメントと照合するため）
+func recordRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if route, ok := c.Request.Context().Value(routeKey{}).(*string); ok {
+			*route = c.FullPath()
+		}
+		c.Next()
+	}
+}
+
+// request はエンジンに直接リクエストを送る
+// body が upload の場合は multipart、それ以外で nil でなければ JSON にする。token は空なら付けない
+// 応答は routes.Spec() の定義と照合し、合わない場合は試験を失敗にする
+func request(t *testing.T, method, path string, body any, token string) response {
+	t.Helper()
+
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case upload:
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		fw, err := mw.CreateFormFile(b.Field, b.Filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write(b.Data); err != nil {
+			t.Fatal(err)
+		}
+		if err := mw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		reader, contentType = &buf, mw.FormDataContentType()
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader, contentType = bytes.NewReader(raw), "application/json"
+	}
+
+	var route string
+	req := httptest.NewRequest(method, path, reader)
+	req = req.WithContext(context.WithValue(req.Context(), routeKey{}, &route))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	res := response{Code: w.Code, Header: w.Header(), Body: w.Body.Bytes(), Route: route}
+	doc := routes.Spec()
+	if route != "" && doc.Operation(method, route) != nil {
+		if err := doc.ValidateResponse(method, route, res.Code, res.Header.Get("Content-Type"), res.Body); err != nil {
+			t.Errorf("%s %s: %v\nbody: %s", method, path, err, res.Body)
+		}
+	}
+	return res
+}
+
+// decode は {"data": ...} の data を v に読み込む
+func (r response) decode(t *testing.T, v any) {
+	t.Helper()
+	envelope := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(r.Body, &envelope); err != nil {
+		t.Fatalf("invalid json: %v\nbody: %s", err, r.Body)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		t.Fatalf("failed to decode data: %v\nbody: %s", err, r.Body)
+	}
+}
+
+// errorCode は {"error": {...}} の code を返す
+func (r response) errorCode(t *testing.T) apierror.Code {
+	t.Helper()
+	var envelope struct {
+		Error apierror.Body `json:"error"`
+	}
+	if err := json.Unmarshal(r.Body, &envelope); err != nil {
+		t.Fatalf("invalid json: %v\nbody: %s", err, r.Body)
+	}
+	return envelope.Error.Code
+}
+
+// expect はステータスを確認する
+func (r response) expect(t *testing.T, status int) response {
+	t.Helper()
+	if r.Code != status {
+		t.Fatalf("status = %d, want %d\nbody: %s", r.Code, status, r.Body)
+	}
+	return r
+}
@@ -0,0 +1,35 @@
+package e2e
+
+import (
+	"api/db"
+	"api/types"
+	"context"
+	"testing"
+)
+
+// 移行済みのスキーマで再度マイグレーションしても、解除したログイン方法を users.password から復活させないこと
+func TestMigrateKeepsUnlinkedIdentity(t *testing.T) {
+	requireEnv(t)
+	user := createUser(t)
+	linkGoogle(t, user)
+	ctx := context.Background()
+	// 移行前の形式のパスワードが残っている状態で、メールアドレスのログイン方法を解除する
+	if err := db.Ctx(ctx).Model(&types.User{}).Where("id = ?", user.ID).Update("password", "$2a$10$legacy").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Ctx(ctx).Where("user_id = ? AND provider = ?", user.ID, types.ProviderEmail).Delete(&types.Identity{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	if err := db.Ctx(ctx).Model(&types.Identity{}).Where("user_id = ? AND provider = ?", user.ID, types.ProviderEmail).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("email identity was restored by the migration")
+	}
+}
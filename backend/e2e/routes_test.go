@@ -0,0 +1,319 @@
+package e2e
+
+import (
+	"api/storage"
+	"api/types"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// routeCase は1回のリクエストとその期待するステータス
+type routeCase struct {
+	method string
+	// 登録したルートのパス（全てのルートに試験があるかの確認に使う）
+	route string
+	path  string
+	body  any
+	token string
+	want  int
+}
+
+type obj = map[string]any
+
+// TestRoutes は登録した全てのルートに対してリクエストを送り、ステータスとドキュメントとの一致を確認する
+// ケースは上から順に実行する（削除等の後のケースは前のケースの結果に依存しない）
+func TestRoutes(t *testing.T) {
+	requireEnv(t)
+
+	var cases []routeCase
+	for _, prefix := range []string{"/api/v1", "/api/v2"} {
+		cases = append(cases, commonCases(t, prefix)...)
+	}
+	cases = append(cases, v1Cases(t)...)
+	cases = append(cases, v2Cases(t)...)
+	cases = append(cases, systemCases(t)...)
+
+	covered := map[string]bool{}
+	for _, tc := range cases {
+		covered[tc.method+" "+tc.route] = true
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			res := request(t, tc.method, tc.path, tc.body, tc.token)
+			if res.Code != tc.want {
+				t.Errorf("status = %d, want %d\nbody: %s", res.Code, tc.want, res.Body)
+			}
+			if res.Route != tc.route {
+				t.Errorf("matched route %q, want %q", res.Route, tc.route)
+			}
+		})
+	}
+
+	var missing []string
+	for _, r := range engine.Routes() {
+		if r.Method == http.MethodOptions {
+			continue
+		}
+		if !covered[r.Method+" "+r.Path] {
+			missing = append(missing, r.Method+" "+r.Path)
+		}
+	}
+	sort.Strings(missing)
+	for _, m := range missing {
+		t.Errorf("%s has no case in TestRoutes", m)
+	}
+}
+
+// commonCases は v1 と v2 で共通のルート（認証・アカウント・カテゴリ・モデレーション）
+func commonCases(t *testing.T, p string) []routeCase {
+	user := createUser(t)
+	tok := tokenFor(t, user)
+	unverifiedUser := createUser(t, unverified())
+	mod := createUser(t, withRole(types.RoleModerator))
+	modTok := tokenFor(t, mod)
+
+	resetUser := createUser(t)
+	passwordUser := createUser(t)
+	leavingUser := createUser(t)
+	exportUser := createUser(t)
+	linkUser := createUser(t)
+	googleUser := createUser(t)
+	linkGoogle(t, googleUser)
+
+	reported := createPost(t, user)
+	report := createReport(t, mod, "post", reported.ID)
+	target := createPost(t, user)
+
+	return []routeCase{
+		{"POST", p + "/auth/login", p + "/auth/login", obj{"email": user.Email, "password": testPassword}, "", http.StatusOK},
+		{"POST", p + "/auth/login", p + "/auth/login", obj{"email": user.Email, "password": "wrong-password"}, "", http.StatusUnauthorized},
+		{"POST", p + "/auth/register", p + "/auth/register", obj{"name": "new user", "email": uuid.NewString() + "@example.com", "password": testPassword}, "", http.StatusCreated},
+		{"POST", p + "/auth/register", p + "/auth/register", obj{"name": "dup", "email": user.Email, "password": testPassword}, "", http.StatusConflict},
+		{"POST", p + "/auth/google", p + "/auth/google", obj{"access_token": googleToken(t, uuid.NewString(), uuid.NewString()+"@gmail.com")}, "", http.StatusOK},
+		{"POST", p + "/auth/google", p + "/auth/google", obj{"access_token": "invalid"}, "", http.StatusUnauthorized},
+		{"POST", p + "/auth/verify-email", p + "/auth/verify-email", obj{"token": issueUserToken(t, unverifiedUser, types.TokenVerifyEmail)}, "", http.StatusOK},
+		{"POST", p + "/auth/verify-email", p + "/auth/verify-email", obj{"token": "unknown"}, "", http.StatusBadRequest},
+		{"POST", p + "/auth/password/forgot", p + "/auth/password/forgot", obj{"email": user.Email}, "", http.StatusAccepted},
+		{"POST", p + "/auth/password/reset", p + "/auth/password/reset", obj{"token": issueUserToken(t, resetUser, types.TokenResetPassword), "password": "new-password-123"}, "", http.StatusOK},
+		{"GET", p + "/social-sensing/heatmap", p + "/social-sensing/heatmap", nil, "", http.StatusOK},
+		{"GET", p + "/categories", p + "/categories", nil, "", http.StatusOK},
+
+		{"GET", p + "/auth/me", p + "/auth/me", nil, tok, http.StatusOK},
+		{"GET", p + "/auth/me", p + "/auth/me", nil, "", http.StatusUnauthorized},
+		{"POST", p + "/auth/logout", p + "/auth/logout", nil, tok, http.StatusOK},
+		{"POST", p + "/auth/verify-email/resend", p + "/auth/verify-email/resend", nil, tokenFor(t, createUser(t, unverified())), http.StatusAccepted},
+		{"POST", p + "/auth/verify-email/resend", p + "/auth/verify-email/resend", nil, tok, http.StatusConflict},
+		{"PUT", p + "/me", p + "/me", obj{"bio": "hello", "home_area": "東京都"}, tok, http.StatusOK},
+		{"POST", p + "/me/avatar", p + "/me/avatar", upload{"avatar", "avatar.png", pngImage(t)}, tok, http.StatusOK},
+		{"PUT", p + "/me/password", p + "/me/password", obj{"current_password": testPassword, "new_password": "new-password-123"}, tokenFor(t, passwordUser), http.StatusOK},
+		{"PUT", p + "/me/password", p + "/me/password", obj{"current_password": "wrong-password", "new_password": "new-password-123"}, tok, http.StatusUnauthorized},
+		{"DELETE", p + "/me", p + "/me", obj{"password": testPassword}, tokenFor(t, leavingUser), http.StatusOK},
+		{"GET", p + "/me/export", p + "/me/export", nil, tokenFor(t, exportUser), http.StatusAccepted},
+		{"GET", p + "/me/export/download", p + "/me/export/download", nil, tok, http.StatusNotFound},
+		{"GET", p + "/me/identities", p + "/me/identities", nil, tok, http.StatusOK},
+		{"POST", p + "/me/identities/google", p + "/me/identities/google", obj{"access_token": googleToken(t, uuid.NewString(), linkUser.Email)}, tokenFor(t, linkUser), http.StatusCreated},
+		{"DELETE", p + "/me/identities/:provider", p + "/me/identities/google", nil, tokenFor(t, googleUser), http.StatusOK},
+		{"DELETE", p + "/me/identities/:provider", p + "/me/identities/email", nil, tok, http.StatusConflict},
+
+		{"GET", p + "/moderation/reports", p + "/moderation/reports", nil, modTok, http.StatusOK},
+		{"GET", p + "/moderation/reports", p + "/moderation/reports", nil, tok, http.StatusForbidden},
+		{"POST", p + "/moderation/reports/:id/dismiss", fmt.Sprintf("%s/moderation/reports/%d/dismiss", p, report.ID), nil, modTok, http.StatusOK},
+		{"POST", p + "/moderation/content/:type/:id/hide", fmt.Sprintf("%s/moderation/content/post/%d/hide", p, target.ID), nil, modTok, http.StatusOK},
+		{"POST", p + "/moderation/content/:type/:id/restore", fmt.Sprintf("%s/moderation/content/post/%d/restore", p, target.ID), nil, modTok, http.StatusOK},
+		{"POST", p + "/moderation/content/:type/:id/hide", p + "/moderation/content/user/1/hide", nil, modTok, http.StatusBadRequest},
+		{"GET", p + "/moderation/filter-results", p + "/moderation/filter-results", nil, modTok, http.StatusOK},
+	}
+}
+
+// v1Cases は /api/v1 のみのルート
+func v1Cases(t *testing.T) []routeCase {
+	const p = "/api/v1"
+	user := createUser(t)
+	tok := tokenFor(t, user)
+	other := createUser(t)
+	otherTok := tokenFor(t, other)
+
+	post := createPost(t, user)
+	thread := createThread(t, user)
+	event := createEvent(t, user)
+	comment := createComment(t, other, thread)
+	since := time.Now().Add(-time.Minute).Unix()
+
+	return []routeCase{
+		{"GET", p + "/thread/:id/details", fmt.Sprintf("%s/thread/%d/details", p, thread.ID), nil, "", http.StatusOK},
+		{"GET", p + "/thread/:id/details", p + "/thread/abc/details", nil, "", http.StatusBadRequest},
+		// ThreadTable は作成されないため常に 404
+		{"GET", p + "/comments/:thread_id", fmt.Sprintf("%s/comments/%d", p, thread.ID), nil, "", http.StatusNotFound},
+
+		{"GET", p + "/user/:id", p + "/user/" + user.ID.String(), nil, "", http.StatusOK},
+		{"GET", p + "/user/:id", p + "/user/" + uuid.NewString(), nil, "", http.StatusNotFound},
+		{"GET", p + "/user/:id/posts", p + "/user/" + user.ID.String() + "/posts", nil, "", http.StatusOK},
+		{"GET", p + "/user/:id/threads", p + "/user/" + user.ID.String() + "/threads", nil, "", http.StatusOK},
+		{"GET", p + "/user/:id/events", p + "/user/" + user.ID.String() + "/events", nil, "", http.StatusOK},
+		{"GET", p + "/user/:id/comments", p + "/user/" + other.ID.String() + "/comments", nil, "", http.StatusOK},
+		{"POST", p + "/getall/post", p + "/getall/post", tokyo, "", http.StatusOK},
+		{"POST", p + "/getall/event", p + "/getall/event", tokyo, "", http.StatusOK},
+		{"POST", p + "/getall/thread", p + "/getall/thread", tokyo, "", http.StatusOK},
+
+		{"POST", p + "/upload", p + "/upload", upload{"file", "photo.png", pngImage(t)}, tok, http.StatusCreated},
+		{"POST", p + "/upload", p + "/upload", upload{"file", "photo.png", pngImage(t)}, "", http.StatusUnauthorized},
+
+		{"POST", p + "/create/post", p + "/create/post", obj{"content": unique(t, "post"), "coordinate": tokyo}, tok, http.StatusCreated},
+		{"POST", p + "/create/post", p + "/create/post", obj{"coordinate": tokyo}, tok, http.StatusBadRequest},
+		{"GET", p + "/update/post/:from", fmt.Sprintf("%s/update/post/%d", p, since), nil, tok, http.StatusOK},
+		{"PUT", p + "/edit/post/:id", fmt.Sprintf("%s/edit/post/%d", p, post.ID), obj{"content": unique(t, "edited")}, tok, http.StatusOK},
+		{"PATCH", p + "/edit/post/:id", fmt.Sprintf("%s/edit/post/%d", p, post.ID), obj{"tags": []string{"e2e"}}, tok, http.StatusOK},
+		{"PATCH", p + "/edit/post/:id", fmt.Sprintf("%s/edit/post/%d", p, post.ID), obj{"content": unique(t, "edited")}, otherTok, http.StatusForbidden},
+		{"DELETE", p + "/delete/post/:id", fmt.Sprintf("%s/delete/post/%d", p, post.ID), nil, otherTok, http.StatusForbidden},
+		{"DELETE", p + "/delete/post/:id", fmt.Sprintf("%s/delete/post/%d", p, post.ID), nil, tok, http.StatusOK},
+
+		{"POST", p + "/create/thread", p + "/create/thread", obj{"content": unique(t, "thread"), "coordinate": tokyo}, tok, http.StatusCreated},
+		{"GET", p + "/update/thread/:from", fmt.Sprintf("%s/update/thread/%d", p, since), nil, tok, http.StatusOK},
+		{"PUT", p + "/edit/thread/:id", fmt.Sprintf("%s/edit/thread/%d", p, thread.ID), obj{"content": unique(t, "edited")}, tok, http.StatusOK},
+		{"PATCH", p + "/edit/thread/:id", fmt.Sprintf("%s/edit/thread/%d", p, thread.ID), obj{"tags": []string{"e2e"}}, tok, http.StatusOK},
+
+		{"POST", p + "/create/event", p + "/create/event", obj{"content": unique(t, "event"), "coordinate": tokyo, "event_date": time.Now().Add(48 * time.Hour)}, tok, http.StatusCreated},
+		{"POST", p + "/create/event", p + "/create/event", obj{"content": unique(t, "event"), "coordinate": tokyo, "event_date": time.Now().Add(-time.Hour)}, tok, http.StatusBadRequest},
+		{"GET", p + "/update/event/:from", fmt.Sprintf("%s/update/event/%d", p, since), nil, tok, http.StatusOK},
+		{"PUT", p + "/edit/event/:id", fmt.Sprintf("%s/edit/event/%d", p, event.ID), obj{"content": unique(t, "edited")}, tok, http.StatusOK},
+		{"PATCH", p + "/edit/event/:id", fmt.Sprintf("%s/edit/event/%d", p, event.ID), obj{"tags": []string{"e2e"}}, tok, http.StatusOK},
+		{"DELETE", p + "/delete/event/:id", fmt.Sprintf("%s/delete/event/%d", p, event.ID), nil, otherTok, http.StatusForbidden},
+		{"DELETE", p + "/delete/event/:id", fmt.Sprintf("%s/delete/event/%d", p, event.ID), nil, tok, http.StatusOK},
+
+		{"POST", p + "/create/comment", p + "/create/comment", obj{"thread_id": thread.ID, "content": unique(t, "comment")}, otherTok, http.StatusCreated},
+		{"POST", p + "/thread/:id/reply", fmt.Sprintf("%s/thread/%d/reply", p, thread.ID), obj{"content": unique(t, "reply")}, otherTok, http.StatusCreated},
+		{"DELETE", p + "/delete/comment/:id", fmt.Sprintf("%s/delete/comment/%d", p, comment.ID), nil, tok, http.StatusForbidden},
+		{"DELETE", p + "/delete/comment/:id", fmt.Sprintf("%s/delete/comment/%d", p, comment.ID), nil, otherTok, http.StatusOK},
+		{"POST", p + "/report", p + "/report", obj{"target_type": "thread", "target_id": thread.ID, "reason": "spam"}, otherTok, http.StatusCreated},
+		{"POST", p + "/report", p + "/report", obj{"target_type": "thread", "target_id": thread.ID, "reason": "spam"}, otherTok, http.StatusConflict},
+		{"DELETE", p + "/delete/thread/:id", fmt.Sprintf("%s/delete/thread/%d", p, thread.ID), nil, otherTok, http.StatusForbidden},
+		{"DELETE", p + "/delete/thread/:id", fmt.Sprintf("%s/delete/thread/%d", p, thread.ID), nil, tok, http.StatusOK},
+	}
+}
+
+// v2Cases は /api/v2 のみのルート
+func v2Cases(t *testing.T) []routeCase {
+	const p = "/api/v2"
+	user := createUser(t)
+	tok := tokenFor(t, user)
+	other := createUser(t)
+	otherTok := tokenFor(t, other)
+
+	post := createPost(t, user)
+	thread := createThread(t, user)
+	event := createEvent(t, user)
+	comment := createComment(t, other, thread)
+	hiddenPost := createPost(t, user, hidden())
+	admin := createUser(t, withRole(types.RoleAdmin))
+	adminTok := tokenFor(t, admin)
+	target := createUser(t)
+	deletedPost := createPost(t, user)
+	mustDelete(t, &deletedPost)
+	until := time.Now().Add(24 * time.Hour)
+	importCSV := []byte("type,content,lat,lng,event_date\nevent," + unique(t, "imported") + ",35.68,139.76,2030-01-01 10:00\n")
+
+	return []routeCase{
+		{"GET", p + "/posts", p + "/posts", nil, "", http.StatusOK},
+		{"GET", p + "/posts", p + "/posts?lat=35.68&lng=139.76&radius=1000", nil, "", http.StatusOK},
+		{"GET", p + "/posts", p + "/posts?lat=35.68", nil, "", http.StatusBadRequest},
+		{"GET", p + "/posts/:id", fmt.Sprintf("%s/posts/%d", p, post.ID), nil, "", http.StatusOK},
+		{"GET", p + "/posts/:id", fmt.Sprintf("%s/posts/%d", p, hiddenPost.ID), nil, "", http.StatusNotFound},
+		{"GET", p + "/threads", p + "/threads?since=1", nil, "", http.StatusOK},
+		{"GET", p + "/threads/:id", fmt.Sprintf("%s/threads/%d", p, thread.ID), nil, "", http.StatusOK},
+		{"GET", p + "/threads/:id/comments", fmt.Sprintf("%s/threads/%d/comments", p, thread.ID), nil, "", http.StatusOK},
+		{"GET", p + "/threads/:id/comments", p + "/threads/abc/comments", nil, "", http.StatusBadRequest},
+		{"GET", p + "/events", p + "/events", nil, "", http.StatusOK},
+		{"GET", p + "/events/:id", fmt.Sprintf("%s/events/%d", p, event.ID), nil, "", http.StatusOK},
+
+		{"GET", p + "/users/:id", p + "/users/" + user.ID.String(), nil, "", http.StatusOK},
+		{"GET", p + "/users/:id/posts", p + "/users/" + user.ID.String() + "/posts?page=1&limit=10", nil, "", http.StatusOK},
+		{"GET", p + "/users/:id/threads", p + "/users/" + user.ID.String() + "/threads", nil, "", http.StatusOK},
+		{"GET", p + "/users/:id/events", p + "/users/" + user.ID.String() + "/events", nil, "", http.StatusOK},
+		{"GET", p + "/users/:id/comments", p + "/users/" + other.ID.String() + "/comments", nil, "", http.StatusOK},
+		{"GET", p + "/users/:id/comments", p + "/users/not-a-uuid/comments", nil, "", http.StatusBadRequest},
+
+		{"POST", p + "/attachments", p + "/attachments", upload{"file", "photo.png", pngImage(t)}, tok, http.StatusCreated},
+		{"POST", p + "/attachments", p + "/attachments", upload{"file", "notes.txt", []byte("plain text")}, tok, http.StatusUnsupportedMediaType},
+
+		{"POST", p + "/posts", p + "/posts", obj{"content": unique(t, "post"), "coordinate": tokyo, "category": types.CategoryCommunity}, tok, http.StatusCreated},
+		{"POST", p + "/posts", p + "/posts", obj{"content": unique(t, "post"), "coordinate": tokyo}, "", http.StatusUnauthorized},
+		{"PATCH", p + "/posts/:id", fmt.Sprintf("%s/posts/%d", p, post.ID), obj{"content": unique(t, "edited")}, tok, http.StatusOK},
+		{"PATCH", p + "/posts/:id", fmt.Sprintf("%s/posts/%d", p, post.ID), obj{"content": unique(t, "edited")}, otherTok, http.StatusForbidden},
+		{"DELETE", p + "/posts/:id", fmt.Sprintf("%s/posts/%d", p, post.ID), nil, otherTok, http.StatusForbidden},
+		{"DELETE", p + "/posts/:id", fmt.Sprintf("%s/posts/%d", p, post.ID), nil, tok, http.StatusOK},
+		{"DELETE", p + "/posts/:id", fmt.Sprintf("%s/posts/%d", p, post.ID), nil, tok, http.StatusNotFound},
+
+		{"POST", p + "/threads", p + "/threads", obj{"content": unique(t, "thread"), "coordinate": tokyo}, tok, http.StatusCreated},
+		{"PATCH", p + "/threads/:id", fmt.Sprintf("%s/threads/%d", p, thread.ID), obj{"content": unique(t, "edited")}, tok, http.StatusOK},
+		{"POST", p + "/threads/:id/comments", fmt.Sprintf("%s/threads/%d/comments", p, thread.ID), obj{"content": unique(t, "reply")}, otherTok, http.StatusCreated},
+		{"DELETE", p + "/comments/:id", fmt.Sprintf("%s/comments/%d", p, comment.ID), nil, tok, http.StatusForbidden},
+		{"DELETE", p + "/comments/:id", fmt.Sprintf("%s/comments/%d", p, comment.ID), nil, otherTok, http.StatusOK},
+		{"POST", p + "/reports", p + "/reports", obj{"target_type": "event", "target_id": event.ID, "reason": "spam"}, otherTok, http.StatusCreated},
+		{"POST", p + "/reports", p + "/reports", obj{"target_type": "event", "target_id": 0, "reason": "spam"}, otherTok, http.StatusBadRequest},
+		{"DELETE", p + "/threads/:id", fmt.Sprintf("%s/threads/%d", p, thread.ID), nil, otherTok, http.StatusForbidden},
+		{"DELETE", p + "/threads/:id", fmt.Sprintf("%s/threads/%d", p, thread.ID), nil, tok, http.StatusOK},
+
+		{"POST", p + "/events", p + "/events", obj{"content": unique(t, "event"), "coordinate": tokyo, "event_date": time.Now().Add(48 * time.Hour)}, tok, http.StatusCreated},
+		{"PATCH", p + "/events/:id", fmt.Sprintf("%s/events/%d", p, event.ID), obj{"content": unique(t, "edited")}, tok, http.StatusOK},
+		{"DELETE", p + "/events/:id", fmt.Sprintf("%s/events/%d", p, event.ID), nil, otherTok, http.StatusForbidden},
+		{"DELETE", p + "/events/:id", fmt.Sprintf("%s/events/%d", p, event.ID), nil, tok, http.StatusOK},
+
+		{"GET", p + "/admin/stats", p + "/admin/stats", nil, adminTok, http.StatusOK},
+		{"GET", p + "/admin/stats", p + "/admin/stats", nil, tok, http.StatusForbidden},
+		{"GET", p + "/admin/users", p + "/admin/users?q=" + target.Email, nil, adminTok, http.StatusOK},
+		{"GET", p + "/admin/users", p + "/admin/users?status=unknown", nil, adminTok, http.StatusBadRequest},
+		{"POST", p + "/admin/users/:id/suspend", p + "/admin/users/" + target.ID.String() + "/suspend", obj{"reason": "spam", "until": until}, adminTok, http.StatusOK},
+		{"POST", p + "/admin/users/:id/suspend", p + "/admin/users/" + target.ID.String() + "/suspend", obj{"reason": "spam", "until": time.Now().Add(-time.Hour)}, adminTok, http.StatusBadRequest},
+		{"POST", p + "/admin/users/:id/reinstate", p + "/admin/users/" + target.ID.String() + "/reinstate", nil, adminTok, http.StatusOK},
+		{"POST", p + "/admin/users/:id/reinstate", p + "/admin/users/" + target.ID.String() + "/reinstate", nil, adminTok, http.StatusConflict},
+		{"POST", p + "/admin/users/:id/ban", p + "/admin/users/" + target.ID.String() + "/ban", obj{"reason": "spam"}, adminTok, http.StatusOK},
+		{"POST", p + "/admin/users/:id/ban", p + "/admin/users/" + uuid.NewString() + "/ban", obj{"reason": "spam"}, adminTok, http.StatusNotFound},
+		{"PUT", p + "/admin/users/:id/role", p + "/admin/users/" + target.ID.String() + "/role", obj{"role": types.RoleModerator}, adminTok, http.StatusOK},
+		{"PUT", p + "/admin/users/:id/role", p + "/admin/users/" + admin.ID.String() + "/role", obj{"role": types.RoleUser}, adminTok, http.StatusBadRequest},
+		{"GET", p + "/admin/deleted/:type", p + "/admin/deleted/post", nil, adminTok, http.StatusOK},
+		{"GET", p + "/admin/deleted/:type", p + "/admin/deleted/user", nil, adminTok, http.StatusBadRequest},
+		{"POST", p + "/admin/deleted/:type/:id/restore", fmt.Sprintf("%s/admin/deleted/post/%d/restore", p, deletedPost.ID), nil, adminTok, http.StatusOK},
+		{"POST", p + "/admin/deleted/:type/:id/restore", fmt.Sprintf("%s/admin/deleted/post/%d/restore", p, deletedPost.ID), nil, adminTok, http.StatusNotFound},
+		{"GET", p + "/admin/export", p + "/admin/export?format=csv&type=event", nil, adminTok, http.StatusOK},
+		{"GET", p + "/admin/export", p + "/admin/export?bbox=1,2,3", nil, adminTok, http.StatusBadRequest},
+		{"GET", p + "/admin/export", p + "/admin/export", nil, tok, http.StatusForbidden},
+		{"POST", p + "/admin/import", p + "/admin/import?dry_run=true", upload{"file", "events.csv", importCSV}, adminTok, http.StatusOK},
+		{"POST", p + "/admin/import", p + "/admin/import", upload{"file", "events.txt", importCSV}, adminTok, http.StatusBadRequest},
+		{"POST", p + "/admin/import", p + "/admin/import", upload{"file", "events.csv", importCSV}, tok, http.StatusForbidden},
+		{"GET", p + "/admin/audit-logs", p + "/admin/audit-logs?action=user.&target_id=" + target.ID.String(), nil, adminTok, http.StatusOK},
+		{"GET", p + "/admin/audit-logs", p + "/admin/audit-logs?actor_id=invalid", nil, adminTok, http.StatusBadRequest},
+		{"GET", p + "/admin/audit-logs", p + "/admin/audit-logs", nil, tok, http.StatusForbidden},
+	}
+}
+
+// systemCases はヘルスチェック・ドキュメント・メトリクス・アップロードファイルの配信
+func systemCases(t *testing.T) []routeCase {
+	ctx := context.Background()
+	data := pngImage(t)
+	public := "e2e/" + uuid.NewString() + ".png"
+	private := storage.PrivatePrefix + uuid.NewString() + ".zip"
+	for _, key := range []string{public, private} {
+		if err := storage.Get().Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const media = storage.LocalMountPath + "/*filepath"
+
+	return []routeCase{
+		{"GET", "/livez", "/livez", nil, "", http.StatusOK},
+		{"GET", "/readyz", "/readyz", nil, "", http.StatusOK},
+		{"GET", "/health", "/health", nil, "", http.StatusOK},
+		{"GET", "/metrics", "/metrics", nil, "", http.StatusOK},
+		{"GET", "/openapi.json", "/openapi.json", nil, "", http.StatusOK},
+		{"GET", "/docs", "/docs", nil, "", http.StatusOK},
+		{"GET", media, storage.LocalMountPath + "/" + public, nil, "", http.StatusOK},
+		{"HEAD", media, storage.LocalMountPath + "/" + public, nil, "", http.StatusOK},
+		// エクスポート等の非公開ファイルは配信しない
+		{"GET", media, storage.LocalMountPath + "/" + private, nil, "", http.StatusNotFound},
+	}
+}
@@ -0,0 +1,58 @@
+package e2e
+
+import (
+	"api/category"
+	"api/db"
+	"api/seed"
+	"api/types"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// 投入したユーザーでログインでき、Reset で投入したデータのみ削除されること
+func TestSeedInsertAndReset(t *testing.T) {
+	requireEnv(t)
+	ctx := context.Background()
+	other := createUser(t)
+
+	ds, err := seed.Generate(seed.Options{
+		Seed:       42,
+		Profile:    seed.Profile{Users: 3, Posts: 10, Threads: 4, Events: 3, Comments: 8, Likes: 15},
+		Base:       time.Now().UTC().Truncate(24 * time.Hour),
+		Categories: category.Get().All(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inserted, err := seed.Insert(ctx, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seed.Insert(ctx, ds); err == nil {
+		t.Error("inserting the same seed twice should fail")
+	}
+
+	request(t, http.MethodPost, "/api/v2/auth/login",
+		obj{"email": seed.Email(42, 0), "password": seed.Password}, "").expect(t, http.StatusOK)
+
+	// 他のユーザーのコメントも投入したスレッドと一緒に削除される
+	comment := createComment(t, other, ds.Threads[0])
+	deleted, err := seed.Reset(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inserted.Comments++
+	if deleted != inserted {
+		t.Errorf("reset deleted %s, want %s", deleted, inserted)
+	}
+	var remaining int64
+	if err := db.Ctx(ctx).Unscoped().Model(&types.Comment{}).Where("id = ?", comment.ID).Count(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 0 {
+		t.Error("comment on a seeded thread should be deleted")
+	}
+	request(t, http.MethodGet, "/api/v2/users/"+other.ID.String(), nil, "").expect(t, http.StatusOK)
+}
@@ -0,0 +1,41 @@
+package e2e
+
+import (
+	"api/apierror"
+	"api/types"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// パスワードの変更後は変更前に発行したトークンを拒否し、変更時に返したトークンは使えること
+func TestPasswordChangeRevokesSessions(t *testing.T) {
+	requireEnv(t)
+	user := createUser(t)
+	old := tokenIssuedAt(t, user, time.Now().Add(-time.Minute))
+	request(t, http.MethodGet, "/api/v2/auth/me", nil, old).expect(t, http.StatusOK)
+
+	var res struct {
+		Token string `json:"token"`
+	}
+	request(t, http.MethodPut, "/api/v2/me/password", obj{"current_password": testPassword, "new_password": "new-password-123"}, old).
+		expect(t, http.StatusOK).decode(t, &res)
+
+	if code := request(t, http.MethodGet, "/api/v2/auth/me", nil, old).expect(t, http.StatusUnauthorized).errorCode(t); code != apierror.CodeInvalidToken {
+		t.Errorf("code = %s, want %s", code, apierror.CodeInvalidToken)
+	}
+	request(t, http.MethodGet, "/api/v2/auth/me", nil, res.Token).expect(t, http.StatusOK)
+}
+
+// パスワードの再設定後は再設定前に発行したトークンを拒否すること
+func TestPasswordResetRevokesSessions(t *testing.T) {
+	requireEnv(t)
+	user := createUser(t)
+	old := tokenIssuedAt(t, user, time.Now().Add(-time.Minute))
+
+	request(t, http.MethodPost, "/api/v2/auth/password/reset", obj{"token": issueUserToken(t, user, types.TokenResetPassword), "password": "new-password-123"}, "").
+		expect(t, http.StatusOK)
+
+	request(t, http.MethodGet, "/api/v2/auth/me", nil, old).expect(t, http.StatusUnauthorized)
+	request(t, http.MethodGet, "/api/v2/auth/me", nil, tokenFor(t, user)).expect(t, http.StatusOK)
+}
@@ -0,0 +1,32 @@
+package e2e
+
+import (
+	"api/types"
+	"net/http"
+	"testing"
+)
+
+// 公開プロフィールの件数は種類毎に数え、非表示の投稿と他のユーザーの投稿は含めないこと
+func TestUserStats(t *testing.T) {
+	requireEnv(t)
+	user := createUser(t)
+	other := createUser(t)
+	for range 3 {
+		createPost(t, user)
+	}
+	createPost(t, user, hidden())
+	createThread(t, user)
+	createThread(t, user)
+	createEvent(t, user)
+	createPost(t, other)
+	createThread(t, other)
+
+	for _, prefix := range []string{"/api/v1/user/", "/api/v2/users/"} {
+		var profile types.UserProfile
+		request(t, http.MethodGet, prefix+user.ID.String(), nil, "").expect(t, http.StatusOK).decode(t, &profile)
+		want := types.UserStats{Posts: 3, Threads: 2, Events: 1}
+		if profile.Stats != want {
+			t.Errorf("%s: stats = %+v, want %+v", prefix, profile.Stats, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package geo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+)
+
+// 位置情報の公開精度
+const (
+	PrecisionExact        = "exact"        // そのまま公開
+	PrecisionNeighborhood = "neighborhood" // 約500m四方
+	PrecisionCity         = "city"         // 約5km四方
+)
+
+// DefaultPrecision は精度が指定されていない場合の既定値
+const DefaultPrecision = PrecisionNeighborhood
+
+// 精度ごとのグリッドの大きさ（度）
+var gridSize = map[string]float64{
+	PrecisionNeighborhood: 0.005,
+	PrecisionCity:         0.05,
+}
+
+// ValidPrecision は精度の値が正しいか判定する
+func ValidPrecision(precision string) bool {
+	return precision == PrecisionExact || gridSize[precision] > 0
+}
+
+// Fuzz は公開用に座標をぼかす。
+// 座標を精度に応じたグリッドのセルに丸めた上で、seed（投稿種別とIDなど）から決まる量だけセル内でずらす。
+// 同じ seed なら常に同じ結果になるため、再取得を繰り返して平均を取っても元の座標はセル以上には絞り込めない。
+func Fuzz(lat, lng float64, precision, seed string) (float64, float64) {
+	size, ok := gridSize[precision]
+	if !ok {
+		if precision == PrecisionExact {
+			return lat, lng
+		}
+		// 不明な値は既定の精度として扱う
+		size = gridSize[DefaultPrecision]
+	}
+
+	cellLat := math.Floor(lat / size)
+	cellLng := math.Floor(lng / size)
+	jLat, jLng := jitter(seed)
+
+	return round6((cellLat + jLat) * size), round6((cellLng + jLng) * size)
+}
+
+// CellSQL は公開精度の列 precisionColumn に応じて、座標の列 column を Fuzz と同じセルの南西端に丸める SQL 式と、
+// セルの大きさ（度）の SQL 式を返す（exact は元の座標と 0）
+// 範囲の検索はセルが範囲に掛かるかで判定する。元の座標で判定すると、狭い範囲の検索を繰り返して公開していない座標を絞り込める
+func CellSQL(column, precisionColumn string) (corner, size string) {
+	size = fmt.Sprintf("(CASE %s WHEN '%s' THEN 0", precisionColumn, PrecisionExact)
+	for _, p := range []string{PrecisionNeighborhood, PrecisionCity} {
+		size += fmt.Sprintf(" WHEN '%s' THEN %s", p, sqlFloat(gridSize[p]))
+	}
+	size += " ELSE " + sqlFloat(gridSize[DefaultPrecision]) + " END)"
+	corner = fmt.Sprintf("COALESCE(FLOOR(%[1]s / NULLIF(%[2]s, 0)) * %[2]s, %[1]s)", column, size)
+	return corner, size
+}
+
+func sqlFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// jitter は seed から [0.1, 0.9) の範囲の決定的なオフセットを2つ作る（セルの端に寄りすぎないようにする）
+func jitter(seed string) (float64, float64) {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	sum := mix(h.Sum64())
+	a := float64(sum>>32) / float64(1<<32)
+	b := float64(sum&0xFFFFFFFF) / float64(1<<32)
+	return 0.1 + a*0.8, 0.1 + b*0.8
+}
+
+// mix は FNV の値のビットを攪拌する（splitmix64 の最終段）
+// FNV のままでは末尾の1文字だけ異なる seed（post:1 と post:2 等）がほぼ同じオフセットになる
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func round6(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
@@ -0,0 +1,71 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// ぼかした座標は元の座標と同じグリッドのセル内（精度の大きさ以内）に収まること
+func TestFuzzStaysWithinPrecision(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, precision := range []string{PrecisionNeighborhood, PrecisionCity} {
+		size := gridSize[precision]
+		for i := 0; i < 1000; i++ {
+			lat := r.Float64()*170 - 85
+			lng := r.Float64()*360 - 180
+			seed := fmt.Sprintf("post:%d", i)
+
+			fLat, fLng := Fuzz(lat, lng, precision, seed)
+			if math.Abs(fLat-lat) >= size || math.Abs(fLng-lng) >= size {
+				t.Fatalf("%s: Fuzz(%f, %f) = (%f, %f) moved more than %f", precision, lat, lng, fLat, fLng, size)
+			}
+			if math.Floor(fLat/size) != math.Floor(lat/size) || math.Floor(fLng/size) != math.Floor(lng/size) {
+				t.Fatalf("%s: Fuzz(%f, %f) = (%f, %f) left the grid cell", precision, lat, lng, fLat, fLng)
+			}
+		}
+	}
+}
+
+// 同じ seed では同じ座標になり、同じセル内の座標からは元の座標を区別できないこと
+func TestFuzzDeterministic(t *testing.T) {
+	lat, lng := 35.6812, 139.7671
+	aLat, aLng := Fuzz(lat, lng, PrecisionNeighborhood, "post:1")
+	bLat, bLng := Fuzz(lat, lng, PrecisionNeighborhood, "post:1")
+	if aLat != bLat || aLng != bLng {
+		t.Errorf("same seed gave (%f, %f) and (%f, %f)", aLat, aLng, bLat, bLng)
+	}
+	if cLat, cLng := Fuzz(lat+0.0001, lng+0.0001, PrecisionNeighborhood, "post:1"); cLat != aLat || cLng != aLng {
+		t.Errorf("nearby coordinate in the same cell gave (%f, %f), want (%f, %f)", cLat, cLng, aLat, aLng)
+	}
+	if dLat, dLng := Fuzz(lat, lng, PrecisionNeighborhood, "post:2"); dLat == aLat && dLng == aLng {
+		t.Error("different seeds gave the same coordinate")
+	}
+}
+
+func TestFuzzPrecisions(t *testing.T) {
+	lat, lng := 35.6812, 139.7671
+	if fLat, fLng := Fuzz(lat, lng, PrecisionExact, "post:1"); fLat != lat || fLng != lng {
+		t.Errorf("exact = (%f, %f), want unchanged", fLat, fLng)
+	}
+	// 不明な値は既定の精度として扱う
+	uLat, uLng := Fuzz(lat, lng, "unknown", "post:1")
+	dLat, dLng := Fuzz(lat, lng, DefaultPrecision, "post:1")
+	if uLat != dLat || uLng != dLng {
+		t.Errorf("unknown precision = (%f, %f), want (%f, %f)", uLat, uLng, dLat, dLng)
+	}
+}
+
+func TestValidPrecision(t *testing.T) {
+	for _, p := range []string{PrecisionExact, PrecisionNeighborhood, PrecisionCity} {
+		if !ValidPrecision(p) {
+			t.Errorf("%s should be valid", p)
+		}
+	}
+	for _, p := range []string{"", "street", "EXACT"} {
+		if ValidPrecision(p) {
+			t.Errorf("%q should be invalid", p)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/audit"
+	"api/db"
+	"api/mailer"
+	"api/ratelimit"
+	"api/types"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	verifyEmailTokenTTL   = 24 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+)
+
+// errInvalidToken は期限切れ・使用済み・存在しないトークンの場合のエラー
+var errInvalidToken = apierror.BadRequest(apierror.CodeInvalidToken, "invalid or expired token")
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// ChangePasswordRequest は Google のみで登録したアカウントの場合 current_password を省略できる
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+// VerifyEmail handles POST /auth/verify-email
+func VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		token, err := consumeToken(tx, req.Token, types.TokenVerifyEmail)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&types.User{}).Where("id = ?", token.UserID).Update("email_verified", true).Error
+	})
+	if errors.Is(err, errInvalidToken) {
+		apierror.Abort(c, err)
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to verify email", err))
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// ResendVerification handles POST /auth/verify-email/resend
+func ResendVerification(c *gin.Context) {
+	var user types.User
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", c.GetString("user_id")).First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errUserNotFound))
+		return
+	}
+	if user.EmailVerified {
+		apierror.Abort(c, apierror.Conflict(apierror.CodeAlreadyVerified, "email already verified"))
+		return
+	}
+	if !mailAllowed(c, user.Email) {
+		return
+	}
+
+	if err := sendVerificationEmail(c.Request.Context(), user); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to send verification email", "error", err)
+		apierror.Abort(c, apierror.Internal("failed to send email", err))
+		return
+	}
+
+	respond(c, http.StatusAccepted, gin.H{"message": "verification email sent"})
+}
+
+// ForgotPassword handles POST /auth/password/forgot
+// アカウントの有無を推測されないよう、登録されていないアドレスでも同じ応答を返す
+// Google のみで登録したアカウントもこの手順でパスワードを設定できる
+func ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	if !mailAllowed(c, req.Email) {
+		return
+	}
+
+	var user types.User
+	err := db.Ctx(c.Request.Context()).Where("LOWER(email) = LOWER(?) AND valid = ?", req.Email, true).First(&user).Error
+	if err == nil {
+		if err := sendPasswordResetEmail(c.Request.Context(), user); err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to send password reset email", "error", err)
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(c.Request.Context(), "failed to find user", "error", err)
+	}
+
+	respond(c, http.StatusAccepted, gin.H{"message": "if the address is registered, a reset email has been sent"})
+}
+
+// ResetPassword handles POST /auth/password/reset
+// メールを受け取れたことの確認にもなるため、メールアドレスも確認済みにする
+func ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+
+	var user types.User
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		token, err := consumeToken(tx, req.Token, types.TokenResetPassword)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", token.UserID).First(&user).Error; err != nil {
+			return err
+		}
+		if err := setPassword(tx, user, req.Password); err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("email_verified", true).Error; err != nil {
+			return err
+		}
+		// 未ログインのため、トークンの持ち主を操作したユーザーとして記録する
+		entry := auditEntry(c, audit.ActionUserPasswordReset, audit.TargetUser, user.ID.String(), nil)
+		entry.Actor = user.ID
+		return audit.Record(tx, entry)
+	})
+	if errors.Is(err, errInvalidToken) {
+		apierror.Abort(c, err)
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to reset password", err))
+		return
+	}
+
+	// 再設定前のロックアウトを解除する
+	if err := ratelimit.Logins().Succeed(c.Request.Context(), user.Email); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to reset login lockout", "error", err)
+	}
+
+	respond(c, http.StatusOK, gin.H{"message": "password updated"})
+}
+
+// ChangePassword handles PUT /me/password
+// パスワードが未設定（Google のみ）の場合はメールアドレスのログイン方法を追加する
+// 他の端末のセッションは無効になり、この端末には新しいトークンを返す
+func ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+
+	var user types.User
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", c.GetString("user_id")).First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errUserNotFound))
+		return
+	}
+	var identity types.Identity
+	err := db.Ctx(c.Request.Context()).Where("user_id = ? AND provider = ?", user.ID, types.ProviderEmail).First(&identity).Error
+	if err == nil {
+		if err := bcrypt.CompareHashAndPassword([]byte(identity.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidCredentials, "invalid credentials"))
+			return
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.Internal("failed to update password", err))
+		return
+	}
+
+	added := err != nil // メールアドレスのログイン方法を追加する
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := setPassword(tx, user, req.NewPassword); err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntry(c, audit.ActionUserPassword, audit.TargetUser, user.ID.String(), gin.H{"identity_added": added}))
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to update password", err))
+		return
+	}
+
+	// 変更前に発行したトークンは全て無効になるため、この端末には新しいトークンを発行する
+	token, err := generateJWT(user.ID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to generate token", err))
+		return
+	}
+	c.SetCookie("token", token, 60*60*24*7, "/", "", false, true) // 7日間有効、HttpOnly
+
+	respond(c, http.StatusOK, gin.H{"message": "password updated", "token": token})
+}
+
+// setPassword はパスワードを更新し、未使用のメール確認・再設定トークンと発行済みの JWT を全て無効にする
+// メールアドレスのログイン方法がなければ追加する
+func setPassword(tx *gorm.DB, user types.User, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	result := tx.Model(&types.Identity{}).
+		Where("user_id = ? AND provider = ?", user.ID, types.ProviderEmail).
+		Update("password_hash", string(hashed))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := tx.Create(&types.Identity{
+			UserID:       user.ID,
+			Provider:     types.ProviderEmail,
+			Subject:      emailSubject(user.Email),
+			Email:        user.Email,
+			PasswordHash: string(hashed),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&types.User{}).Where("id = ?", user.ID).Update("password_set_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return revokeTokens(tx, user.ID, "")
+}
+
+// sendVerificationEmail はメール確認用のトークンを発行して送信する
+func sendVerificationEmail(ctx context.Context, user types.User) error {
+	token, err := issueToken(ctx, user.ID, types.TokenVerifyEmail, verifyEmailTokenTTL)
+	if err != nil {
+		return fmt.Errorf("failed to issue token: %w", err)
+	}
+	return mailer.Get().Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "【CHAP】メールアドレスの確認",
+		Body: fmt.Sprintf("%s さん\n\nCHAP へのご登録ありがとうございます。\n"+
+			"以下のリンクからメールアドレスの確認を完了してください（%d時間有効）。\n\n%s\n\n"+
+			"このメールに心当たりがない場合は破棄してください。\n",
+			user.Name, int(verifyEmailTokenTTL.Hours()), appURL("/verify-email", token)),
+	})
+}
+
+// sendPasswordResetEmail はパスワード再設定用のトークンを発行して送信する
+func sendPasswordResetEmail(ctx context.Context, user types.User) error {
+	token, err := issueToken(ctx, user.ID, types.TokenResetPassword, resetPasswordTokenTTL)
+	if err != nil {
+		return fmt.Errorf("failed to issue token: %w", err)
+	}
+	return mailer.Get().Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "【CHAP】パスワードの再設定",
+		Body: fmt.Sprintf("%s さん\n\n以下のリンクからパスワードを再設定してください（%d分間有効）。\n\n%s\n\n"+
+			"再設定を依頼していない場合はこのメールを破棄してください。パスワードは変更されません。\n",
+			user.Name, int(resetPasswordTokenTTL.Minutes()), appURL("/reset-password", token)),
+	})
+}
+
+// issueToken は同じ用途の古いトークンを無効にしてから新しいトークンを発行する
+// 返り値の平文トークンはメールでのみ送り、DBにはハッシュを保存する
+func issueToken(ctx context.Context, uid uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	err := db.SafeTransaction(ctx, func(tx *gorm.DB) error {
+		if err := revokeTokens(tx, uid, purpose); err != nil {
+			return err
+		}
+		return tx.Create(&types.UserToken{
+			UserID:    uid,
+			Purpose:   purpose,
+			TokenHash: hashToken(raw),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// consumeToken はトークンを検証して使用済みにする（同時に使われても一度しか成功しない）
+func consumeToken(tx *gorm.DB, raw string, purpose string) (types.UserToken, error) {
+	var token types.UserToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", hashToken(raw), purpose).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return token, errInvalidToken
+		}
+		return token, err
+	}
+
+	now := time.Now()
+	result := tx.Model(&types.UserToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", token.ID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return token, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return token, errInvalidToken
+	}
+	return token, nil
+}
+
+// revokeTokens はユーザーの未使用トークンを使用済みにする（purpose が空なら全用途）
+func revokeTokens(tx *gorm.DB, uid uuid.UUID, purpose string) error {
+	query := tx.Model(&types.UserToken{}).Where("user_id = ? AND used_at IS NULL", uid)
+	if purpose != "" {
+		query = query.Where("purpose = ?", purpose)
+	}
+	return query.Update("used_at", time.Now()).Error
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// mailAllowed は宛先アドレス毎の送信回数を制限し、超えた場合は 429 を返して false を返す
+func mailAllowed(c *gin.Context, email string) bool {
+	limit, ok := ratelimit.LimitFor("mail")
+	if !ok {
+		return true
+	}
+	result, err := ratelimit.Get().Take(c.Request.Context(), "mail:"+strings.ToLower(strings.TrimSpace(email)), limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "mail rate limit store error", "error", err)
+		return true
+	}
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+		apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, "too many emails requested for this address"))
+		return false
+	}
+	return true
+}
+
+// appURL はメールに記載するフロントエンドのURLを組み立てる（APP_BASE_URL で変更可能）
+func appURL(path, token string) string {
+	return strings.TrimRight(appConfig.App.BaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
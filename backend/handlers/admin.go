@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/audit"
+	"api/db"
+	"api/types"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	errSelfAdmin     = apierror.BadRequest(apierror.CodeInvalidRequest, "cannot change your own account")
+	errNotSuspended  = apierror.Conflict(apierror.CodeConflict, "account is not suspended")
+	errPurgedContent = apierror.Conflict(apierror.CodeConflict, "content of a deleted account cannot be restored")
+)
+
+// likeEscaper は LIKE の検索語の % と _ をそのまま検索する
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListUsers handles GET /admin/users?q=&role=&status=
+// 名前・メールアドレスで検索し、登録の新しい順にページングして返す（status=deleted は退会手続き中のユーザー）
+func ListUsers(c *gin.Context) {
+	var q types.AdminUserQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	page, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	tx := db.Ctx(c.Request.Context()).Model(&types.User{}).Where("id <> ?", types.DeletedUserID)
+	if q.Q != "" {
+		like := "%" + likeEscaper.Replace(q.Q) + "%"
+		tx = tx.Where("(name ILIKE ? OR email ILIKE ?)", like, like)
+	}
+	if q.Role != "" {
+		tx = tx.Where("role = ?", q.Role)
+	}
+	switch q.Status {
+	case types.UserActive:
+		tx = tx.Where("(valid OR suspended_until <= ?)", now)
+	case types.UserSuspended:
+		tx = tx.Where("NOT valid AND suspended_until > ?", now)
+	case types.UserBanned:
+		tx = tx.Where("NOT valid AND suspended_until IS NULL")
+	case "deleted":
+		tx = tx.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	var total int64
+	var users []types.User
+	if err := tx.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch users", err))
+		return
+	}
+	if err := tx.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch users", err))
+		return
+	}
+
+	items := make([]types.AdminUserResponse, len(users))
+	for i, u := range users {
+		items[i] = u.AdminResponse(now)
+	}
+	respond(c, http.StatusOK, gin.H{
+		"items": items,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// SuspendUser handles POST /admin/users/:id/suspend
+// until まで利用停止にする（ログイン・認証が必要なリクエストを 403 で拒否する）
+func SuspendUser(c *gin.Context) {
+	var req types.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	if !req.Until.After(time.Now()) {
+		apierror.Abort(c, apierror.Validation("until", "must be in the future"))
+		return
+	}
+	updateUserByAdmin(c, audit.ActionUserSuspend, func(user types.User) (map[string]interface{}, any, error) {
+		return map[string]interface{}{"valid": false, "suspended_until": req.Until, "suspend_reason": req.Reason},
+			gin.H{"reason": req.Reason, "until": req.Until, "previous_status": user.Status(time.Now())}, nil
+	})
+}
+
+// BanUser handles POST /admin/users/:id/ban
+// 無期限で利用停止にする（ReinstateUser で解除するまで続く）
+func BanUser(c *gin.Context) {
+	var req types.BanUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	updateUserByAdmin(c, audit.ActionUserBan, func(user types.User) (map[string]interface{}, any, error) {
+		return map[string]interface{}{"valid": false, "suspended_until": nil, "suspend_reason": req.Reason},
+			gin.H{"reason": req.Reason, "previous_status": user.Status(time.Now())}, nil
+	})
+}
+
+// ReinstateUser handles POST /admin/users/:id/reinstate
+// 利用停止を解除する
+func ReinstateUser(c *gin.Context) {
+	updateUserByAdmin(c, audit.ActionUserReinstate, func(user types.User) (map[string]interface{}, any, error) {
+		if user.Valid {
+			return nil, nil, errNotSuspended
+		}
+		return map[string]interface{}{"valid": true, "suspended_until": nil, "suspend_reason": ""},
+			gin.H{"previous_status": user.Status(time.Now()), "previous_reason": user.SuspendReason}, nil
+	})
+}
+
+// UpdateUserRole handles PUT /admin/users/:id/role
+func UpdateUserRole(c *gin.Context) {
+	var req types.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	updateUserByAdmin(c, audit.ActionUserRole, func(user types.User) (map[string]interface{}, any, error) {
+		return map[string]interface{}{"role": req.Role}, gin.H{"from": user.Role, "to": req.Role}, nil
+	})
+}
+
+// updateUserByAdmin は :id のユーザーを change の結果で更新し、同じトランザクションで監査ログを記録する
+// 自分自身のアカウントは変更できない（管理者がいなくなるのを防ぐ）
+func updateUserByAdmin(c *gin.Context, action string, change func(user types.User) (map[string]interface{}, any, error)) {
+	uid, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+	if uid.String() == c.GetString("user_id") {
+		apierror.Abort(c, errSelfAdmin)
+		return
+	}
+	if uid == types.DeletedUserID {
+		apierror.Abort(c, errUserNotFound)
+		return
+	}
+
+	var user types.User
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", uid).First(&user).Error; err != nil {
+			return err
+		}
+		updates, detail, err := change(user)
+		if err != nil {
+			return err
+		}
+		before := user.AdminResponse(time.Now())
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", uid).First(&user).Error; err != nil {
+			return err
+		}
+		entry := auditEntry(c, action, audit.TargetUser, user.ID.String(), detail)
+		entry.Before, entry.After = before, user.AdminResponse(time.Now())
+		return audit.Record(tx, entry)
+	})
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) {
+		apierror.Abort(c, apiErr)
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errUserNotFound))
+		return
+	}
+
+	respond(c, http.StatusOK, user.AdminResponse(time.Now()))
+}
+
+// ListDeletedContent handles GET /admin/deleted/:type
+// 削除済みの投稿・スレッド・イベント・コメントを削除の新しい順にページングして返す
+func ListDeletedContent(c *gin.Context) {
+	targetType := c.Param("type")
+	model, ok := contentModel(targetType)
+	if !ok {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid content type"))
+		return
+	}
+	page, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	columns := "id, user_id, username, content, valid, created_at, deleted_at"
+	if targetType != targetComment {
+		columns += ", category"
+	}
+	deleted := db.Ctx(c.Request.Context()).Unscoped().Model(model).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	items := []types.DeletedContentResponse{}
+	if err := deleted.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch deleted content", err))
+		return
+	}
+	if err := deleted.Select(columns).Order("deleted_at DESC").Offset((page - 1) * limit).Limit(limit).Scan(&items).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch deleted content", err))
+		return
+	}
+	for i := range items {
+		items[i].Type = targetType
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"items": items,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// RestoreDeletedContent handles POST /admin/deleted/:type/:id/restore
+// 削除を取り消す（削除時に消えた添付ファイルは戻らない。退会の保持方針で消去したものは戻せない）
+func RestoreDeletedContent(c *gin.Context) {
+	targetType := c.Param("type")
+	model, ok := contentModel(targetType)
+	if !ok {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid content type"))
+		return
+	}
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid id"))
+		return
+	}
+
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		var row struct {
+			UserID    uuid.UUID
+			DeletedAt time.Time
+		}
+		result := tx.Unscoped().Model(model).Select("user_id, deleted_at").
+			Where("id = ? AND deleted_at IS NOT NULL", targetID).Scan(&row)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errContentNotFound
+		}
+		if row.UserID == types.DeletedUserID {
+			return errPurgedContent
+		}
+		if err := tx.Unscoped().Model(model).Where("id = ?", targetID).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntry(c, audit.ContentAction(targetType, audit.OpUndelete), targetType, strconv.FormatUint(targetID, 10),
+			gin.H{"deleted_at": row.DeletedAt}))
+	})
+	if errors.Is(err, errContentNotFound) {
+		apierror.Abort(c, errContentNotFound.WithMessage("deleted %s not found", targetType))
+		return
+	}
+	if errors.Is(err, errPurgedContent) {
+		apierror.Abort(c, errPurgedContent)
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to restore "+targetType, err))
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"target_type": targetType, "target_id": targetID})
+}
+
+// GetAdminStats handles GET /admin/stats
+func GetAdminStats(c *gin.Context) {
+	ctx := c.Request.Context()
+	now := time.Now()
+	weekAgo := now.AddDate(0, 0, -7)
+	stats := types.AdminStats{
+		Users:       types.UserCounts{Roles: map[string]int64{}},
+		Content:     map[string]types.ContentCounts{},
+		GeneratedAt: now,
+	}
+
+	users := func() *gorm.DB {
+		return db.Ctx(ctx).Model(&types.User{}).Where("id <> ?", types.DeletedUserID)
+	}
+	counts := []struct {
+		dst   *int64
+		query *gorm.DB
+	}{
+		{&stats.Users.Active, users().Where("(valid OR suspended_until <= ?)", now)},
+		{&stats.Users.Suspended, users().Where("NOT valid AND suspended_until > ?", now)},
+		{&stats.Users.Banned, users().Where("NOT valid AND suspended_until IS NULL")},
+		{&stats.Users.Deleting, users().Unscoped().Where("deleted_at IS NOT NULL")},
+		{&stats.Users.New7d, users().Unscoped().Where("created_at >= ?", weekAgo)},
+		{&stats.OpenReports, db.Ctx(ctx).Model(&types.Report{}).Where("status = ?", types.ReportOpen)},
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.dst).Error; err != nil {
+			apierror.Abort(c, apierror.Internal("failed to fetch stats", err))
+			return
+		}
+	}
+	stats.Users.Total = stats.Users.Active + stats.Users.Suspended + stats.Users.Banned + stats.Users.Deleting
+
+	var roles []struct {
+		Role  string
+		Count int64
+	}
+	if err := users().Select("role, COUNT(*) AS count").Group("role").Scan(&roles).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch stats", err))
+		return
+	}
+	for _, r := range roles {
+		stats.Users.Roles[r.Role] = r.Count
+	}
+
+	for _, targetType := range []string{targetPost, targetThread, targetEvent, targetComment} {
+		counts, err := contentCounts(ctx, targetType, weekAgo)
+		if err != nil {
+			apierror.Abort(c, apierror.Internal("failed to fetch stats", err))
+			return
+		}
+		stats.Content[targetType] = counts
+	}
+
+	respond(c, http.StatusOK, stats)
+}
+
+func contentCounts(ctx context.Context, targetType string, since time.Time) (types.ContentCounts, error) {
+	model, _ := contentModel(targetType)
+	var counts types.ContentCounts
+	err := db.Ctx(ctx).Unscoped().Model(model).Select(`
+            COUNT(*) FILTER (WHERE deleted_at IS NULL AND valid) AS visible,
+            COUNT(*) FILTER (WHERE deleted_at IS NULL AND NOT valid) AS hidden,
+            COUNT(*) FILTER (WHERE deleted_at IS NOT NULL) AS deleted,
+            COUNT(*) FILTER (WHERE created_at >= ?) AS new7d`, since).
+		Scan(&counts).Error
+	return counts, err
+}
+
+// LiftExpiredSuspensions は期限を過ぎた利用停止を解除する（定期実行）
+// 期限を過ぎていれば解除前でもログインできるが、公開プロフィール等は valid で絞り込むため戻しておく
+func LiftExpiredSuspensions(ctx context.Context) error {
+	result := db.Ctx(ctx).Model(&types.User{}).
+		Where("NOT valid AND suspended_until <= ?", time.Now()).
+		Updates(map[string]interface{}{"valid": true, "suspended_until": nil, "suspend_reason": ""})
+	return result.Error
+}
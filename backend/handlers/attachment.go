@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/db"
+	"api/imageproc"
+	"api/storage"
+	"api/types"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAttachmentBytes    = 10 << 20 // 10MB
+	maxAttachmentsPerItem = 4
+	attachmentMaxSize     = 2048 // 長辺の最大ピクセル数
+	thumbnailMaxSize      = 320
+)
+
+// errInvalidAttachments はクライアントが指定した添付ファイルIDが不正な場合のエラー
+var errInvalidAttachments = apierror.BadRequest(apierror.CodeInvalidAttachment, "invalid attachment ids")
+
+// 添付ファイルを紐付けられる投稿種別
+const (
+	targetPost    = "post"
+	targetThread  = "thread"
+	targetEvent   = "event"
+	targetComment = "comment"
+)
+
+// UploadAttachment handles POST /upload (multipart/form-data, field "file")
+// MIME タイプを実データから判定し、縮小・再エンコード（EXIF の位置情報を除去）してサムネイルと共に保存する
+func UploadAttachment(c *gin.Context) {
+	uid, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
+		return
+	}
+
+	// ボディを読む前にサイズを検証する
+	if c.Request.ContentLength > maxAttachmentBytes+(1<<20) {
+		apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "file is too large"))
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAttachmentBytes+(1<<20))
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("file", "is required"))
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxAttachmentBytes {
+		apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "file is too large"))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentBytes+1))
+	if err != nil || len(data) > maxAttachmentBytes {
+		apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "file is too large"))
+		return
+	}
+	if !imageproc.IsAllowed(imageproc.Sniff(data)) {
+		apierror.Abort(c, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMediaType, "unsupported file type"))
+		return
+	}
+
+	img, format, err := imageproc.Decode(data)
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMediaType, "unsupported image"))
+		return
+	}
+	full, err := imageproc.Encode(imageproc.Fit(img, attachmentMaxSize), format)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to process image", err))
+		return
+	}
+	thumb, err := imageproc.Encode(imageproc.Fit(img, thumbnailMaxSize), format)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to process image", err))
+		return
+	}
+
+	store := storage.Get()
+	ctx := c.Request.Context()
+	name := uuid.New().String()
+	key := fmt.Sprintf("attachments/%s/%s%s", uid, name, imageproc.Extension(full.ContentType))
+	thumbKey := fmt.Sprintf("attachments/%s/%s_thumb%s", uid, name, imageproc.Extension(thumb.ContentType))
+
+	if err := store.Put(ctx, key, bytes.NewReader(full.Data), int64(len(full.Data)), full.ContentType); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to store attachment", "error", err)
+		apierror.Abort(c, apierror.Internal("failed to store file", err))
+		return
+	}
+	if err := store.Put(ctx, thumbKey, bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to store attachment", "error", err)
+		_ = store.Delete(ctx, key)
+		apierror.Abort(c, apierror.Internal("failed to store file", err))
+		return
+	}
+
+	attachment := types.Attachment{
+		UserID:       uid,
+		Key:          key,
+		ThumbnailKey: thumbKey,
+		URL:          store.URL(key),
+		ThumbnailURL: store.URL(thumbKey),
+		ContentType:  full.ContentType,
+		Size:         int64(len(full.Data)),
+		Width:        full.Width,
+		Height:       full.Height,
+	}
+	if err := db.Ctx(c.Request.Context()).Create(&attachment).Error; err != nil {
+		deleteBlobs(ctx, []types.Attachment{attachment})
+		apierror.Abort(c, apierror.Internal("failed to save attachment", err))
+		return
+	}
+
+	respond(c, http.StatusCreated, attachment)
+}
+
+// linkAttachments は自分がアップロードした未使用の添付ファイルを投稿に紐付ける
+func linkAttachments(tx *gorm.DB, uid uuid.UUID, targetType string, targetID uint, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if len(ids) > maxAttachmentsPerItem {
+		return errInvalidAttachments.WithMessage("too many attachments (max %d)", maxAttachmentsPerItem)
+	}
+
+	var count int64
+	if err := tx.Model(&types.Attachment{}).
+		Where("id IN ? AND user_id = ?", ids, uid).
+		Where("id NOT IN (?)", tx.Model(&types.ContentAttachment{}).Select("attachment_id")).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(ids) {
+		return errInvalidAttachments
+	}
+
+	links := make([]types.ContentAttachment, len(ids))
+	for i, id := range ids {
+		links[i] = types.ContentAttachment{
+			AttachmentID: id,
+			TargetType:   targetType,
+			TargetID:     targetID,
+			Position:     i,
+		}
+	}
+	return tx.Create(&links).Error
+}
+
+// replaceAttachments は投稿の添付ファイルを ids に置き換え、外れた添付ファイルを返す
+func replaceAttachments(tx *gorm.DB, uid uuid.UUID, targetType string, targetID uint, ids []uint) ([]types.Attachment, error) {
+	current, err := findAttachments(tx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[uint]bool{}
+	for _, id := range ids {
+		keep[id] = true
+	}
+	var removed []types.Attachment
+	for _, a := range current {
+		if !keep[a.ID] {
+			removed = append(removed, a)
+		}
+	}
+
+	if err := tx.Where("target_type = ? AND target_id = ?", targetType, targetID).Delete(&types.ContentAttachment{}).Error; err != nil {
+		return nil, err
+	}
+	if len(removed) > 0 {
+		if err := tx.Delete(&removed).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := linkAttachments(tx, uid, targetType, targetID, ids); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// unlinkAttachments は投稿に紐付いた添付ファイルのレコードを削除し、削除したものを返す
+// 実ファイルの削除はコミット後に deleteBlobs で行う
+func unlinkAttachments(tx *gorm.DB, targetType string, targetID uint) ([]types.Attachment, error) {
+	attachments, err := findAttachments(tx, targetType, targetID)
+	if err != nil || len(attachments) == 0 {
+		return nil, err
+	}
+	if err := tx.Where("target_type = ? AND target_id = ?", targetType, targetID).Delete(&types.ContentAttachment{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Delete(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func findAttachments(tx *gorm.DB, targetType string, targetID uint) ([]types.Attachment, error) {
+	var attachments []types.Attachment
+	err := tx.Joins("JOIN content_attachments ON content_attachments.attachment_id = attachments.id").
+		Where("content_attachments.target_type = ? AND content_attachments.target_id = ?", targetType, targetID).
+		Order("content_attachments.position").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+// deleteBlobs は添付ファイルの実体とサムネイルを保存先から削除する
+func deleteBlobs(ctx context.Context, attachments []types.Attachment) {
+	store := storage.Get()
+	for _, a := range attachments {
+		for _, key := range []string{a.Key, a.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := store.Delete(ctx, key); err != nil {
+				slog.ErrorContext(ctx, "failed to delete blob", "key", key, "error", err)
+			}
+		}
+	}
+}
+
+// attachmentErrorResponse は添付ファイル処理のエラーをレスポンスに変換する
+func attachmentErrorResponse(c *gin.Context, err error, message string) {
+	if errors.Is(err, errInvalidAttachments) {
+		apierror.Abort(c, err)
+		return
+	}
+	apierror.Abort(c, apierror.Internal(message, err))
+}
+
+// loadAttachments は複数投稿の添付ファイルを一括取得し、投稿ID毎にまとめて返す
+func loadAttachments(ctx context.Context, targetType string, ids []uint) (map[uint][]types.Attachment, error) {
+	result := map[uint][]types.Attachment{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		types.Attachment
+		TargetID uint
+	}
+	if err := db.Ctx(ctx).Table("attachments").
+		Select("attachments.*, content_attachments.target_id").
+		Joins("JOIN content_attachments ON content_attachments.attachment_id = attachments.id").
+		Where("content_attachments.target_type = ? AND content_attachments.target_id IN ?", targetType, ids).
+		Order("content_attachments.position").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.TargetID] = append(result[row.TargetID], row.Attachment)
+	}
+	return result, nil
+}
+
+// attachMedia はレスポンス用に投稿へ添付ファイルを詰める
+// dest には *types.Post / *[]types.Post など各投稿種別のポインタを渡す
+func attachMedia(ctx context.Context, dest interface{}) error {
+	switch v := dest.(type) {
+	case *types.Post:
+		posts := []types.Post{*v}
+		if err := attachMedia(ctx, &posts); err != nil {
+			return err
+		}
+		*v = posts[0]
+	case *types.Thread:
+		threads := []types.Thread{*v}
+		if err := attachMedia(ctx, &threads); err != nil {
+			return err
+		}
+		*v = threads[0]
+	case *types.Event:
+		events := []types.Event{*v}
+		if err := attachMedia(ctx, &events); err != nil {
+			return err
+		}
+		*v = events[0]
+	case *types.Comment:
+		comments := []types.Comment{*v}
+		if err := attachMedia(ctx, &comments); err != nil {
+			return err
+		}
+		*v = comments[0]
+	case *[]types.Post:
+		ids := make([]uint, len(*v))
+		for i, p := range *v {
+			ids[i] = p.ID
+		}
+		m, err := loadAttachments(ctx, targetPost, ids)
+		if err != nil {
+			return err
+		}
+		for i := range *v {
+			(*v)[i].Attachments = orEmpty(m[(*v)[i].ID])
+		}
+	case *[]types.Thread:
+		ids := make([]uint, len(*v))
+		for i, t := range *v {
+			ids[i] = t.ID
+		}
+		m, err := loadAttachments(ctx, targetThread, ids)
+		if err != nil {
+			return err
+		}
+		for i := range *v {
+			(*v)[i].Attachments = orEmpty(m[(*v)[i].ID])
+		}
+	case *[]types.Event:
+		ids := make([]uint, len(*v))
+		for i, e := range *v {
+			ids[i] = e.ID
+		}
+		m, err := loadAttachments(ctx, targetEvent, ids)
+		if err != nil {
+			return err
+		}
+		for i := range *v {
+			(*v)[i].Attachments = orEmpty(m[(*v)[i].ID])
+		}
+	case *[]types.Comment:
+		ids := make([]uint, len(*v))
+		for i, cm := range *v {
+			ids[i] = cm.ID
+		}
+		m, err := loadAttachments(ctx, targetComment, ids)
+		if err != nil {
+			return err
+		}
+		for i := range *v {
+			(*v)[i].Attachments = orEmpty(m[(*v)[i].ID])
+		}
+	default:
+		return fmt.Errorf("attachMedia: unsupported type %T", dest)
+	}
+	return nil
+}
+
+func orEmpty(attachments []types.Attachment) []types.Attachment {
+	if attachments == nil {
+		return []types.Attachment{}
+	}
+	return attachments
+}
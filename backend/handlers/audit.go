@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/audit"
+	"api/db"
+	"api/types"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListAuditLogs handles GET /admin/audit-logs?actor_id=&action=&target_type=&target_id=&request_id=&from=&to=
+// 監査ログを新しい順にページングして返す
+func ListAuditLogs(c *gin.Context) {
+	var q types.AuditLogQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	page, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	tx := db.Ctx(c.Request.Context()).Model(&types.AuditLog{})
+	if q.ActorID != "" {
+		tx = tx.Where("actor_id = ?", q.ActorID)
+	}
+	if strings.HasSuffix(q.Action, ".") {
+		tx = tx.Where("action LIKE ?", likeEscaper.Replace(q.Action)+"%")
+	} else if q.Action != "" {
+		tx = tx.Where("action = ?", q.Action)
+	}
+	if q.TargetType != "" {
+		tx = tx.Where("target_type = ?", q.TargetType)
+	}
+	if q.TargetID != "" {
+		tx = tx.Where("target_id = ?", q.TargetID)
+	}
+	if q.RequestID != "" {
+		tx = tx.Where("request_id = ?", q.RequestID)
+	}
+	if q.From > 0 {
+		tx = tx.Where("created_at >= ?", time.Unix(q.From, 0))
+	}
+	if q.To > 0 {
+		tx = tx.Where("created_at < ?", time.Unix(q.To, 0))
+	}
+
+	var total int64
+	logs := []types.AuditLog{}
+	if err := tx.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch audit logs", err))
+		return
+	}
+	if err := tx.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&logs).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch audit logs", err))
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"items": logs,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// auditEntry は操作したユーザー、IP、リクエストIDを付けた監査ログの内容を返す
+func auditEntry(c *gin.Context, action, targetType, targetID string, detail any) audit.Entry {
+	actor, _ := uuid.Parse(c.GetString("user_id"))
+	return audit.Entry{
+		Actor:      actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Detail:     detail,
+		IP:         c.ClientIP(),
+		RequestID:  c.GetString("request_id"),
+	}
+}
+
+// recordContentChange は投稿・スレッド・イベント・コメントの作成・編集・削除を監査ログに記録する
+// before・after は Response() の値（作成は before、削除は after を nil にする）
+func recordContentChange(tx *gorm.DB, c *gin.Context, targetType, op string, id uint, before, after, detail any) error {
+	entry := auditEntry(c, audit.ContentAction(targetType, op), targetType, strconv.FormatUint(uint64(id), 10), detail)
+	entry.Before, entry.After = before, after
+	return audit.Record(tx, entry)
+}
+
+// attachmentDetail は添付ファイルを指定した場合のみ監査ログの補足にする（Response() には添付ファイルのIDが含まれないため）
+func attachmentDetail(ids []uint) any {
+	if ids == nil {
+		return nil
+	}
+	return gin.H{"attachment_ids": ids}
+}
@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"api/apierror"
 	"api/db"
+	"api/middleware"
+	"api/ratelimit"
 	"api/types"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type LoginRequest struct {
@@ -33,56 +40,60 @@ type AuthResponse struct {
 func Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		apierror.Abort(c, apierror.Bind(err))
 		return
 	}
 
-	// データベースからユーザーを検索
-	var user types.User
-	result := db.SafeDB().Where("email = ?", req.Email).First(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+	// 連続して失敗しているアカウントはパスワードを検証せずに拒否する
+	if loginLocked(c, req.Email) {
 		return
 	}
 
-	// LoginTypeに応じてログイン処理を分岐
-	switch user.LoginType {
-	case "email":
-		// Emailログインの場合、パスワード検証
-		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
-			return
-		}
-
-		// EmailLoginテーブルでも確認（追加セキュリティ）
-		var emailLogin types.EmailLogin
-		if err := db.SafeDB().Where("user_id = ? AND email = ?", user.ID, user.Email).First(&emailLogin).Error; err != nil {
-			fmt.Printf("Warning: EmailLogin record not found for user %s\n", user.ID)
-		}
+	// メールアドレスのログイン方法を検索してパスワードを検証
+	var identity types.Identity
+	if err := db.Ctx(c.Request.Context()).Where("provider = ? AND subject = ?", types.ProviderEmail, emailSubject(req.Email)).First(&identity).Error; err != nil {
+		loginFailed(c, req.Email)
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(identity.PasswordHash), []byte(req.Password)); err != nil {
+		loginFailed(c, req.Email)
+		return
+	}
 
-	case "google":
-		// Googleログインの場合は、パスワード認証をスキップ
-		// 実際のGoogle OAuth認証は別途実装が必要
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Google login not supported via this endpoint"})
+	// 退会手続き中のユーザーがログインした場合は退会を取り消す
+	var user types.User
+	if err := db.Ctx(c.Request.Context()).Unscoped().Where("id = ?", identity.UserID).First(&user).Error; err != nil {
+		loginFailed(c, req.Email)
 		return
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported login type"})
+	}
+	// 利用停止中のアカウントにはトークンを発行しない
+	if suspended := middleware.Suspended(user); suspended != nil {
+		apierror.Abort(c, suspended)
+		return
+	}
+	if err := restoreAccount(db.Ctx(c.Request.Context()), &user); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to restore account", err))
 		return
 	}
+	touchIdentity(c.Request.Context(), identity.ID)
 
 	// JWTトークン生成
 	token, err := generateJWT(user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		apierror.Abort(c, apierror.Internal("failed to generate token", err))
 		return
 	}
 
 	user.Password = ""
 
+	if err := ratelimit.Logins().Succeed(c.Request.Context(), req.Email); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to reset login lockout", "error", err)
+	}
+
 	// JWTをHttpOnly Cookieに設定
 	c.SetCookie("token", token, 60*60*24*7, "/", "", false, true) // 7日間有効、HttpOnly
 
-	c.JSON(http.StatusOK, AuthResponse{
+	respond(c, http.StatusOK, AuthResponse{
 		Token: token,
 		User:  user,
 	})
@@ -91,56 +102,59 @@ func Login(c *gin.Context) {
 func Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		apierror.Abort(c, apierror.Bind(err))
 		return
 	}
-	fmt.Println("Register request:", req) // デバッグ用ログ出力
 
-	// メールアドレスの重複チェック
-	var existingUser types.User
-	if err := db.SafeDB().Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "email already exists"})
+	// メールアドレスの重複チェック（Google で登録済みの場合はそちらでログインしてパスワードを設定する）
+	var existing int64
+	db.Ctx(c.Request.Context()).Unscoped().Model(&types.User{}).Where("LOWER(email) = LOWER(?)", req.Email).Count(&existing)
+	if existing > 0 {
+		apierror.Abort(c, apierror.Conflict(apierror.CodeAccountExists, "email already exists"))
 		return
 	}
 
 	// パスワードハッシュ化
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		apierror.Abort(c, apierror.Internal("failed to hash password", err))
 		return
 	}
 
-	// 新規ユーザー作成
+	// 新規ユーザーとメールアドレスのログイン方法を作成
 	user := types.User{
 		ID:        uuid.New(), // UUIDを自動生成
 		Name:      req.Name,
 		Email:     req.Email,
-		Password:  string(hashedPassword),
 		Valid:     true,
-		LoginType: "email", // デフォルトのログインタイプ
+		LoginType: types.ProviderEmail,
 	}
-	fmt.Printf("Creating user: %+v\n", user) // デバッグ用ログ出力
-	result := db.SafeDB().Create(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&types.Identity{
+			UserID:       user.ID,
+			Provider:     types.ProviderEmail,
+			Subject:      emailSubject(user.Email),
+			Email:        user.Email,
+			PasswordHash: string(hashedPassword),
+		}).Error
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to create user", err))
 		return
 	}
 
-	// EmailLoginテーブルにも記録（Emailログインの場合）
-	emailLogin := types.EmailLogin{
-		UserID:   user.ID,
-		Email:    user.Email,
-		Password: string(hashedPassword),
-	}
-	if err := db.SafeDB().Create(&emailLogin).Error; err != nil {
-		// EmailLoginの作成に失敗した場合はログに記録するが、続行
-		fmt.Printf("Warning: Failed to create EmailLogin record: %v\n", err)
+	// 確認メールの送信に失敗しても登録は完了させる（再送できる）
+	if err := sendVerificationEmail(c.Request.Context(), user); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to send verification email", "error", err)
 	}
 
 	// JWTトークン生成
 	token, err := generateJWT(user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		apierror.Abort(c, apierror.Internal("failed to generate token", err))
 		return
 	}
 
@@ -150,7 +164,7 @@ func Register(c *gin.Context) {
 	// パスワードをレスポンスから除外
 	user.Password = ""
 
-	c.JSON(http.StatusCreated, AuthResponse{
+	respond(c, http.StatusCreated, AuthResponse{
 		Token: token,
 		User:  user,
 	})
@@ -158,9 +172,9 @@ func Register(c *gin.Context) {
 
 func generateJWT(userID uuid.UUID) (string, error) {
 	// JWT Secretの確認
-	jwtSecret := os.Getenv("JWT_SECRET")
+	jwtSecret := appConfig.Auth.JWTSecret
 	if jwtSecret == "" {
-		return "", fmt.Errorf("JWT_SECRET environment variable is not set")
+		return "", fmt.Errorf("JWT_SECRET is not configured")
 	}
 
 	// JWT Claims作成
@@ -186,94 +200,122 @@ func GetCurrentUser(c *gin.Context) {
 	// ミドルウェアでセットされたユーザーIDを取得
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeUnauthorized, "user not authenticated"))
 		return
 	}
 
 	var user types.User
-	result := db.SafeDB().Where("id = ?", userID).First(&user)
+	result := db.Ctx(c.Request.Context()).Where("id = ?", userID).First(&user)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errUserNotFound))
 		return
 	}
 
 	// パスワードは返さない
 	user.Password = ""
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	respond(c, http.StatusOK, gin.H{"user": user})
 }
 
 func Logout(c *gin.Context) {
 	// Cookieからトークンを削除
 	c.SetCookie("token", "", -1, "/", "", false, true)
-	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+	respond(c, http.StatusOK, gin.H{"message": "logged out successfully"})
 }
 
 // GoogleLoginRequest for Google OAuth login
+// email / name は互換性のために受け付けるが、Google から取得した値を優先する
 type GoogleLoginRequest struct {
 	AccessToken string `json:"access_token" binding:"required"`
-	Email       string `json:"email" binding:"required"`
-	Name        string `json:"name" binding:"required"`
+	Email       string `json:"email"`
+	Name        string `json:"name"`
 }
 
 // GoogleLogin handles Google OAuth login
+// Google のユーザーIDで紐付け済みのアカウントにログインし、未登録なら新規登録する
+// 同じメールアドレスの確認済みアカウントがあれば自動で紐付ける
 func GoogleLogin(c *gin.Context) {
 	var req GoogleLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		apierror.Abort(c, apierror.Bind(err))
 		return
 	}
-	var user types.User
-	result := db.SafeDB().Where("email = ? AND login_type = ?", req.Email, "google").First(&user)
 
-	if result.Error != nil {
-		// ユーザーが存在しない場合、新規登録
-		user = types.User{
-			ID:        uuid.New(),
-			Name:      req.Name,
-			Email:     req.Email,
-			Valid:     true,
-			LoginType: "google",
-			Password:  "", // Googleログインの場合はパスワード不要
-		}
+	profile, ok := verifyGoogleToken(c, req.AccessToken)
+	if !ok {
+		return
+	}
 
-		createResult := db.SafeDB().Create(&user)
-		if createResult.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
-			return
+	var user types.User
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		var identity types.Identity
+		err := tx.Where("provider = ? AND subject = ?", types.ProviderGoogle, profile.Sub).First(&identity).Error
+		if err == nil {
+			if err := tx.Unscoped().Where("id = ?", identity.UserID).First(&user).Error; err != nil {
+				return err
+			}
+			if err := restoreAccount(tx, &user); err != nil {
+				return err
+			}
+			return tx.Model(&identity).Updates(map[string]interface{}{"email": profile.Email, "last_used_at": time.Now()}).Error
 		}
-
-		// GoogleLogin テーブルにも記録
-		googleLogin := types.GoogleLogin{
-			UserID:      user.ID,
-			AccessToken: req.AccessToken,
-			Email:       req.Email,
-			Name:        req.Name,
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
 		}
-		db.SafeDB().Create(&googleLogin)
-	} else {
-		// 既存ユーザーの場合、GoogleLogin テーブルのアクセストークンを更新
-		var googleLogin types.GoogleLogin
-		if err := db.SafeDB().Where("user_id = ?", user.ID).First(&googleLogin).Error; err != nil {
-			// GoogleLogin レコードが存在しない場合は作成
-			googleLogin = types.GoogleLogin{
-				UserID:      user.ID,
-				AccessToken: req.AccessToken,
-				Email:       req.Email,
-				Name:        req.Name,
+
+		err = tx.Unscoped().Where("LOWER(email) = LOWER(?)", profile.Email).First(&user).Error
+		switch {
+		case err == nil:
+			// 確認されていないアドレスで先に登録されたアカウントを乗っ取られないよう、
+			// 確認済みのアカウント（または旧形式の Google アカウント）のみ自動で紐付ける
+			if !user.EmailVerified && user.LoginType != types.ProviderGoogle {
+				return errAccountExists
+			}
+			if err := restoreAccount(tx, &user); err != nil {
+				return err
+			}
+			if err := tx.Model(&user).Update("email_verified", true).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			user = types.User{
+				ID:            uuid.New(),
+				Name:          googleDisplayName(profile, req.Name),
+				Email:         profile.Email,
+				Valid:         true,
+				LoginType:     types.ProviderGoogle,
+				EmailVerified: true, // Google で確認済みのアドレスのため確認メールは送らない
 			}
-			db.SafeDB().Create(&googleLogin)
-		} else {
-			// 既存のGoogleLogin レコードを更新
-			googleLogin.AccessToken = req.AccessToken
-			db.SafeDB().Save(&googleLogin)
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		default:
+			return err
 		}
+		return linkGoogleIdentity(tx, user.ID, profile)
+	})
+	if errors.Is(err, errAccountExists) {
+		apierror.Abort(c, err)
+		return
+	}
+	if errors.Is(err, errIdentityConflict) {
+		apierror.Abort(c, err)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to verify google token", "error", err)
+		apierror.Abort(c, apierror.Internal("failed to log in with google", err))
+		return
+	}
+	if suspended := middleware.Suspended(user); suspended != nil {
+		apierror.Abort(c, suspended)
+		return
 	}
 
 	// JWTトークン生成
 	token, err := generateJWT(user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		apierror.Abort(c, apierror.Internal("failed to generate token", err))
 		return
 	}
 
@@ -283,8 +325,31 @@ func GoogleLogin(c *gin.Context) {
 	// JWTをHttpOnly Cookieに設定
 	c.SetCookie("token", token, 60*60*24*7, "/", "", false, true) // 7日間有効、HttpOnly
 
-	c.JSON(http.StatusOK, AuthResponse{
+	respond(c, http.StatusOK, AuthResponse{
 		Token: token,
 		User:  user,
 	})
 }
+
+// loginLocked はアカウントがロック中なら 429 を返して true を返す
+func loginLocked(c *gin.Context, email string) bool {
+	remaining, err := ratelimit.Logins().Locked(c.Request.Context(), email)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to check login lockout", "error", err)
+		return false
+	}
+	if remaining <= 0 {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+	apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeAccountLocked, "too many failed login attempts"))
+	return true
+}
+
+// loginFailed は失敗を記録して 401 を返す（存在しないアカウントも同様に数える）
+func loginFailed(c *gin.Context, email string) {
+	if _, err := ratelimit.Logins().Fail(c.Request.Context(), email); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to record login failure", "error", err)
+	}
+	apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidCredentials, "invalid credentials"))
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/audit"
+	"api/bulk"
+	"api/category"
+	"api/db"
+	"api/server"
+	"api/types"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// 取り込めるファイルの最大サイズ
+	maxImportBytes = 20 << 20
+	// エクスポートの書き出しの最大時間（全件の書き出しは SERVER_WRITE_TIMEOUT 内に終わらないことがある）
+	bulkExportTimeout = 30 * time.Minute
+)
+
+// ExportContent handles GET /admin/export
+// 条件に合う投稿・スレッド・イベントを GeoJSON（既定）・NDJSON・CSV で書き出す
+func ExportContent(c *gin.Context) {
+	var req types.ExportQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	format := req.Format
+	if format == "" {
+		format = bulk.FormatGeoJSON
+	}
+
+	f := bulk.Filter{
+		Kinds:         bulk.ParseList(req.Type),
+		Categories:    bulk.ParseList(req.Category),
+		IncludeHidden: req.IncludeHidden,
+	}
+	for _, kind := range f.Kinds {
+		if !bulk.ValidKind(kind) {
+			apierror.Abort(c, apierror.Validation("type", "must be post, thread or event"))
+			return
+		}
+	}
+	for _, id := range f.Categories {
+		if _, ok := category.Get().Lookup(id); !ok {
+			apierror.Abort(c, apierror.Validation("category", "unknown category"))
+			return
+		}
+	}
+	if req.BBox != "" {
+		bbox, err := bulk.ParseBBox(req.BBox)
+		if err != nil {
+			apierror.Abort(c, apierror.Validation("bbox", err.Error()))
+			return
+		}
+		f.BBox = bbox
+	}
+	if req.From > 0 {
+		f.From = time.Unix(req.From, 0)
+	}
+	if req.To > 0 {
+		f.To = time.Unix(req.To, 0)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		apierror.Abort(c, apierror.Validation("to", "must be after from"))
+		return
+	}
+
+	if err := server.ExtendWriteDeadline(c.Writer, time.Now().Add(bulkExportTimeout)); err != nil {
+		slog.WarnContext(c.Request.Context(), "failed to extend write deadline", "error", err)
+	}
+	filename := fmt.Sprintf("chap-content-%s%s", time.Now().Format("20060102"), bulk.Extension(format))
+	c.Header("Content-Type", bulk.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// 書き出しを始めた後はステータスを変えられないため、途中のエラーはログのみ
+	count, err := bulk.Export(c.Request.Context(), c.Writer, format, f)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "content export failed", "format", format, "written", count, "error", err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "Content exported", "format", format, "count", count)
+	entry := auditEntry(c, audit.ActionContentExport, audit.TargetContent, "", gin.H{"format": format, "filter": f, "count": count})
+	if err := audit.Record(db.Ctx(c.Request.Context()), entry); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to record content export", "error", err)
+	}
+}
+
+// ImportContent handles POST /admin/import (multipart/form-data, field "file")
+// 各行を検証し、誤りのある行と既存の投稿等と重複する行を飛ばして取り込む（dry_run=true の場合は保存しない）
+func ImportContent(c *gin.Context) {
+	var req types.ImportQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+
+	// ボディを読む前にサイズを検証する
+	if c.Request.ContentLength > maxImportBytes+(1<<20) {
+		apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "file is too large"))
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes+(1<<20))
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("file", "is required"))
+		return
+	}
+	defer file.Close()
+	if header.Size > maxImportBytes {
+		apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "file is too large"))
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = bulk.FormatOf(header.Filename)
+	}
+	if format == "" {
+		apierror.Abort(c, apierror.Validation("format", "cannot be determined from the file name"))
+		return
+	}
+
+	authorID := req.UserID
+	if authorID == "" {
+		authorID = c.GetString("user_id")
+	}
+	var author types.User
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", authorID).First(&author).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errUserNotFound))
+		return
+	}
+
+	entry := auditEntry(c, audit.ActionContentImport, audit.TargetContent, "", nil)
+	res, err := bulk.Import(c.Request.Context(), file, bulk.ImportOptions{
+		Format: format,
+		Kind:   req.Type,
+		Author: author,
+		DryRun: req.DryRun,
+		Audit:  &entry,
+	})
+	var fileErr *bulk.FileError
+	if errors.As(err, &fileErr) {
+		apierror.Abort(c, apierror.Validation("file", fileErr.Message))
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to import content", err))
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Content imported",
+		"format", format, "author_id", author.ID, "dry_run", res.DryRun,
+		"total", res.Total, "imported", res.Imported, "duplicates", len(res.Duplicates), "errors", len(res.Errors))
+	respond(c, http.StatusOK, res)
+}
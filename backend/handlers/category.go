@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/category"
+	"api/contentfilter"
+	"api/types"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListCategories handles GET /categories
+func ListCategories(c *gin.Context) {
+	respond(c, http.StatusOK, types.Responses(category.Get().All()))
+}
+
+// resolveCategory は指定されたカテゴリが登録済みか確認する（未指定の場合は既定のカテゴリ）
+// 未登録の場合は 400 を返し、false を返す
+func resolveCategory(c *gin.Context, id string) (types.Category, bool) {
+	registry := category.Get()
+	if id == "" {
+		id = registry.Default()
+	}
+	cat, ok := registry.Lookup(id)
+	if !ok {
+		apierror.Abort(c, apierror.Validation("category", "unknown category"))
+		return cat, false
+	}
+	return cat, true
+}
+
+// applyCategoryPolicy はカテゴリのモデレーション方針を判定に反映する
+// review のカテゴリはフィルタを通過した投稿も保留にする
+func applyCategoryPolicy(verdict *contentfilter.Verdict, cat types.Category) {
+	if cat.Moderation != types.ModerationReview || verdict.Decision != contentfilter.Allow {
+		return
+	}
+	verdict.Decision = contentfilter.Hold
+	verdict.Results = append(verdict.Results, contentfilter.Result{
+		Filter:   "category",
+		Decision: contentfilter.Hold,
+		Reason:   "category " + cat.ID + " requires review",
+	})
+}
@@ -1,82 +1,189 @@
 package handlers
 
 import (
+	"api/apierror"
+	"api/audit"
+	"api/contentfilter"
 	"api/db"
 	"api/types"
+	"context"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func GetCommentsByThreadID(c *gin.Context) {
 	threadIDStr := c.Param("thread_id")
 	threadID, err := strconv.ParseUint(threadIDStr, 10, 64)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid thread_id"})
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid thread_id"))
 		return
 	}
 	var threadTable types.ThreadTable
-	dbConn := db.SafeDB()
+	dbConn := db.Ctx(c.Request.Context())
 	if err := dbConn.Where("thread_id = ?", threadID).First(&threadTable).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Thread not found"})
+		apierror.Abort(c, apierror.Lookup(err, errThreadNotFound))
 		return
 	}
 	commentIDs := threadTable.CommentIDs
 	if len(commentIDs) == 0 {
-		c.JSON(200, gin.H{"comments": []types.Comment{}})
+		respond(c, http.StatusOK, gin.H{"comments": []types.CommentResponse{}})
 		return
 	}
 	var comments []types.Comment
-	if err := dbConn.Where("id IN ?", commentIDs).Find(&comments).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to retrieve comments"})
+	if err := dbConn.Scopes(db.Visible).Where("id IN ?", commentIDs).Find(&comments).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to retrieve comments", err))
 		return
 	}
-	c.JSON(200, gin.H{"comments": comments})
+	if err := attachMedia(c.Request.Context(), &comments); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	obscureLocations(&comments)
+	respond(c, http.StatusOK, gin.H{"comments": types.Responses(comments)})
+}
+
+// ListThreadComments handles GET /api/v2/threads/:id/comments
+// スレッドのレスを古い順に返す
+func ListThreadComments(c *gin.Context) {
+	threadID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid thread id"))
+		return
+	}
+	if err := db.Ctx(c.Request.Context()).Scopes(db.Visible).Select("id").Where("id = ?", threadID).First(&types.Thread{}).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errThreadNotFound))
+		return
+	}
+	replies, err := loadReplies(c.Request.Context(), uint(threadID))
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to retrieve comments", err))
+		return
+	}
+	respond(c, http.StatusOK, types.Responses(replies))
+}
+
+// loadReplies はスレッドのレス（表示中のコメント）を古い順に、添付ファイルと位置の丸めを適用して返す
+func loadReplies(ctx context.Context, threadID uint) ([]types.Comment, error) {
+	var replies []types.Comment
+	if err := db.Ctx(ctx).Scopes(db.Visible).Where("thread_id = ?", threadID).Order("created_at ASC").Find(&replies).Error; err != nil {
+		return nil, err
+	}
+	if err := attachMedia(ctx, &replies); err != nil {
+		return nil, err
+	}
+	obscureLocations(&replies)
+	return replies, nil
 }
 
 func CreateComment(c *gin.Context) {
-	var comment types.Comment
-	if err := c.ShouldBindJSON(&comment); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid JSON format"})
+	var req types.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
 		return
 	}
+	comment := req.Comment()
 	// If thread id from path exists, assign it
 	if threadIDStr := c.Param("id"); threadIDStr != "" {
 		if threadID, err := strconv.ParseUint(threadIDStr, 10, 64); err == nil {
 			comment.ThreadID = uint(threadID)
 		}
 	}
+	if comment.ThreadID == 0 {
+		apierror.Abort(c, apierror.Validation("thread_id", "is required"))
+		return
+	}
 	userID := c.GetString("user_id") // ユーザーIDを取得（認証済みであることを前提とする）
 	uid, err := uuid.Parse(userID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid user ID"})
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user ID"))
 		return
 	}
 	var user types.User
-	if err := db.SafeDB().Where("id = ?", uid).Select("name").First(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user info"})
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", uid).Select("name, default_precision").First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to get user info", err))
 		return
 	}
 	comment.Username = user.Name
 
+	precision, ok := resolvePrecision(comment.Precision, user.DefaultPrecision)
+	if !ok {
+		apierror.Abort(c, apierror.Validation("precision", "invalid precision"))
+		return
+	}
+	comment.Precision = precision
+
 	comment.UserID = uid
-	comment.Valid = true
-	dbConn := db.SafeDB()
-	if err := dbConn.Create(&comment).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create comment"})
+
+	// 保存前にコンテンツフィルタにかけ、保留の場合は非表示で保存する
+	verdict, ok := screenContent(c, uid, targetComment, 0, comment.Content, comment.Tags)
+	if !ok {
 		return
 	}
-	c.JSON(201, gin.H{"message": "Comment created successfully", "comment": comment})
+	comment.Valid = verdict.Decision == contentfilter.Allow
+	// 添付ファイルの紐付けも同じトランザクションで行う
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := recordFilterResults(tx, uid, targetComment, comment.ID, comment.Content, verdict); err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetComment, audit.OpCreate, comment.ID, nil, comment.Response(), attachmentDetail(comment.AttachmentIDs)); err != nil {
+			return err
+		}
+		return linkAttachments(tx, uid, targetComment, comment.ID, comment.AttachmentIDs)
+	})
+	if err != nil {
+		attachmentErrorResponse(c, err, "Failed to create comment")
+		return
+	}
+	if err := attachMedia(c.Request.Context(), &comment); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	respond(c, http.StatusCreated, gin.H{"message": "Comment created successfully", "comment": comment.Response()})
 }
 
 func DeleteComment(c *gin.Context) {
-	comment_id := c.Param("comment_id")
-	dbConn := db.SafeDB()
-	if err := dbConn.Delete(&types.Comment{}, comment_id).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to delete comment"})
+	commentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid comment id"))
+		return
+	}
+	var comment types.Comment
+	result := db.Ctx(c.Request.Context()).Where("id = ?", commentID).Limit(1).Find(&comment)
+	if result.Error != nil {
+		apierror.Abort(c, apierror.Internal("failed to delete comment", result.Error))
+		return
+	}
+	if result.RowsAffected > 0 && comment.UserID.String() != c.GetString("user_id") {
+		apierror.Abort(c, errNotOwner)
+		return
+	}
+
+	// コメントの削除と同時に添付ファイルも削除する（削除済み・存在しない場合も成功とする）
+	var removed []types.Attachment
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if result.RowsAffected > 0 {
+			if err := tx.Delete(&comment).Error; err != nil {
+				return err
+			}
+			if err := recordContentChange(tx, c, targetComment, audit.OpDelete, comment.ID, comment.Response(), nil, nil); err != nil {
+				return err
+			}
+		}
+		var err error
+		removed, err = unlinkAttachments(tx, targetComment, uint(commentID))
+		return err
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to delete comment", err))
 		return
 	}
-	c.JSON(200, gin.H{"message": "Comment deleted successfully"})
+	deleteBlobs(c.Request.Context(), removed)
+	respond(c, http.StatusOK, gin.H{"message": "Comment deleted successfully"})
 }
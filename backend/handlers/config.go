@@ -0,0 +1,11 @@
+package handlers
+
+import "api/config"
+
+// appConfig はハンドラーが使う設定（起動時に Configure で設定する）
+var appConfig = &config.Config{}
+
+// Configure はハンドラーが使う設定を登録する
+func Configure(cfg *config.Config) {
+	appConfig = cfg
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/audit"
+	"api/db"
+	"api/storage"
+	"api/types"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// 退会時の投稿の扱い
+const (
+	retainAnonymize = "anonymize" // 投稿者を「退会したユーザー」に付け替えて残す
+	retainDelete    = "delete"    // 内容・位置情報・添付ファイルを消去して削除する
+)
+
+// 既定の保持方針。他のユーザーの返信が付くスレッドとコメントは会話が壊れないよう匿名化して残す
+var defaultRetention = map[string]string{
+	targetPost:    retainDelete,
+	targetThread:  retainAnonymize,
+	targetEvent:   retainDelete,
+	targetComment: retainAnonymize,
+}
+
+type DeleteAccountRequest struct {
+	// パスワードを設定しているアカウントでは必須
+	Password string `json:"password"`
+}
+
+// DeleteAccount handles DELETE /me
+// すぐには削除せず、猶予期間の経過後に PurgeDeletedAccounts で削除する
+func DeleteAccount(c *gin.Context) {
+	var req DeleteAccountRequest
+	// ボディは省略可能
+	_ = c.ShouldBindJSON(&req)
+
+	var user types.User
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", c.GetString("user_id")).First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errUserNotFound))
+		return
+	}
+	if user.ID == types.DeletedUserID {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user"))
+		return
+	}
+
+	var identity types.Identity
+	err := db.Ctx(c.Request.Context()).Where("user_id = ? AND provider = ?", user.ID, types.ProviderEmail).First(&identity).Error
+	if err == nil {
+		if err := bcrypt.CompareHashAndPassword([]byte(identity.PasswordHash), []byte(req.Password)); err != nil {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidCredentials, "invalid credentials"))
+			return
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.Internal("failed to delete account", err))
+		return
+	}
+
+	// 退会手続き中はパスワード再設定等のトークンを使えないようにする
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := revokeTokens(tx, user.ID, ""); err != nil {
+			return err
+		}
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntry(c, audit.ActionUserDelete, audit.TargetUser, user.ID.String(),
+			gin.H{"grace_period": deletionGracePeriod().String()}))
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to delete account", err))
+		return
+	}
+
+	c.SetCookie("token", "", -1, "/", "", false, true)
+	respond(c, http.StatusOK, gin.H{
+		"message":               "account scheduled for deletion",
+		"deletion_scheduled_at": time.Now().Add(deletionGracePeriod()),
+	})
+}
+
+// restoreAccount は退会手続き中のユーザーがログインした場合に退会を取り消す
+func restoreAccount(tx *gorm.DB, user *types.User) error {
+	if !user.DeletedAt.Valid {
+		return nil
+	}
+	if err := tx.Unscoped().Model(user).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	slog.InfoContext(tx.Statement.Context, "account deletion cancelled", "user_id", user.ID)
+	return nil
+}
+
+// PurgeDeletedAccounts は猶予期間を過ぎた退会ユーザーのデータを保持方針に従って削除する（定期実行）
+func PurgeDeletedAccounts(ctx context.Context) error {
+	var users []types.User
+	if err := db.Ctx(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND id <> ?", time.Now().Add(-deletionGracePeriod()), types.DeletedUserID).
+		Find(&users).Error; err != nil {
+		return err
+	}
+
+	retention, err := retentionPolicy()
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := purgeAccount(ctx, user, retention); err != nil {
+			return fmt.Errorf("failed to purge user %s: %w", user.ID, err)
+		}
+		slog.InfoContext(ctx, "purged deleted account", "user_id", user.ID)
+	}
+	return nil
+}
+
+func purgeAccount(ctx context.Context, user types.User, retention map[string]string) error {
+	var blobs []types.Attachment
+	var exports []types.DataExport
+
+	err := db.SafeTransaction(ctx, func(tx *gorm.DB) error {
+		for _, targetType := range []string{targetPost, targetThread, targetEvent, targetComment} {
+			removed, err := purgeContent(tx, user.ID, targetType, retention[targetType])
+			if err != nil {
+				return err
+			}
+			blobs = append(blobs, removed...)
+		}
+
+		// いいねを取り消す
+		for _, like := range []struct{ table, content, column string }{
+			{"post_likes", "posts", "post_id"},
+			{"thread_likes", "threads", "thread_id"},
+			{"event_likes", "events", "event_id"},
+		} {
+			if err := tx.Exec(fmt.Sprintf(
+				`UPDATE %s SET "like" = GREATEST("like" - 1, 0) WHERE id IN (SELECT %s FROM %s WHERE user_id = ?)`,
+				like.content, like.column, like.table), user.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = ?", like.table), user.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		// 投稿に紐付いていない添付ファイル
+		var orphans []types.Attachment
+		if err := tx.Where("user_id = ?", user.ID).
+			Where("id NOT IN (?)", tx.Model(&types.ContentAttachment{}).Select("attachment_id")).
+			Find(&orphans).Error; err != nil {
+			return err
+		}
+		if len(orphans) > 0 {
+			if err := tx.Delete(&orphans).Error; err != nil {
+				return err
+			}
+			blobs = append(blobs, orphans...)
+		}
+		// 匿名化して残した投稿の添付ファイル
+		if err := tx.Model(&types.Attachment{}).Where("user_id = ?", user.ID).Update("user_id", types.DeletedUserID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", user.ID).Find(&exports).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&types.DataExport{}, &types.UserToken{}, &types.Identity{}, &types.FilterResult{}} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("reporter_id = ?", user.ID).Delete(&types.Report{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&user).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// 実ファイルはコミット後に削除する
+	deleteBlobs(ctx, blobs)
+	store := storage.Get()
+	if user.ImageKey != "" {
+		if err := store.Delete(ctx, user.ImageKey); err != nil {
+			slog.ErrorContext(ctx, "failed to delete avatar", "key", user.ImageKey, "error", err)
+		}
+	}
+	for _, export := range exports {
+		if export.Key == "" {
+			continue
+		}
+		if err := store.Delete(ctx, export.Key); err != nil {
+			slog.ErrorContext(ctx, "failed to delete export", "key", export.Key, "error", err)
+		}
+	}
+	return nil
+}
+
+// purgeContent はユーザーの投稿を「退会したユーザー」に付け替え、delete の場合は内容を消去して削除する
+// 削除した添付ファイルを返す
+func purgeContent(tx *gorm.DB, uid uuid.UUID, targetType, policy string) ([]types.Attachment, error) {
+	model, _ := contentModel(targetType)
+	var ids []uint
+	if err := tx.Unscoped().Model(model).Where("user_id = ?", uid).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	updates := map[string]interface{}{
+		"user_id":  types.DeletedUserID,
+		"username": types.DeletedUserName,
+	}
+	var removed []types.Attachment
+	if policy == retainDelete {
+		updates["content"] = ""
+		updates["tags"] = pq.StringArray{}
+		updates["lat"] = 0
+		updates["lng"] = 0
+		updates["deleted_at"] = gorm.Expr("COALESCE(deleted_at, ?)", time.Now())
+		for _, id := range ids {
+			attachments, err := unlinkAttachments(tx, targetType, id)
+			if err != nil {
+				return nil, err
+			}
+			removed = append(removed, attachments...)
+		}
+	}
+
+	if err := tx.Unscoped().Model(model).Where("id IN ?", ids).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// retentionPolicy は ACCOUNT_RETENTION（例: "posts=delete,comments=anonymize"）で既定の保持方針を上書きする
+func retentionPolicy() (map[string]string, error) {
+	policy := map[string]string{}
+	for k, v := range defaultRetention {
+		policy[k] = v
+	}
+
+	spec := appConfig.Account.Retention
+	if spec == "" {
+		return policy, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		targetType := strings.TrimSuffix(strings.TrimSpace(name), "s")
+		if _, known := policy[targetType]; !ok || !known || (value != retainAnonymize && value != retainDelete) {
+			return nil, fmt.Errorf("invalid ACCOUNT_RETENTION entry: %q", entry)
+		}
+		policy[targetType] = value
+	}
+	return policy, nil
+}
+
+// deletionGracePeriod は退会から削除までの猶予期間（ACCOUNT_DELETION_GRACE_DAYS で変更可能）
+func deletionGracePeriod() time.Duration {
+	return time.Duration(appConfig.Account.DeletionGraceDays) * 24 * time.Hour
+}
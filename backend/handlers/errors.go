@@ -0,0 +1,17 @@
+package handlers
+
+import "api/apierror"
+
+// 各ハンドラーで共通の 404
+var (
+	errUserNotFound     = apierror.NotFound(apierror.CodeUserNotFound, "user not found")
+	errPostNotFound     = apierror.NotFound(apierror.CodePostNotFound, "post not found")
+	errThreadNotFound   = apierror.NotFound(apierror.CodeThreadNotFound, "thread not found")
+	errEventNotFound    = apierror.NotFound(apierror.CodeEventNotFound, "event not found")
+	errReportNotFound   = apierror.NotFound(apierror.CodeReportNotFound, "report not found")
+	errExportNotFound   = apierror.NotFound(apierror.CodeExportNotFound, "export not found")
+	errIdentityNotFound = apierror.NotFound(apierror.CodeIdentityNotFound, "login method not found")
+)
+
+// errNotOwner は他のユーザーの投稿を編集・削除しようとした場合のエラー
+var errNotOwner = apierror.Forbidden(apierror.CodeForbidden, "you can only modify your own content")
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"api/apierror"
+	"api/audit"
+	"api/contentfilter"
 	"api/db"
 	"api/types"
 	"net/http"
@@ -13,32 +16,16 @@ import (
 )
 
 func GetAllEvents(c *gin.Context) {
-	var events []types.Event
-	var userCoordinate types.Coordinate
-	dbConn := db.SafeDB()
-
-	if err := c.ShouldBindJSON(&userCoordinate); err != nil {
-		// coordinateが提供されない場合、entertainment/disasterのみをDBから取得
-		if err := dbConn.Where("category IN (?)", []string{"entertainment", "disaster"}).Find(&events).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch events"})
-			return
-		}
-	} else {
-		// データベース側でカテゴリ別フィルタリング処理
-		if err := dbConn.Where(`
-            category IN ('entertainment', 'disaster') OR 
-            (category = 'community' AND 
-             lat BETWEEN ? AND ? AND 
-             lng BETWEEN ? AND ?)
-        `,
-			userCoordinate.Lat-types.AROUND, userCoordinate.Lat+types.AROUND,
-			userCoordinate.Lng-types.AROUND, userCoordinate.Lng+types.AROUND,
-		).Find(&events).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch events"})
-			return
-		}
+	listContent[types.Event](c, "events", bodyQuery(c))
+}
+
+// ListEvents handles GET /api/v2/events?lat=&lng=&radius=&since=
+func ListEvents(c *gin.Context) {
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
 	}
-	c.JSON(http.StatusOK, events)
+	listContent[types.Event](c, "events", q)
 }
 
 func EditEvent(c *gin.Context) {
@@ -46,116 +33,222 @@ func EditEvent(c *gin.Context) {
 	var event types.Event
 
 	// GORMでイベントを取得
-	result := db.SafeDB().Where("id = ?", id).First(&event)
+	result := db.Ctx(c.Request.Context()).Where("id = ?", id).First(&event)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errEventNotFound))
+		return
+	}
+
+	if event.UserID.String() != c.GetString("user_id") {
+		apierror.Abort(c, errNotOwner)
 		return
 	}
 
-	// リクエストボディから更新内容を取得
-	if err := c.ShouldBindJSON(&event); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json format"})
+	// 監査ログ用の変更前の内容
+	before := event.Response()
+
+	// 指定された項目のみ更新する（投稿者・表示状態等はリクエストから変更できない）
+	var req types.UpdateEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
 		return
 	}
+	req.Apply(&event)
+	cat, ok := resolveCategory(c, event.Category)
+	if !ok {
+		return
+	}
+	event.Category = cat.ID
+
+	// 変更後の内容をコンテンツフィルタにかけ、保留の場合は非表示にする
+	verdict, ok := screenContent(c, event.UserID, targetEvent, event.ID, event.Content, event.Tags)
+	if !ok {
+		return
+	}
+	applyCategoryPolicy(&verdict, cat)
+	if verdict.Decision == contentfilter.Hold {
+		event.Valid = false
+	}
 
 	// 更新日時を現在の時刻に設定
 	event.UpdatedAt = time.Now()
 
-	// GORMで更新
-	if err := db.SafeDB().Save(&event).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update event"})
+	// GORMで更新（attachment_ids が指定された場合は添付ファイルも置き換える）
+	var removed []types.Attachment
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Save(&event).Error; err != nil {
+			return err
+		}
+		if err := recordFilterResults(tx, event.UserID, targetEvent, event.ID, event.Content, verdict); err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetEvent, audit.OpUpdate, event.ID, before, event.Response(), attachmentDetail(event.AttachmentIDs)); err != nil {
+			return err
+		}
+		if event.AttachmentIDs != nil {
+			var err error
+			removed, err = replaceAttachments(tx, event.UserID, targetEvent, event.ID, event.AttachmentIDs)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		attachmentErrorResponse(c, err, "failed to update event")
 		return
 	}
+	deleteBlobs(c.Request.Context(), removed)
 
-	c.JSON(http.StatusOK, gin.H{"event": event})
+	if err := attachMedia(c.Request.Context(), &event); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"event": event.Response()})
 }
 
-// GetEvent handles GET /event/:id
+// GetEvent handles GET /api/v2/events/:id
 func GetEvent(c *gin.Context) {
 	id := c.Param("id")
 	var event types.Event
 
-	result := db.SafeDB().Where("id = ?", id).First(&event)
+	result := db.Ctx(c.Request.Context()).Scopes(db.Visible).Where("id = ?", id).First(&event)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errEventNotFound))
 		return
 	}
+	if err := attachMedia(c.Request.Context(), &event); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	obscureLocations(&event)
 
-	c.JSON(http.StatusOK, event)
+	respond(c, http.StatusOK, event.Response())
 }
 
 // CreateEvent handles POST /event
 func CreateEvent(c *gin.Context) {
-	var event types.Event
-	if err := c.ShouldBindJSON(&event); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json format"})
+	var req types.CreateEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	event := req.Event()
+	cat, ok := resolveCategory(c, event.Category)
+	if !ok {
 		return
 	}
+	event.Category = cat.ID
 	// JWT認証からuser_idを取得
 	userID := c.GetString("user_id")
 	uid, err := uuid.Parse(userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id format"})
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
 		return
 	}
 	var user types.User
-	if err := db.SafeDB().Where("id = ?", uid).Select("name").First(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user info"})
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", uid).Select("name, default_precision").First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to get user info", err))
 		return
 	}
 	event.Username = user.Name
 
+	precision, ok := resolvePrecision(event.Precision, user.DefaultPrecision)
+	if !ok {
+		apierror.Abort(c, apierror.Validation("precision", "invalid precision"))
+		return
+	}
+	event.Precision = precision
+
 	event.UserID = uid
-	db.SafeDB().Where("id = ?", uid).Select("username").Find(&event.Username)
-	result := db.SafeDB().Create(&event)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create event"})
+
+	// 保存前にコンテンツフィルタにかけ、保留の場合（確認が必要なカテゴリを含む）は非表示で保存する
+	verdict, ok := screenContent(c, uid, targetEvent, 0, event.Content, event.Tags)
+	if !ok {
+		return
+	}
+	applyCategoryPolicy(&verdict, cat)
+	event.Valid = verdict.Decision == contentfilter.Allow
+	// 添付ファイルの紐付けも同じトランザクションで行う
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		if err := recordFilterResults(tx, uid, targetEvent, event.ID, event.Content, verdict); err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetEvent, audit.OpCreate, event.ID, nil, event.Response(), attachmentDetail(event.AttachmentIDs)); err != nil {
+			return err
+		}
+		return linkAttachments(tx, uid, targetEvent, event.ID, event.AttachmentIDs)
+	})
+	if err != nil {
+		attachmentErrorResponse(c, err, "failed to create event")
+		return
+	}
+	if err := attachMedia(c.Request.Context(), &event); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated,
-		event,
-	)
+	respond(c, http.StatusCreated, event.Response())
 }
 
 func GetAroundAllEvent(c *gin.Context) {
 	var req types.Coordinate
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		apierror.Abort(c, apierror.Bind(err))
 		return
 	}
 	var events []types.Event
-	dbConn := db.SafeDB()
+	dbConn := db.Ctx(c.Request.Context()).Scopes(db.Visible)
 	if err := dbConn.Where("lat BETWEEN ? AND ? AND lng BETWEEN ? AND ?",
 		req.Lat-types.AROUND, req.Lat+types.AROUND,
 		req.Lng-types.AROUND, req.Lng+types.AROUND,
 	).Find(&events).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "no events found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch events"})
+		apierror.Abort(c, apierror.Internal("failed to fetch events", err))
 		return
 	}
-	c.JSON(http.StatusOK, events)
+	if err := attachMedia(c.Request.Context(), &events); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	obscureLocations(&events)
+	respond(c, http.StatusOK, types.Responses(events))
 }
 
 func DeleteEvent(c *gin.Context) {
 	id := c.Param("id")
 	var event types.Event
 
-	result := db.SafeDB().Where("id = ?", id).First(&event)
+	result := db.Ctx(c.Request.Context()).Where("id = ?", id).First(&event)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errEventNotFound))
+		return
+	}
+	if event.UserID.String() != c.GetString("user_id") {
+		apierror.Abort(c, errNotOwner)
 		return
 	}
 
-	if err := db.SafeDB().Delete(&event).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete event"})
+	// イベントの削除と同時に添付ファイルも削除する
+	var removed []types.Attachment
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Delete(&event).Error; err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetEvent, audit.OpDelete, event.ID, event.Response(), nil, nil); err != nil {
+			return err
+		}
+		var err error
+		removed, err = unlinkAttachments(tx, targetEvent, event.ID)
+		return err
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to delete event", err))
 		return
 	}
+	deleteBlobs(c.Request.Context(), removed)
 
-	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "event deleted"})
+	respond(c, http.StatusOK, gin.H{"message": "event deleted"})
 }
 
 func GetUpdateEvent(c *gin.Context) {
@@ -165,15 +258,21 @@ func GetUpdateEvent(c *gin.Context) {
 	// from を数値に変換（例：Unix timestamp）
 	fromTime, err := strconv.ParseInt(from, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'from' parameter"})
+		apierror.Abort(c, apierror.Validation("from", "invalid value"))
 		return
 	}
 	// 条件に合う投稿を取得（updated_at > from）
-	if err := db.SafeDB().
+	if err := db.Ctx(c.Request.Context()).
+		Scopes(db.Visible).
 		Where("updated_at > ?", time.Unix(fromTime, 0)).
 		Find(&events).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated events"})
+		apierror.Abort(c, apierror.Internal("failed to fetch updated events", err))
+		return
+	}
+	if err := attachMedia(c.Request.Context(), &events); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
 		return
 	}
-	c.JSON(http.StatusOK, events)
+	obscureLocations(&events)
+	respond(c, http.StatusOK, types.Responses(events))
 }
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/db"
+	"api/jobs"
+	"api/server"
+	"api/storage"
+	"api/types"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// 作成したエクスポートをダウンロードできる期間
+	exportTTL = 7 * 24 * time.Hour
+	// これより長く完了しないエクスポートは再起動等で失われたものとみなして作り直す
+	exportStaleAfter = 30 * time.Minute
+	// ダウンロードの最大時間（大きな ZIP は SERVER_WRITE_TIMEOUT 内に送りきれないことがある）
+	exportDownloadTimeout = 30 * time.Minute
+)
+
+// ExportData handles GET /me/export
+// 有効なエクスポートがあればその情報を返し、なければバックグラウンドで作成を始めて 202 を返す
+func ExportData(c *gin.Context) {
+	uid, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
+		return
+	}
+
+	var latest types.DataExport
+	err = db.Ctx(c.Request.Context()).Where("user_id = ?", uid).Order("created_at DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.Internal("failed to fetch export", err))
+		return
+	}
+	if err == nil {
+		switch {
+		case latest.Status == types.ExportReady && latest.ExpiresAt != nil && latest.ExpiresAt.After(time.Now()):
+			// リクエストと同じバージョンのダウンロード先を返す（/api/v1/me/export → /api/v1/me/export/download）
+			respond(c, http.StatusOK, gin.H{"export": latest, "download_url": c.FullPath() + "/download"})
+			return
+		case (latest.Status == types.ExportPending || latest.Status == types.ExportRunning) &&
+			time.Since(latest.CreatedAt) < exportStaleAfter:
+			respond(c, http.StatusAccepted, gin.H{"export": latest})
+			return
+		}
+	}
+
+	export := types.DataExport{UserID: uid, Status: types.ExportPending}
+	if err := db.Ctx(c.Request.Context()).Create(&export).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to create export", err))
+		return
+	}
+	id := export.ID
+	if err := jobs.Enqueue(fmt.Sprintf("export %d", id), func(ctx context.Context) error {
+		return runExport(ctx, id)
+	}); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to enqueue export", "export_id", id, "error", err)
+		failExport(c.Request.Context(), id, err)
+		apierror.Abort(c, apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "export is temporarily unavailable"))
+		return
+	}
+
+	respond(c, http.StatusAccepted, gin.H{"export": export})
+}
+
+// DownloadExport handles GET /me/export/download
+func DownloadExport(c *gin.Context) {
+	var export types.DataExport
+	if err := db.Ctx(c.Request.Context()).
+		Where("user_id = ? AND status = ? AND expires_at > ?", c.GetString("user_id"), types.ExportReady, time.Now()).
+		Order("created_at DESC").
+		First(&export).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errExportNotFound))
+		return
+	}
+
+	r, err := storage.Get().Open(c.Request.Context(), export.Key)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to read export", "error", err)
+		apierror.Abort(c, apierror.Internal("failed to read export", err))
+		return
+	}
+	defer r.Close()
+
+	if err := server.ExtendWriteDeadline(c.Writer, time.Now().Add(exportDownloadTimeout)); err != nil {
+		slog.WarnContext(c.Request.Context(), "failed to extend write deadline", "error", err)
+	}
+
+	filename := fmt.Sprintf("chap-export-%s.zip", export.CreatedAt.Format("20060102"))
+	c.DataFromReader(http.StatusOK, export.Size, "application/zip", r, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, filename),
+		"Cache-Control":       "no-store",
+	})
+}
+
+// PurgeExpiredExports は期限切れのエクスポートを削除する（定期実行）
+func PurgeExpiredExports(ctx context.Context) error {
+	var exports []types.DataExport
+	if err := db.Ctx(ctx).
+		Where("expires_at < ? OR (status = ? AND created_at < ?)", time.Now(), types.ExportFailed, time.Now().Add(-exportTTL)).
+		Find(&exports).Error; err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if export.Key != "" {
+			if err := storage.Get().Delete(ctx, export.Key); err != nil {
+				return err
+			}
+		}
+		if err := db.Ctx(ctx).Delete(&export).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runExport はユーザーのデータを JSON と画像にまとめた ZIP を作成して保存する
+func runExport(ctx context.Context, id uint) error {
+	var export types.DataExport
+	if err := db.Ctx(ctx).First(&export, id).Error; err != nil {
+		return err
+	}
+	if err := db.Ctx(ctx).Model(&export).Update("status", types.ExportRunning).Error; err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "export-*.zip")
+	if err != nil {
+		failExport(ctx, id, err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := writeExport(ctx, tmp, export.UserID); err != nil {
+		failExport(ctx, id, err)
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		failExport(ctx, id, err)
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		failExport(ctx, id, err)
+		return err
+	}
+
+	key := fmt.Sprintf("%s%s/%s.zip", storage.PrivatePrefix, export.UserID, uuid.New())
+	if err := storage.Get().Put(ctx, key, tmp, size, "application/zip"); err != nil {
+		failExport(ctx, id, err)
+		return err
+	}
+
+	now := time.Now()
+	expires := now.Add(exportTTL)
+	return db.Ctx(ctx).Model(&export).Updates(types.DataExport{
+		Status:      types.ExportReady,
+		Key:         key,
+		Size:        size,
+		CompletedAt: &now,
+		ExpiresAt:   &expires,
+	}).Error
+}
+
+func failExport(ctx context.Context, id uint, cause error) {
+	if err := db.Ctx(ctx).Model(&types.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status": types.ExportFailed,
+		"error":  cause.Error(),
+	}).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to mark export as failed", "export_id", id, "error", err)
+	}
+}
+
+// writeExport は ZIP を書き込む
+//
+//	profile.json     プロフィールとログイン方法
+//	posts.json 等    投稿・スレッド・イベント・コメント（非表示のものも含む）
+//	likes.json       いいねした投稿等のID
+//	reports.json     行った通報
+//	media/           アバターと添付画像
+func writeExport(ctx context.Context, w io.Writer, uid uuid.UUID) error {
+	zw := zip.NewWriter(w)
+	conn := db.Ctx(ctx)
+
+	var user types.User
+	if err := conn.Unscoped().Where("id = ?", uid).First(&user).Error; err != nil {
+		return err
+	}
+	user.Password = ""
+	var identities []types.Identity
+	if err := conn.Where("user_id = ?", uid).Find(&identities).Error; err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "profile.json", gin.H{"user": user, "identities": identities}); err != nil {
+		return err
+	}
+
+	var posts []types.Post
+	var threads []types.Thread
+	var events []types.Event
+	var comments []types.Comment
+	for _, item := range []struct {
+		name string
+		dest interface{}
+	}{
+		{"posts.json", &posts},
+		{"threads.json", &threads},
+		{"events.json", &events},
+		{"comments.json", &comments},
+	} {
+		if err := conn.Where("user_id = ?", uid).Order("created_at").Find(item.dest).Error; err != nil {
+			return err
+		}
+		if err := attachMedia(ctx, item.dest); err != nil {
+			return err
+		}
+		if err := writeJSON(zw, item.name, item.dest); err != nil {
+			return err
+		}
+	}
+
+	likes := map[string][]uint{}
+	for _, like := range []struct{ name, table, column string }{
+		{"posts", "post_likes", "post_id"},
+		{"threads", "thread_likes", "thread_id"},
+		{"events", "event_likes", "event_id"},
+	} {
+		var ids []uint
+		if err := conn.Table(like.table).Where("user_id = ?", uid).Pluck(like.column, &ids).Error; err != nil {
+			return err
+		}
+		likes[like.name] = ids
+	}
+	if err := writeJSON(zw, "likes.json", likes); err != nil {
+		return err
+	}
+
+	var reports []types.Report
+	if err := conn.Where("reporter_id = ?", uid).Order("created_at").Find(&reports).Error; err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "reports.json", reports); err != nil {
+		return err
+	}
+
+	keys := []string{}
+	if user.ImageKey != "" {
+		keys = append(keys, user.ImageKey)
+	}
+	var attachments []types.Attachment
+	if err := conn.Where("user_id = ?", uid).Find(&attachments).Error; err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		keys = append(keys, a.Key)
+	}
+	for _, key := range keys {
+		if err := copyBlob(ctx, zw, key); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// copyBlob は保存先のファイルを media/ 配下にコピーする（見つからないファイルは飛ばす）
+func copyBlob(ctx context.Context, zw *zip.Writer, key string) error {
+	r, err := storage.Get().Open(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "skipping missing blob", "key", key, "error", err)
+		return nil
+	}
+	defer r.Close()
+
+	f, err := zw.Create(path.Join("media", key))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
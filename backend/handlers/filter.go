@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/contentfilter"
+	"api/db"
+	"api/types"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// screenContent は保存前にコンテンツフィルタを実行する（targetID は編集の場合のみ指定し、新規は 0）
+// 拒否した場合は判定を記録して 422 を返し、false を返す
+func screenContent(c *gin.Context, uid uuid.UUID, targetType string, targetID uint, content string, tags []string) (contentfilter.Verdict, bool) {
+	verdict := contentfilter.Get().Run(c.Request.Context(), contentfilter.Input{
+		UserID:     uid,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Content:    content,
+		Tags:       tags,
+	})
+	if verdict.Decision != contentfilter.Reject {
+		return verdict, true
+	}
+
+	if err := recordFilterResults(db.Ctx(c.Request.Context()), uid, targetType, 0, content, verdict); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to record filter result", err))
+		return verdict, false
+	}
+	reason := verdict.Results[len(verdict.Results)-1].Reason
+	apierror.Abort(c, apierror.New(http.StatusUnprocessableEntity, apierror.CodeContentRejected, "content rejected").
+		WithDetails(apierror.FieldError{Field: "content", Message: reason}))
+	return verdict, false
+}
+
+// recordFilterResults は Allow 以外の判定をモデレーター向けに記録する
+func recordFilterResults(tx *gorm.DB, uid uuid.UUID, targetType string, targetID uint, content string, verdict contentfilter.Verdict) error {
+	if len(verdict.Results) == 0 {
+		return nil
+	}
+	rows := make([]types.FilterResult, len(verdict.Results))
+	for i, r := range verdict.Results {
+		rows[i] = types.FilterResult{
+			UserID:     uid,
+			TargetType: targetType,
+			TargetID:   targetID,
+			Filter:     r.Filter,
+			Decision:   r.Decision.String(),
+			Reason:     r.Reason,
+			Content:    content,
+		}
+	}
+	return tx.Create(&rows).Error
+}
+
+// ListFilterResults handles GET /moderation/filter-results?decision=hold
+func ListFilterResults(c *gin.Context) {
+	decision := c.DefaultQuery("decision", contentfilter.Hold.String())
+	if decision != contentfilter.Hold.String() && decision != contentfilter.Reject.String() {
+		apierror.Abort(c, apierror.Validation("decision", "invalid value"))
+		return
+	}
+	page, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	var total int64
+	var results []types.FilterResult
+	if err := db.Ctx(c.Request.Context()).Model(&types.FilterResult{}).Where("decision = ?", decision).Count(&total).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch filter results", err))
+		return
+	}
+	if err := db.Ctx(c.Request.Context()).
+		Where("decision = ?", decision).
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&results).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch filter results", err))
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"items": results,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/audit"
+	"api/db"
+	"api/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// errAccountExists は未確認のアカウントに Google アカウントを自動で紐付けようとした場合のエラー
+	errAccountExists = apierror.Conflict(apierror.CodeAccountExists, "an account with this email already exists; log in with your password and link Google from your settings")
+	// errIdentityConflict は既に他のユーザー、または同じ種類のログイン方法が紐付いている場合のエラー
+	errIdentityConflict = apierror.Conflict(apierror.CodeIdentityConflict, "login method is already linked")
+)
+
+// googleProfile は userinfo エンドポイントの応答
+type googleProfile struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+type LinkGoogleRequest struct {
+	AccessToken string `json:"access_token" binding:"required"`
+}
+
+// ListIdentities handles GET /me/identities
+func ListIdentities(c *gin.Context) {
+	var identities []types.Identity
+	if err := db.Ctx(c.Request.Context()).Where("user_id = ?", c.GetString("user_id")).Order("created_at").Find(&identities).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch login methods", err))
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"identities": identities})
+}
+
+// LinkGoogle handles POST /me/identities/google
+func LinkGoogle(c *gin.Context) {
+	var req LinkGoogleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	uid, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
+		return
+	}
+
+	profile, ok := verifyGoogleToken(c, req.AccessToken)
+	if !ok {
+		return
+	}
+
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := linkGoogleIdentity(tx, uid, profile); err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntry(c, audit.ActionUserLink, audit.TargetUser, uid.String(), gin.H{"provider": types.ProviderGoogle}))
+	})
+	if errors.Is(err, errIdentityConflict) {
+		apierror.Abort(c, err)
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to link google account", err))
+		return
+	}
+
+	respond(c, http.StatusCreated, gin.H{"message": "google account linked"})
+}
+
+// UnlinkIdentity handles DELETE /me/identities/:provider
+// ログインできなくならないよう、最後のログイン方法は解除できない
+func UnlinkIdentity(c *gin.Context) {
+	provider := c.Param("provider")
+	if provider != types.ProviderEmail && provider != types.ProviderGoogle {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid provider"))
+		return
+	}
+	userID := c.GetString("user_id")
+
+	var count int64
+	var removed int64
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Model(&types.Identity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count <= 1 {
+			return nil
+		}
+		result := tx.Where("user_id = ? AND provider = ?", userID, provider).Delete(&types.Identity{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+		if removed == 0 {
+			return nil
+		}
+		return audit.Record(tx, auditEntry(c, audit.ActionUserUnlink, audit.TargetUser, userID, gin.H{"provider": provider}))
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to unlink login method", err))
+		return
+	}
+	if count <= 1 {
+		apierror.Abort(c, apierror.Conflict(apierror.CodeLastLoginMethod, "cannot remove the last login method"))
+		return
+	}
+	if removed == 0 {
+		apierror.Abort(c, errIdentityNotFound)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"message": "login method unlinked"})
+}
+
+// linkGoogleIdentity はユーザーに Google のログイン方法を追加する
+func linkGoogleIdentity(tx *gorm.DB, uid uuid.UUID, profile googleProfile) error {
+	var conflicts int64
+	if err := tx.Model(&types.Identity{}).
+		Where("(provider = ? AND subject = ?) OR (user_id = ? AND provider = ?)",
+			types.ProviderGoogle, profile.Sub, uid, types.ProviderGoogle).
+		Count(&conflicts).Error; err != nil {
+		return err
+	}
+	if conflicts > 0 {
+		return errIdentityConflict
+	}
+
+	now := time.Now()
+	return tx.Create(&types.Identity{
+		UserID:     uid,
+		Provider:   types.ProviderGoogle,
+		Subject:    profile.Sub,
+		Email:      profile.Email,
+		LastUsedAt: &now,
+	}).Error
+}
+
+// verifyGoogleToken はアクセストークンを Google に問い合わせて検証する
+// 失敗した場合はレスポンスを書き込んで false を返す
+func verifyGoogleToken(c *gin.Context, accessToken string) (googleProfile, bool) {
+	profile, err := fetchGoogleProfile(c.Request.Context(), accessToken)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to verify google token", "error", err)
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidToken, "invalid google access token"))
+		return profile, false
+	}
+	if profile.Sub == "" || profile.Email == "" || !profile.EmailVerified {
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeEmailNotVerified, "google account email is not verified"))
+		return profile, false
+	}
+	return profile, true
+}
+
+func fetchGoogleProfile(ctx context.Context, accessToken string) (googleProfile, error) {
+	var profile googleProfile
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, appConfig.Auth.GoogleUserInfoURL, nil)
+	if err != nil {
+		return profile, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return profile, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return profile, fmt.Errorf("google userinfo returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return profile, err
+	}
+	return profile, nil
+}
+
+// googleDisplayName は新規登録時の表示名を決める
+func googleDisplayName(profile googleProfile, requested string) string {
+	if profile.Name != "" {
+		return profile.Name
+	}
+	if requested != "" {
+		return requested
+	}
+	name, _, _ := strings.Cut(profile.Email, "@")
+	return name
+}
+
+// emailSubject はメールアドレスのログイン方法の識別子（大文字・小文字を区別しない）
+func emailSubject(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// touchIdentity はログイン方法の最終使用日時を更新する
+func touchIdentity(ctx context.Context, id uint) {
+	if err := db.Ctx(ctx).Model(&types.Identity{}).Where("id = ?", id).Update("last_used_at", time.Now()).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to update identity", "identity_id", id, "error", err)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/category"
+	"api/db"
+	"api/types"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contentQuery は投稿・スレッド・イベントの一覧の条件（v1 と v2 で共通）
+type contentQuery struct {
+	// nil の場合は全国のカテゴリのみ
+	At *types.Coordinate
+	// 地域のカテゴリの表示範囲（m）。0 の場合は各カテゴリの半径
+	RadiusMeters int
+	// ゼロ値でない場合はこの日時より後に作成・更新されたもののみ
+	Since time.Time
+}
+
+// listContent は条件に合う投稿等を返す
+// 全国のカテゴリは常に、地域のカテゴリは指定された座標の周辺のみ取得する
+func listContent[M interface{ Response() R }, R any](c *gin.Context, name string, q contentQuery) {
+	tx := db.Ctx(c.Request.Context()).Scopes(db.Visible, category.Get().Scope(q.At, q.RadiusMeters))
+	if !q.Since.IsZero() {
+		tx = tx.Where("updated_at > ? OR created_at > ?", q.Since, q.Since)
+	}
+
+	var items []M
+	if err := tx.Find(&items).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch "+name, err))
+		return
+	}
+	if err := attachMedia(c.Request.Context(), &items); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	obscureLocations(&items)
+	respond(c, http.StatusOK, types.Responses(items))
+}
+
+// bodyQuery は v1 の一覧（POST /getall/...）のボディの座標を読み取る
+// 座標が無い・不正な場合は全国のカテゴリのみにする
+func bodyQuery(c *gin.Context) contentQuery {
+	var at types.Coordinate
+	if err := c.ShouldBindJSON(&at); err != nil {
+		return contentQuery{}
+	}
+	return contentQuery{At: &at}
+}
+
+// bindListQuery は v2 の一覧のクエリ（?lat=&lng=&radius=&since=）を読み取る
+func bindListQuery(c *gin.Context) (contentQuery, bool) {
+	var req types.ListQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return contentQuery{}, false
+	}
+	if (req.Lat == nil) != (req.Lng == nil) {
+		apierror.Abort(c, apierror.Validation("lat", "lat and lng must be specified together"))
+		return contentQuery{}, false
+	}
+	if req.Radius > 0 && req.Lat == nil {
+		apierror.Abort(c, apierror.Validation("radius", "requires lat and lng"))
+		return contentQuery{}, false
+	}
+
+	q := contentQuery{RadiusMeters: req.Radius}
+	if req.Lat != nil {
+		q.At = &types.Coordinate{Lat: *req.Lat, Lng: *req.Lng}
+	}
+	if req.Since > 0 {
+		q.Since = time.Unix(req.Since, 0)
+	}
+	return q, true
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"api/geo"
+	"api/types"
+	"fmt"
+)
+
+// resolvePrecision は投稿時に指定された精度を検証し、未指定ならユーザーの既定値を使う
+func resolvePrecision(requested, userDefault string) (string, bool) {
+	if requested == "" {
+		requested = userDefault
+	}
+	if requested == "" {
+		requested = geo.DefaultPrecision
+	}
+	return requested, geo.ValidPrecision(requested)
+}
+
+// obscureLocations は公開レスポンス用に投稿者が選んだ精度で座標をぼかす
+// DB上の正確な座標は範囲検索のためにそのまま残る
+// dest には *types.Post / *[]types.Post など各投稿種別のポインタを渡す
+func obscureLocations(dest interface{}) {
+	switch v := dest.(type) {
+	case *types.Post:
+		v.Coordinate = fuzzCoordinate(v.Coordinate, v.Precision, targetPost, v.ID)
+	case *types.Thread:
+		v.Coordinate = fuzzCoordinate(v.Coordinate, v.Precision, targetThread, v.ID)
+	case *types.Event:
+		v.Coordinate = fuzzCoordinate(v.Coordinate, v.Precision, targetEvent, v.ID)
+	case *types.Comment:
+		v.Coordinate = fuzzCoordinate(v.Coordinate, v.Precision, targetComment, v.ID)
+	case *[]types.Post:
+		for i := range *v {
+			obscureLocations(&(*v)[i])
+		}
+	case *[]types.Thread:
+		for i := range *v {
+			obscureLocations(&(*v)[i])
+		}
+	case *[]types.Event:
+		for i := range *v {
+			obscureLocations(&(*v)[i])
+		}
+	case *[]types.Comment:
+		for i := range *v {
+			obscureLocations(&(*v)[i])
+		}
+	}
+}
+
+func fuzzCoordinate(c types.Coordinate, precision, targetType string, id uint) types.Coordinate {
+	lat, lng := geo.Fuzz(c.Lat, c.Lng, precision, fmt.Sprintf("%s:%d", targetType, id))
+	return types.Coordinate{Lat: lat, Lng: lng}
+}
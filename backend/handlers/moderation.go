@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/audit"
+	"api/db"
+	"api/types"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var errContentNotFound = apierror.NotFound(apierror.CodeContentNotFound, "content not found")
+
+type ReportRequest struct {
+	TargetType string `json:"target_type" binding:"required,oneof=post thread event comment"`
+	TargetID   uint   `json:"target_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required,max=1000"`
+}
+
+// CreateReport handles POST /report
+// 一定数のユーザーから通報された投稿はモデレーターの確認を待たずに非表示にする
+func CreateReport(c *gin.Context) {
+	var req ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+
+	uid, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
+		return
+	}
+
+	model, _ := contentModel(req.TargetType)
+	var exists int64
+	if err := db.Ctx(c.Request.Context()).Model(model).Scopes(db.Visible).Where("id = ?", req.TargetID).Count(&exists).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to create report", err))
+		return
+	}
+	if exists == 0 {
+		apierror.Abort(c, errContentNotFound.WithMessage("%s not found", req.TargetType))
+		return
+	}
+
+	var duplicated int64
+	db.Ctx(c.Request.Context()).Model(&types.Report{}).
+		Where("reporter_id = ? AND target_type = ? AND target_id = ?", uid, req.TargetType, req.TargetID).
+		Count(&duplicated)
+	if duplicated > 0 {
+		apierror.Abort(c, apierror.Conflict(apierror.CodeAlreadyReported, "already reported"))
+		return
+	}
+
+	report := types.Report{
+		ReporterID: uid,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Reason:     req.Reason,
+		Status:     types.ReportOpen,
+	}
+	hidden := false
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+		entry := auditEntry(c, audit.ActionReportCreate, audit.TargetReport, strconv.FormatUint(uint64(report.ID), 10), nil)
+		entry.After = report
+		if err := audit.Record(tx, entry); err != nil {
+			return err
+		}
+
+		var reporters int64
+		if err := tx.Model(&types.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", req.TargetType, req.TargetID, types.ReportOpen).
+			Distinct("reporter_id").
+			Count(&reporters).Error; err != nil {
+			return err
+		}
+		if reporters >= int64(autoHideThreshold()) {
+			hidden = true
+			// 自動の処理のため操作したユーザーは記録しない（通報者は直前の report.create に記録している）
+			entry := auditEntry(c, "", "", "", gin.H{"reason": "report_threshold", "reporters": reporters, "report_id": report.ID})
+			entry.Actor = uuid.Nil
+			return setContentValid(tx, entry, req.TargetType, req.TargetID, false)
+		}
+		return nil
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to create report", err))
+		return
+	}
+
+	respond(c, http.StatusCreated, gin.H{"report": report, "hidden": hidden})
+}
+
+// ListReports handles GET /moderation/reports?status=open
+func ListReports(c *gin.Context) {
+	status := c.DefaultQuery("status", types.ReportOpen)
+	if status != types.ReportOpen && status != types.ReportActioned && status != types.ReportDismissed {
+		apierror.Abort(c, apierror.Validation("status", "invalid value"))
+		return
+	}
+	page, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	var total int64
+	var reports []types.Report
+	if err := db.Ctx(c.Request.Context()).Model(&types.Report{}).Where("status = ?", status).Count(&total).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch reports", err))
+		return
+	}
+	if err := db.Ctx(c.Request.Context()).
+		Where("status = ?", status).
+		Order("created_at ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&reports).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch reports", err))
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"items": reports,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// DismissReport handles POST /moderation/reports/:id/dismiss
+func DismissReport(c *gin.Context) {
+	moderatorID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
+		return
+	}
+
+	var report types.Report
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", c.Param("id")).First(&report).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errReportNotFound))
+		return
+	}
+	if report.Status != types.ReportOpen {
+		apierror.Abort(c, apierror.Conflict(apierror.CodeAlreadyResolved, "report already resolved"))
+		return
+	}
+
+	now := time.Now()
+	before := report
+	report.Status = types.ReportDismissed
+	report.ResolvedBy = &moderatorID
+	report.ResolvedAt = &now
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Save(&report).Error; err != nil {
+			return err
+		}
+		entry := auditEntry(c, audit.ActionReportDismiss, audit.TargetReport, strconv.FormatUint(uint64(report.ID), 10), nil)
+		entry.Before, entry.After = before, report
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to update report", err))
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{"report": report})
+}
+
+// HideContent handles POST /moderation/content/:type/:id/hide
+// 対象を非表示にし、未対応の通報を対応済みにする
+func HideContent(c *gin.Context) {
+	moderateContent(c, false, types.ReportActioned)
+}
+
+// RestoreContent handles POST /moderation/content/:type/:id/restore
+// 対象を再表示し、未対応の通報は却下扱いにする
+func RestoreContent(c *gin.Context) {
+	moderateContent(c, true, types.ReportDismissed)
+}
+
+func moderateContent(c *gin.Context, valid bool, resolution string) {
+	moderatorID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
+		return
+	}
+	targetType := c.Param("type")
+	if _, ok := contentModel(targetType); !ok {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid content type"))
+		return
+	}
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid id"))
+		return
+	}
+
+	var resolved int64
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		result := tx.Model(&types.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, types.ReportOpen).
+			Updates(map[string]interface{}{
+				"status":      resolution,
+				"resolved_by": moderatorID,
+				"resolved_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		resolved = result.RowsAffected
+		return setContentValid(tx, auditEntry(c, "", "", "", gin.H{"resolved_reports": resolved}), targetType, uint(targetID), valid)
+	})
+	if errors.Is(err, errContentNotFound) {
+		apierror.Abort(c, errContentNotFound.WithMessage("%s not found", targetType))
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to update "+targetType, err))
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"target_type":      targetType,
+		"target_id":        targetID,
+		"valid":            valid,
+		"resolved_reports": resolved,
+	})
+}
+
+// setContentValid は投稿・スレッド・イベント・コメントの表示状態を切り替え、entry に操作と変更前後の表示状態を設定して監査ログに記録する
+func setContentValid(tx *gorm.DB, entry audit.Entry, targetType string, id uint, valid bool) error {
+	model, ok := contentModel(targetType)
+	if !ok {
+		return errContentNotFound
+	}
+	var previous []bool
+	if err := tx.Model(model).Where("id = ?", id).Limit(1).Pluck("valid", &previous).Error; err != nil {
+		return err
+	}
+	if len(previous) == 0 {
+		return errContentNotFound
+	}
+	if err := tx.Model(model).Where("id = ?", id).Update("valid", valid).Error; err != nil {
+		return err
+	}
+
+	op := audit.OpHide
+	if valid {
+		op = audit.OpRestore
+	}
+	entry.Action = audit.ContentAction(targetType, op)
+	entry.TargetType = targetType
+	entry.TargetID = strconv.FormatUint(uint64(id), 10)
+	entry.Before = gin.H{"valid": previous[0]}
+	entry.After = gin.H{"valid": valid}
+	return audit.Record(tx, entry)
+}
+
+// contentModel は投稿種別に対応するモデルを返す
+func contentModel(targetType string) (interface{}, bool) {
+	switch targetType {
+	case targetPost:
+		return &types.Post{}, true
+	case targetThread:
+		return &types.Thread{}, true
+	case targetEvent:
+		return &types.Event{}, true
+	case targetComment:
+		return &types.Comment{}, true
+	}
+	return nil, false
+}
+
+// autoHideThreshold は自動非表示にする通報者数（REPORT_AUTO_HIDE_THRESHOLD で変更可能）
+func autoHideThreshold() int {
+	return appConfig.Moderation.ReportAutoHideThreshold
+}
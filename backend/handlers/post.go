@@ -1,15 +1,19 @@
 package handlers
 
 import (
+	"api/apierror"
+	"api/audit"
+	"api/contentfilter"
 	"api/db"
 	"api/types"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func EditPost(c *gin.Context) {
@@ -17,87 +21,169 @@ func EditPost(c *gin.Context) {
 	var post types.Post
 
 	// GORMで投稿を取得
-	result := db.SafeDB().First(&post, id)
+	result := db.Ctx(c.Request.Context()).Where("id = ?", id).First(&post)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errPostNotFound))
 		return
 	}
 
-	// リクエストボディから更新内容を取得
-	if err := c.ShouldBindJSON(&post); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json format"})
+	if post.UserID.String() != c.GetString("user_id") {
+		apierror.Abort(c, errNotOwner)
 		return
 	}
 
+	// 監査ログ用の変更前の内容
+	before := post.Response()
+
+	// 指定された項目のみ更新する（投稿者・表示状態等はリクエストから変更できない）
+	var req types.UpdatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	req.Apply(&post)
+	cat, ok := resolveCategory(c, post.Category)
+	if !ok {
+		return
+	}
+	post.Category = cat.ID
+
+	// 変更後の内容をコンテンツフィルタにかけ、保留の場合は非表示にする
+	verdict, ok := screenContent(c, post.UserID, targetPost, post.ID, post.Content, post.Tags)
+	if !ok {
+		return
+	}
+	applyCategoryPolicy(&verdict, cat)
+	if verdict.Decision == contentfilter.Hold {
+		post.Valid = false
+	}
+
 	// 更新日時を現在の時刻に設定
 	post.UpdatedAt = time.Now()
 
-	// GORMで更新
-	if err := db.SafeDB().Save(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update post"})
+	// GORMで更新（attachment_ids が指定された場合は添付ファイルも置き換える）
+	var removed []types.Attachment
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Save(&post).Error; err != nil {
+			return err
+		}
+		if err := recordFilterResults(tx, post.UserID, targetPost, post.ID, post.Content, verdict); err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetPost, audit.OpUpdate, post.ID, before, post.Response(), attachmentDetail(post.AttachmentIDs)); err != nil {
+			return err
+		}
+		if post.AttachmentIDs != nil {
+			var err error
+			removed, err = replaceAttachments(tx, post.UserID, targetPost, post.ID, post.AttachmentIDs)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		attachmentErrorResponse(c, err, "failed to update post")
 		return
 	}
+	deleteBlobs(c.Request.Context(), removed)
 
-	c.JSON(http.StatusOK, gin.H{"post": post})
+	if err := attachMedia(c.Request.Context(), &post); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"post": post.Response()})
 }
 
 // CreatePost handles POST /post
 func CreatePost(c *gin.Context) {
-	var post types.Post
-	log.Printf("[CreatePost] Received request")
-
-	if err := c.ShouldBindJSON(&post); err != nil {
-		log.Printf("[CreatePost] JSON bind error: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json format"})
+	var req types.CreatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.InfoContext(c.Request.Context(), "invalid post request", "error", err)
+		apierror.Abort(c, apierror.Bind(err))
 		return
 	}
-	log.Printf("[CreatePost] Parsed post data: %+v", post)
+	post := req.Post()
+	cat, ok := resolveCategory(c, post.Category)
+	if !ok {
+		return
+	}
+	post.Category = cat.ID
 
 	// JWT認証からuser_idを取得
 	userID := c.GetString("user_id")
-	log.Printf("[CreatePost] User ID from JWT: %s", userID)
 
 	uid, err := uuid.Parse(userID)
 	if err != nil {
-		log.Printf("[CreatePost] UUID parse error: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id format"})
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
 		return
 	}
 	var user types.User
-	if err := db.SafeDB().Where("id = ?", uid).Select("name").First(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user info"})
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", uid).Select("name, default_precision").First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to get user info", err))
 		return
 	}
 	post.Username = user.Name
 
+	precision, ok := resolvePrecision(post.Precision, user.DefaultPrecision)
+	if !ok {
+		apierror.Abort(c, apierror.Validation("precision", "invalid precision"))
+		return
+	}
+	post.Precision = precision
+
 	post.UserID = uid
-	post.ID = 0 // 自動インクリメント用に0に設定
-	log.Printf("[CreatePost] Final post data before save: %+v", post)
 
-	// GORMでSupabaseのPostgreSQLに保存
-	result := db.SafeDB().Create(&post)
-	if result.Error != nil {
-		log.Printf("[CreatePost] Database save error: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create post"})
+	// 保存前にコンテンツフィルタにかけ、保留の場合（確認が必要なカテゴリを含む）は非表示で保存する
+	verdict, ok := screenContent(c, uid, targetPost, 0, post.Content, post.Tags)
+	if !ok {
 		return
 	}
+	applyCategoryPolicy(&verdict, cat)
+	post.Valid = verdict.Decision == contentfilter.Allow
 
-	log.Printf("[CreatePost] Post created successfully with ID: %d", post.ID)
-	c.JSON(http.StatusCreated, post)
+	// GORMでSupabaseのPostgreSQLに保存（添付ファイルの紐付けも同じトランザクションで行う）
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if err := recordFilterResults(tx, uid, targetPost, post.ID, post.Content, verdict); err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetPost, audit.OpCreate, post.ID, nil, post.Response(), attachmentDetail(post.AttachmentIDs)); err != nil {
+			return err
+		}
+		return linkAttachments(tx, uid, targetPost, post.ID, post.AttachmentIDs)
+	})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to create post", "error", err)
+		attachmentErrorResponse(c, err, "failed to create post")
+		return
+	}
+	if err := attachMedia(c.Request.Context(), &post); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "post created", "post_id", post.ID)
+	respond(c, http.StatusCreated, post.Response())
 }
 
-// GetPost handles GET /post/:id
+// GetPost handles GET /api/v2/posts/:id
 func GetPost(c *gin.Context) {
 	id := c.Param("id")
 	var post types.Post
 
-	result := db.SafeDB().First(&post, id)
+	result := db.Ctx(c.Request.Context()).Scopes(db.Visible).Where("id = ?", id).First(&post)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errPostNotFound))
 		return
 	}
+	if err := attachMedia(c.Request.Context(), &post); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	obscureLocations(&post)
 
-	c.JSON(http.StatusOK, post)
+	respond(c, http.StatusOK, post.Response())
 }
 
 func GetUpdatePost(c *gin.Context) {
@@ -107,64 +193,72 @@ func GetUpdatePost(c *gin.Context) {
 	// from を数値に変換（例：Unix timestamp）
 	fromTime, err := strconv.ParseInt(from, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'from' parameter"})
+		apierror.Abort(c, apierror.Validation("from", "invalid value"))
 		return
 	}
 
 	// 条件に合う投稿を取得（updated_at > from）
-	if err := db.SafeDB().
+	if err := db.Ctx(c.Request.Context()).
+		Scopes(db.Visible).
 		Where("updated_at > ? or created_at > ?", time.Unix(fromTime, 0), time.Unix(fromTime, 0)).
 		Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated posts"})
+		apierror.Abort(c, apierror.Internal("failed to fetch updated posts", err))
+		return
+	}
+	if err := attachMedia(c.Request.Context(), &posts); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
 		return
 	}
+	obscureLocations(&posts)
 
-	c.JSON(http.StatusOK, posts)
+	respond(c, http.StatusOK, types.Responses(posts))
 }
 
 func DeletePost(c *gin.Context) {
 	id := c.Param("id")
 	var post types.Post
 
-	result := db.GetDB().First(&post, id)
+	result := db.Ctx(c.Request.Context()).Where("id = ?", id).First(&post)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errPostNotFound))
+		return
+	}
+	if post.UserID.String() != c.GetString("user_id") {
+		apierror.Abort(c, errNotOwner)
 		return
 	}
 
-	if err := db.GetDB().Delete(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete post"})
+	// 投稿の削除と同時に添付ファイルも削除する
+	var removed []types.Attachment
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Delete(&post).Error; err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetPost, audit.OpDelete, post.ID, post.Response(), nil, nil); err != nil {
+			return err
+		}
+		var err error
+		removed, err = unlinkAttachments(tx, targetPost, post.ID)
+		return err
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to delete post", err))
 		return
 	}
+	deleteBlobs(c.Request.Context(), removed)
 
-	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "post deleted"})
+	respond(c, http.StatusOK, gin.H{"message": "post deleted"})
 }
 
 func GetAllPosts(c *gin.Context) {
-	var posts []types.Post
-	var userCoordinate types.Coordinate
-	dbConn := db.SafeDB()
-
-	if err := c.ShouldBindJSON(&userCoordinate); err != nil {
-		// coordinateが提供されない場合、entertainment/disasterのみをDBから取得
-		if err := dbConn.Where("category IN (?)", []string{"entertainment", "disaster"}).Find(&posts).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch posts"})
-			return
-		}
-	} else {
-		// データベース側でカテゴリ別フィルタリング処理
-		if err := dbConn.Where(`
-            category IN ('entertainment', 'disaster') OR 
-            (category = 'community' AND 
-             lat BETWEEN ? AND ? AND 
-             lng BETWEEN ? AND ?)
-        `,
-			userCoordinate.Lat-types.AROUND, userCoordinate.Lat+types.AROUND,
-			userCoordinate.Lng-types.AROUND, userCoordinate.Lng+types.AROUND,
-		).Find(&posts).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch posts"})
-			return
-		}
+	listContent[types.Post](c, "posts", bodyQuery(c))
+}
+
+// ListPosts handles GET /api/v2/posts?lat=&lng=&radius=&since=
+func ListPosts(c *gin.Context) {
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
 	}
-	c.JSON(http.StatusOK, posts)
+	listContent[types.Post](c, "posts", q)
 }
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"api/apierror"
+	"api/audit"
+	"api/db"
+	"api/geo"
+	"api/imageproc"
+	"api/storage"
+	"api/types"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAvatarBytes = 5 << 20 // 5MB
+	avatarSize     = 256
+)
+
+type UpdateProfileRequest struct {
+	Name     *string `json:"name" binding:"omitempty,max=50"`
+	Bio      *string `json:"bio" binding:"omitempty,max=500"`
+	HomeArea *string `json:"home_area" binding:"omitempty,max=100"`
+	// 投稿時の座標の公開精度の既定値（exact / neighborhood / city）
+	DefaultPrecision *string `json:"default_precision"`
+}
+
+// UpdateProfile handles PUT /me
+// 名前を変更した場合は投稿等に複製されている username も同じトランザクションで書き換える
+func UpdateProfile(c *gin.Context) {
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+
+	uid, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
+		return
+	}
+
+	var user types.User
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", uid).First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errUserNotFound))
+		return
+	}
+
+	before := user.AdminResponse(time.Now())
+	updates := map[string]interface{}{}
+	renamed := false
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			apierror.Abort(c, apierror.Validation("name", "must not be empty"))
+			return
+		}
+		if name != user.Name {
+			user.Name = name
+			updates["name"] = name
+			renamed = true
+		}
+	}
+	if req.Bio != nil {
+		user.Bio = strings.TrimSpace(*req.Bio)
+		updates["bio"] = user.Bio
+	}
+	if req.HomeArea != nil {
+		user.HomeArea = strings.TrimSpace(*req.HomeArea)
+		updates["home_area"] = user.HomeArea
+	}
+	if req.DefaultPrecision != nil {
+		if !geo.ValidPrecision(*req.DefaultPrecision) {
+			apierror.Abort(c, apierror.Validation("default_precision", "invalid precision"))
+			return
+		}
+		user.DefaultPrecision = *req.DefaultPrecision
+		updates["default_precision"] = user.DefaultPrecision
+	}
+
+	if len(updates) > 0 {
+		err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+			if err := tx.Model(&types.User{}).Where("id = ?", uid).Updates(updates).Error; err != nil {
+				return err
+			}
+			entry := auditEntry(c, audit.ActionUserUpdate, audit.TargetUser, uid.String(), nil)
+			entry.Before, entry.After = before, user.AdminResponse(time.Now())
+			if err := audit.Record(tx, entry); err != nil {
+				return err
+			}
+			if renamed {
+				return renameAuthor(tx, uid, user.Name)
+			}
+			return nil
+		})
+		if err != nil {
+			apierror.Abort(c, apierror.Internal("failed to update profile", err))
+			return
+		}
+	}
+
+	user.Password = ""
+	respond(c, http.StatusOK, gin.H{"user": user})
+}
+
+// renameAuthor は投稿・スレッド・イベント・コメントに複製された投稿者名を更新する
+func renameAuthor(tx *gorm.DB, uid uuid.UUID, name string) error {
+	for _, model := range []interface{}{&types.Post{}, &types.Thread{}, &types.Event{}, &types.Comment{}} {
+		if err := tx.Unscoped().Model(model).Where("user_id = ?", uid).Update("username", name).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UploadAvatar handles POST /me/avatar (multipart/form-data, field "avatar")
+// 画像は正方形に切り抜いて縮小し、再エンコードで EXIF を除去してから保存する
+func UploadAvatar(c *gin.Context) {
+	uid, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAvatarBytes+(1<<20))
+	file, header, err := c.Request.FormFile("avatar")
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "avatar file is required"))
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxAvatarBytes {
+		apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "avatar is too large"))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxAvatarBytes+1))
+	if err != nil || len(data) > maxAvatarBytes {
+		apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "avatar is too large"))
+		return
+	}
+
+	img, format, err := imageproc.Decode(data)
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMediaType, "unsupported image"))
+		return
+	}
+	avatar, err := imageproc.Encode(imageproc.Square(img, avatarSize), format)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to process image", err))
+		return
+	}
+
+	var user types.User
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", uid).First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errUserNotFound))
+		return
+	}
+
+	store := storage.Get()
+	key := fmt.Sprintf("avatars/%s/%s%s", uid, uuid.New(), imageproc.Extension(avatar.ContentType))
+	ctx := c.Request.Context()
+	if err := store.Put(ctx, key, bytes.NewReader(avatar.Data), int64(len(avatar.Data)), avatar.ContentType); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to store avatar", "error", err)
+		apierror.Abort(c, apierror.Internal("failed to store avatar", err))
+		return
+	}
+
+	oldKey, oldImage := user.ImageKey, user.Image
+	user.Image = store.URL(key)
+	user.ImageKey = key
+	err = db.SafeTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(&types.User{}).Where("id = ?", uid).Updates(map[string]interface{}{
+			"image":     user.Image,
+			"image_key": user.ImageKey,
+		}).Error; err != nil {
+			return err
+		}
+		entry := auditEntry(c, audit.ActionUserAvatar, audit.TargetUser, uid.String(), nil)
+		entry.Before, entry.After = gin.H{"image": oldImage}, gin.H{"image": user.Image}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		_ = store.Delete(ctx, key)
+		apierror.Abort(c, apierror.Internal("failed to update avatar", err))
+		return
+	}
+
+	// 古いアバター画像は不要になるので削除（失敗してもレスポンスには影響させない）
+	if oldKey != "" {
+		if err := store.Delete(ctx, oldKey); err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to delete old avatar", "key", oldKey, "error", err)
+		}
+	}
+
+	user.Password = ""
+	respond(c, http.StatusOK, gin.H{"user": user})
+}
@@ -0,0 +1,9 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// respond は成功時のレスポンスを {"data": ...} の形で書き込む
+// エラーは apierror.Abort で返す（apierror.Middleware が {"error": {...}} の形で書き込む）
+func respond(c *gin.Context, status int, data interface{}) {
+	c.JSON(status, gin.H{"data": data})
+}
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"api/apierror"
 	"api/db"
+	"api/telemetry"
 	"api/types"
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
-	"os"
 	"regexp"
 	"sync"
 	"time"
@@ -29,19 +31,24 @@ func GetSocialSensingHeatmap(c *gin.Context) {
 
 	// キャッシュが有効ならそれを返す
 	if time.Since(lastUpdate) < cacheDuration && cachedHeatmapJSON != "" {
-		c.Data(http.StatusOK, "application/json", []byte(cachedHeatmapJSON))
+		telemetry.HeatmapCacheHit()
+		respond(c, http.StatusOK, json.RawMessage(cachedHeatmapJSON))
 		return
 	}
 
+	telemetry.HeatmapCacheMiss()
+
 	// DBから取得
 	var posts []types.Post
-	if err := db.SafeDB().Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch posts"})
+	if err := db.Ctx(c.Request.Context()).Scopes(db.Visible).Find(&posts).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch posts", err))
 		return
 	}
 
 	// Geminiに投げる
 	var heatmap []types.HeatmapPoint
+	// 外部サービスに渡すため、公開時と同じ精度にぼかした座標を使う
+	obscureLocations(&posts)
 	for _, p := range posts {
 		heatmap = append(heatmap, types.HeatmapPoint{
 			Lat:   p.Coordinate.Lat,
@@ -49,9 +56,9 @@ func GetSocialSensingHeatmap(c *gin.Context) {
 			Value: 1,
 		})
 	}
-	summary, geojson, err := getGeminiHeatmapSummaryAndGeoJSON(heatmap)
+	summary, geojson, err := getGeminiHeatmapSummaryAndGeoJSON(c.Request.Context(), heatmap)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "gemini error"})
+		apierror.Abort(c, apierror.Internal("gemini error", err))
 		return
 	}
 
@@ -63,15 +70,16 @@ func GetSocialSensingHeatmap(c *gin.Context) {
 	respBytes, _ := json.Marshal(response)
 	cachedHeatmapJSON = string(respBytes)
 	lastUpdate = time.Now()
-	println("Updated heatmap cache at", respBytes)
+	slog.InfoContext(c.Request.Context(), "heatmap cache updated", "points", len(heatmap))
 
-	c.Data(http.StatusOK, "application/json", respBytes)
+	respond(c, http.StatusOK, json.RawMessage(respBytes))
 }
 
-func getGeminiHeatmapSummaryAndGeoJSON(heatmap []types.HeatmapPoint) (string, map[string]interface{}, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
+func getGeminiHeatmapSummaryAndGeoJSON(ctx context.Context, heatmap []types.HeatmapPoint) (string, map[string]interface{}, error) {
+	apiKey := appConfig.Gemini.APIKey
 	if apiKey == "" {
-		return "", nil, nil
+		// 未設定の場合は空の FeatureCollection を返す（geojson を null にしない）
+		return "", map[string]interface{}{"type": "FeatureCollection", "features": []interface{}{}}, nil
 	}
 	prompt := `
 以下の投稿データから、ヒートマップ表示に適したGeoJSON FeatureCollection形式のJSONを生成してください。
@@ -92,15 +100,19 @@ func getGeminiHeatmapSummaryAndGeoJSON(heatmap []types.HeatmapPoint) (string, ma
 	data, _ := json.Marshal(heatmap)
 	prompt += "投稿データ: " + string(data)
 
-	ctx := context.Background()
 	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
 	if err != nil {
 		return "", nil, err
 	}
 	defer client.Close()
 
-	model := client.GenerativeModel("gemini-2.0-flash-001")
-	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	modelName := appConfig.Gemini.Model
+	model := client.GenerativeModel(modelName)
+	var resp *genai.GenerateContentResponse
+	err = telemetry.ObserveLLM(ctx, "heatmap", modelName, func(ctx context.Context) error {
+		resp, err = model.GenerateContent(ctx, genai.Text(prompt))
+		return err
+	})
 	if err != nil {
 		return "", nil, err
 	}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"api/apierror"
+	"api/audit"
+	"api/contentfilter"
 	"api/db"
 	"api/types"
 	"net/http"
@@ -9,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func EditThread(c *gin.Context) {
@@ -16,64 +20,129 @@ func EditThread(c *gin.Context) {
 	var thread types.Thread
 
 	// GORMでスレッドを取得
-	result := db.SafeDB().Where("id = ?", id).First(&thread)
+	result := db.Ctx(c.Request.Context()).Where("id = ?", id).First(&thread)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errThreadNotFound))
 		return
 	}
 
-	// リクエストボディから更新内容を取得
-	if err := c.ShouldBindJSON(&thread); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json format"})
+	if thread.UserID.String() != c.GetString("user_id") {
+		apierror.Abort(c, errNotOwner)
 		return
 	}
 
+	// 監査ログ用の変更前の内容
+	before := thread.Response()
+
+	// 指定された項目のみ更新する（投稿者・表示状態等はリクエストから変更できない）
+	var req types.UpdateThreadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	req.Apply(&thread)
+	cat, ok := resolveCategory(c, thread.Category)
+	if !ok {
+		return
+	}
+	thread.Category = cat.ID
+
+	// 変更後の内容をコンテンツフィルタにかけ、保留の場合は非表示にする
+	verdict, ok := screenContent(c, thread.UserID, targetThread, thread.ID, thread.Content, thread.Tags)
+	if !ok {
+		return
+	}
+	applyCategoryPolicy(&verdict, cat)
+	if verdict.Decision == contentfilter.Hold {
+		thread.Valid = false
+	}
+
 	// 更新日時を現在の時刻に設定
 	thread.UpdatedAt = time.Now()
 
-	// GORMで更新
-	if err := db.SafeDB().Save(&thread).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update thread"})
+	// GORMで更新（attachment_ids が指定された場合は添付ファイルも置き換える）
+	var removed []types.Attachment
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Save(&thread).Error; err != nil {
+			return err
+		}
+		if err := recordFilterResults(tx, thread.UserID, targetThread, thread.ID, thread.Content, verdict); err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetThread, audit.OpUpdate, thread.ID, before, thread.Response(), attachmentDetail(thread.AttachmentIDs)); err != nil {
+			return err
+		}
+		if thread.AttachmentIDs != nil {
+			var err error
+			removed, err = replaceAttachments(tx, thread.UserID, targetThread, thread.ID, thread.AttachmentIDs)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		attachmentErrorResponse(c, err, "failed to update thread")
 		return
 	}
+	deleteBlobs(c.Request.Context(), removed)
 
-	c.JSON(http.StatusOK, gin.H{"thread": thread})
+	if err := attachMedia(c.Request.Context(), &thread); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"thread": thread.Response()})
 }
 
-// GetThread handles GET /thread/:id
+// GetThread handles GET /api/v2/threads/:id
 func GetThread(c *gin.Context) {
 	id := c.Param("id")
 	var thread types.Thread
 
-	result := db.SafeDB().Where("id = ?", id).First(&thread)
+	result := db.Ctx(c.Request.Context()).Scopes(db.Visible).Where("id = ?", id).First(&thread)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errThreadNotFound))
 		return
 	}
+	if err := attachMedia(c.Request.Context(), &thread); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	obscureLocations(&thread)
 
-	c.JSON(http.StatusOK, thread)
+	respond(c, http.StatusOK, thread.Response())
 }
 
 // CreateThread handles POST /thread
 func CreateThread(c *gin.Context) {
-	var thread types.Thread
-	if err := c.ShouldBindJSON(&thread); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json format"})
+	var req types.CreateThreadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Bind(err))
+		return
+	}
+	thread := req.Thread()
+	cat, ok := resolveCategory(c, thread.Category)
+	if !ok {
 		return
 	}
+	thread.Category = cat.ID
 
 	// JWT認証からuser_idを取得
 	userID := c.GetString("user_id")
 	uid, err := uuid.Parse(userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id format"})
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user_id format"))
 		return
 	}
 
-	// ThreadのUserIDを設定（他のフィールドはリクエストから取得）
+	// ThreadのUserIDを設定
 	thread.UserID = uid
-	thread.ID = 0       // 自動インクリメント用に0に設定
-	thread.Valid = true // デフォルトで有効に設定
+
+	// 保存前にコンテンツフィルタにかけ、保留の場合（確認が必要なカテゴリを含む）は非表示で保存する
+	verdict, ok := screenContent(c, uid, targetThread, 0, thread.Content, thread.Tags)
+	if !ok {
+		return
+	}
+	applyCategoryPolicy(&verdict, cat)
+	thread.Valid = verdict.Decision == contentfilter.Allow
 	// 明示的に現在時刻を設定（gormタグと併用で確実に）
 	if thread.CreatedAt.IsZero() {
 		thread.CreatedAt = time.Now()
@@ -83,77 +152,87 @@ func CreateThread(c *gin.Context) {
 	}
 	// UsersテーブルからユーザーIDに該当するusernameを取得
 	var user types.User
-	if err := db.SafeDB().Where("id = ?", uid).Select("name").First(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user info"})
+	if err := db.Ctx(c.Request.Context()).Where("id = ?", uid).Select("name, default_precision").First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to get user info", err))
 		return
 	}
 	thread.Username = user.Name
 
-	// GORMでSupabaseのPostgreSQLに保存
-	result := db.SafeDB().Create(&thread)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create thread"})
+	precision, ok := resolvePrecision(thread.Precision, user.DefaultPrecision)
+	if !ok {
+		apierror.Abort(c, apierror.Validation("precision", "invalid precision"))
+		return
+	}
+	thread.Precision = precision
+
+	// GORMでSupabaseのPostgreSQLに保存（添付ファイルの紐付けも同じトランザクションで行う）
+	err = db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Create(&thread).Error; err != nil {
+			return err
+		}
+		if err := recordFilterResults(tx, uid, targetThread, thread.ID, thread.Content, verdict); err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetThread, audit.OpCreate, thread.ID, nil, thread.Response(), attachmentDetail(thread.AttachmentIDs)); err != nil {
+			return err
+		}
+		return linkAttachments(tx, uid, targetThread, thread.ID, thread.AttachmentIDs)
+	})
+	if err != nil {
+		attachmentErrorResponse(c, err, "failed to create thread")
+		return
+	}
+	if err := attachMedia(c.Request.Context(), &thread); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, thread)
+	respond(c, http.StatusCreated, thread.Response())
 }
 
 // GetThreadDetails returns a thread with its replies (comments)
 // GET /thread/:id/details
 func GetThreadDetails(c *gin.Context) {
-	id := c.Param("id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid thread id"))
+		return
+	}
 
 	// Fetch thread
 	var thread types.Thread
-	if err := db.SafeDB().Where("id = ?", id).First(&thread).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+	if err := db.Ctx(c.Request.Context()).Scopes(db.Visible).Where("id = ?", id).First(&thread).Error; err != nil {
+		apierror.Abort(c, apierror.Lookup(err, errThreadNotFound))
 		return
 	}
-
-	// Fetch replies (comments) by foreign key
-	var replies []types.Comment
-	// If conversion to uint is needed for safety
-	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid thread id"})
+	if err := attachMedia(c.Request.Context(), &thread); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
 		return
 	}
-	if err := db.SafeDB().Where("thread_id = ?", id).Order("created_at ASC").Find(&replies).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load replies"})
+	obscureLocations(&thread)
+
+	replies, err := loadReplies(c.Request.Context(), uint(id))
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load replies", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"thread":  thread,
-		"replies": replies,
+	respond(c, http.StatusOK, gin.H{
+		"thread":  thread.Response(),
+		"replies": types.Responses(replies),
 	})
 }
 func GetAllThreads(c *gin.Context) {
-	var threads []types.Thread
-	var userCoordinate types.Coordinate
-	dbConn := db.SafeDB()
-	if err := c.ShouldBindJSON(&userCoordinate); err != nil {
-		// coordinateが提供されない場合、entertainment/disasterのみをDBから取得
-		if err := dbConn.Where("category IN (?)", []string{"entertainment", "disaster"}).Find(&threads).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch threads"})
-			return
-		}
-	} else {
-		// データベース側でカテゴリ別フィルタリング処理
-		if err := dbConn.Where(`
-            category IN ('entertainment', 'disaster') OR 
-            (category = 'community' AND 
-             lat BETWEEN ? AND ? AND 
-             lng BETWEEN ? AND ?)
-        `,
-			userCoordinate.Lat-types.AROUND, userCoordinate.Lat+types.AROUND,
-			userCoordinate.Lng-types.AROUND, userCoordinate.Lng+types.AROUND,
-		).Find(&threads).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch posts"})
-			return
-		}
+	listContent[types.Thread](c, "threads", bodyQuery(c))
+}
+
+// ListThreads handles GET /api/v2/threads?lat=&lng=&radius=&since=
+func ListThreads(c *gin.Context) {
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
 	}
-	c.JSON(http.StatusOK, threads)
+	listContent[types.Thread](c, "threads", q)
 }
 func GetUpdateThread(c *gin.Context) {
 	from := c.Param("from")
@@ -162,34 +241,58 @@ func GetUpdateThread(c *gin.Context) {
 	// from を数値に変換（例：Unix timestamp）
 	fromTime, err := strconv.ParseInt(from, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'from' parameter"})
+		apierror.Abort(c, apierror.Validation("from", "invalid value"))
 		return
 	}
 
 	// 条件に合う投稿を取得（updated_at > from）
-	if err := db.SafeDB().
+	if err := db.Ctx(c.Request.Context()).
+		Scopes(db.Visible).
 		Where("updated_at > ?", time.Unix(fromTime, 0)).
 		Find(&threads).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated threads"})
+		apierror.Abort(c, apierror.Internal("failed to fetch updated threads", err))
+		return
+	}
+	if err := attachMedia(c.Request.Context(), &threads); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
 		return
 	}
+	obscureLocations(&threads)
 
-	c.JSON(http.StatusOK, threads)
+	respond(c, http.StatusOK, types.Responses(threads))
 }
 func DeleteThread(c *gin.Context) {
 	id := c.Param("id")
 	var thread types.Thread
 
-	result := db.SafeDB().Where("id = ?", id).First(&thread)
+	result := db.Ctx(c.Request.Context()).Where("id = ?", id).First(&thread)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errThreadNotFound))
+		return
+	}
+	if thread.UserID.String() != c.GetString("user_id") {
+		apierror.Abort(c, errNotOwner)
 		return
 	}
 
-	if err := db.SafeDB().Delete(&thread).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete thread"})
+	// スレッドの削除と同時に添付ファイルも削除する
+	var removed []types.Attachment
+	err := db.SafeTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+		if err := tx.Delete(&thread).Error; err != nil {
+			return err
+		}
+		if err := recordContentChange(tx, c, targetThread, audit.OpDelete, thread.ID, thread.Response(), nil, nil); err != nil {
+			return err
+		}
+		var err error
+		removed, err = unlinkAttachments(tx, targetThread, thread.ID)
+		return err
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to delete thread", err))
 		return
 	}
+	deleteBlobs(c.Request.Context(), removed)
 
-	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "thread deleted"})
+	respond(c, http.StatusOK, gin.H{"message": "thread deleted"})
 }
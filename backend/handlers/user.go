@@ -1,26 +1,170 @@
 package handlers
 
 import (
+	"api/apierror"
 	"api/db"
 	"api/types"
+	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
 )
 
 // GetUserByID handles GET /user/:id
+// メールアドレス等を含まない公開プロフィールを返す
 func GetUserByID(c *gin.Context) {
-	idStr := c.Param("id")
+	uid, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
 
 	var user types.User
-	result := db.SafeDB().Where("id = ?", idStr).First(&user)
+	result := db.Ctx(c.Request.Context()).Where("id = ? AND valid = ?", uid, true).First(&user)
 	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		apierror.Abort(c, apierror.Lookup(result.Error, errUserNotFound))
 		return
 	}
 
-	// パスワードをレスポンスから除外
-	user.Password = ""
+	stats, err := getUserStats(c.Request.Context(), uid)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch user stats", err))
+		return
+	}
+
+	respond(c, http.StatusOK, types.UserProfile{
+		ID:        user.ID,
+		Name:      user.Name,
+		Avatar:    user.Image,
+		Bio:       user.Bio,
+		HomeArea:  user.HomeArea,
+		CreatedAt: user.CreatedAt,
+		Stats:     stats,
+	})
+}
+
+// GetUserPosts handles GET /user/:id/posts
+func GetUserPosts(c *gin.Context) {
+	listUserContent[types.Post](c, "posts")
+}
+
+// GetUserThreads handles GET /user/:id/threads
+func GetUserThreads(c *gin.Context) {
+	listUserContent[types.Thread](c, "threads")
+}
+
+// GetUserEvents handles GET /user/:id/events
+func GetUserEvents(c *gin.Context) {
+	listUserContent[types.Event](c, "events")
+}
 
-	c.JSON(http.StatusOK, user)
+// GetUserComments handles GET /user/:id/comments
+func GetUserComments(c *gin.Context) {
+	listUserContent[types.Comment](c, "comments")
+}
+
+// listUserContent は指定ユーザーの投稿を新しい順にページングして返す
+func listUserContent[M interface{ Response() R }, R any](c *gin.Context, name string) {
+	uid, ok := parseUserIDParam(c)
+	if !ok {
+		return
+	}
+	page, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	var (
+		items []M
+		total int64
+	)
+	if err := db.Ctx(c.Request.Context()).Model(new(M)).Scopes(db.Visible).Where("user_id = ?", uid).Count(&total).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch "+name, err))
+		return
+	}
+	if err := db.Ctx(c.Request.Context()).
+		Scopes(db.Visible).
+		Where("user_id = ?", uid).
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("failed to fetch "+name, err))
+		return
+	}
+	if err := attachMedia(c.Request.Context(), &items); err != nil {
+		apierror.Abort(c, apierror.Internal("failed to load attachments", err))
+		return
+	}
+	obscureLocations(&items)
+
+	respond(c, http.StatusOK, gin.H{
+		"items": types.Responses(items),
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// getUserStats はユーザーの投稿数と獲得いいね数を集計する
+func getUserStats(ctx context.Context, uid uuid.UUID) (types.UserStats, error) {
+	var stats types.UserStats
+	// チェーンは Count の度に条件が積み重なるため、集計毎に新しく作る
+	if err := db.Ctx(ctx).Model(&types.Post{}).Scopes(db.Visible).Where("user_id = ?", uid).Count(&stats.Posts).Error; err != nil {
+		return stats, err
+	}
+	if err := db.Ctx(ctx).Model(&types.Thread{}).Scopes(db.Visible).Where("user_id = ?", uid).Count(&stats.Threads).Error; err != nil {
+		return stats, err
+	}
+	if err := db.Ctx(ctx).Model(&types.Event{}).Scopes(db.Visible).Where("user_id = ?", uid).Count(&stats.Events).Error; err != nil {
+		return stats, err
+	}
+	if err := db.Ctx(ctx).Raw(`
+        SELECT COALESCE(SUM("like"), 0) FROM (
+            SELECT "like" FROM posts WHERE user_id = ? AND valid AND deleted_at IS NULL
+            UNION ALL
+            SELECT "like" FROM threads WHERE user_id = ? AND valid AND deleted_at IS NULL
+            UNION ALL
+            SELECT "like" FROM events WHERE user_id = ? AND valid AND deleted_at IS NULL
+            UNION ALL
+            SELECT "like" FROM comments WHERE user_id = ? AND valid AND deleted_at IS NULL
+        ) AS received
+    `, uid, uid, uid, uid).Scan(&stats.LikesReceived).Error; err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+func parseUserIDParam(c *gin.Context) (uuid.UUID, bool) {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRequest, "invalid user id"))
+		return uuid.Nil, false
+	}
+	return uid, true
+}
+
+// parsePagination は ?page=&limit= を読み取る（page は1始まり）
+func parsePagination(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		apierror.Abort(c, apierror.Validation("page", "invalid value"))
+		return 0, 0, false
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+	if err != nil || limit < 1 {
+		apierror.Abort(c, apierror.Validation("limit", "invalid value"))
+		return 0, 0, false
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return page, limit, true
 }
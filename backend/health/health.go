@@ -0,0 +1,136 @@
+package health
+
+import (
+	"api/logging"
+	"api/server"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 1つのチェックの最大時間
+const checkTimeout = 2 * time.Second
+
+// チェック結果の状態
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+	// 任意の依存先に問題があるが、リクエストは受け付けられる
+	StatusDegraded = "degraded"
+	StatusShutdown = "shutting_down"
+)
+
+// CheckFunc は依存先を確認し、問題があればエラーを返す
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	required bool
+	fn       CheckFunc
+}
+
+// Result は1つのチェックの結果
+type Result struct {
+	Status   string `json:"status"`
+	Required bool   `json:"required"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report は /readyz のレスポンス
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+var (
+	mu     sync.RWMutex
+	checks []check
+)
+
+// Register はチェックを追加する
+// required が true のチェックが失敗すると /readyz は 503 を返し、false の場合は degraded として 200 を返す
+func Register(name string, required bool, fn CheckFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	checks = append(checks, check{name: name, required: required, fn: fn})
+}
+
+// Run は全てのチェックを並行に実行する
+func Run(ctx context.Context) Report {
+	mu.RLock()
+	list := append([]check(nil), checks...)
+	mu.RUnlock()
+
+	results := make([]Result, len(list))
+	var wg sync.WaitGroup
+	for i, ch := range list {
+		wg.Add(1)
+		go func(i int, ch check) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, ch)
+		}(i, ch)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: map[string]Result{}}
+	for i, ch := range list {
+		r := results[i]
+		report.Checks[ch.name] = r
+		if r.Status == StatusOK {
+			continue
+		}
+		if ch.required {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	// 終了処理中はロードバランサーから外されるよう、チェックの結果に関わらず失敗とする
+	select {
+	case <-server.Draining():
+		report.Status = StatusShutdown
+	default:
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, ch check) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	result = Result{Status: StatusOK, Required: ch.required}
+	defer func() {
+		if r := recover(); r != nil {
+			result.Status, result.Error = StatusFail, "check panicked"
+		}
+		result.Duration = time.Since(start).String()
+	}()
+
+	if err := ch.fn(ctx); err != nil {
+		result.Status, result.Error = StatusFail, logging.Scrub(err.Error())
+	}
+	return result
+}
+
+// Livez handles GET /livez
+// プロセスが応答できるかだけを返す（依存先の障害で再起動されないよう、外部には問い合わせない）
+func Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// Readyz handles GET /readyz
+// 依存先のチェック結果を返す。必須のチェックが失敗している場合と終了処理中は 503
+func Readyz(c *gin.Context) {
+	report := Run(c.Request.Context())
+	code := http.StatusOK
+	if report.Status == StatusFail || report.Status == StatusShutdown {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, report)
+}
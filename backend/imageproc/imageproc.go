@@ -0,0 +1,138 @@
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var ErrUnsupportedType = errors.New("unsupported image type")
+
+// 受け付ける画像の MIME タイプ
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// 最大ピクセル数（巨大画像によるメモリ枯渇を防ぐ）
+const maxPixels = 40_000_000
+
+// Image は処理済みの画像データ
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Sniff はファイル先頭のバイト列から MIME タイプを判定する（クライアント申告の Content-Type は信用しない）
+func Sniff(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// IsAllowed は MIME タイプが受付可能な画像形式か判定する
+func IsAllowed(contentType string) bool {
+	return allowedTypes[contentType]
+}
+
+// Decode は画像をデコードし、EXIF の向き情報を画素に反映する
+func Decode(data []byte) (image.Image, string, error) {
+	if !IsAllowed(Sniff(data)) {
+		return nil, "", ErrUnsupportedType
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedType
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", errors.New("image dimensions too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedType
+	}
+
+	// メタデータは再エンコードで失われるため、向きだけは先に画素へ反映する
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// Fit は長辺が maxSize を超える場合に縦横比を保って縮小する
+func Fit(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+	if w >= h {
+		h = h * maxSize / w
+		w = maxSize
+	} else {
+		w = w * maxSize / h
+		h = maxSize
+	}
+	return scale(img, b, max(w, 1), max(h, 1))
+}
+
+// Square は中央を正方形に切り抜いて size×size に縮小する（アバター用）
+func Square(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	return scale(img, image.Rect(x0, y0, x0+side, y0+side), size, size)
+}
+
+// Encode は画像を再エンコードする。PNG/GIF は透過を保つため PNG、それ以外は JPEG にする。
+// 元ファイルの EXIF（位置情報を含む）などのメタデータは出力に含まれない。
+func Encode(img image.Image, format string) (*Image, error) {
+	var buf bytes.Buffer
+	contentType := "image/jpeg"
+
+	switch format {
+	case "png", "gif":
+		contentType = "image/png"
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	default:
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+	}
+
+	b := img.Bounds()
+	return &Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Width:       b.Dx(),
+		Height:      b.Dy(),
+	}, nil
+}
+
+// Extension は MIME タイプに対応する拡張子を返す
+func Extension(contentType string) string {
+	if contentType == "image/png" {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+func scale(img image.Image, src image.Rectangle, w, h int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Over, nil)
+	return dst
+}
@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// 1つのジョブの最大実行時間
+const jobTimeout = 30 * time.Minute
+
+// Func はバックグラウンドで実行する処理
+type Func func(ctx context.Context) error
+
+var (
+	// ErrQueueFull はキューが一杯でジョブを受け付けられない場合のエラー
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrNotStarted は Start の前に Enqueue した場合のエラー
+	ErrNotStarted = errors.New("job runner is not started")
+)
+
+type job struct {
+	name string
+	fn   Func
+}
+
+var (
+	queue chan job
+	wg    sync.WaitGroup
+)
+
+// Start は workers 個のワーカーを起動する。ctx がキャンセルされると新しいジョブの取り出しをやめる
+// キューはプロセス内のみのため、再起動時に未実行のジョブは失われる（呼び出し側で再実行できるようにする）
+func Start(ctx context.Context, workers, size int) {
+	queue = make(chan job, size)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-queue:
+					run(ctx, j)
+				}
+			}
+		}()
+	}
+	slog.Info("Job runner started", "workers", workers)
+}
+
+// Wait は全てのワーカーと定期実行の終了を待つ
+func Wait() {
+	wg.Wait()
+}
+
+// Enqueue はジョブをキューに追加する（ブロックしない）
+func Enqueue(name string, fn Func) error {
+	if queue == nil {
+		return ErrNotStarted
+	}
+	select {
+	case queue <- job{name: name, fn: fn}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Every は interval 毎に fn を実行する（起動直後にも1回実行する）
+func Every(ctx context.Context, interval time.Duration, name string, fn Func) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			run(ctx, job{name: name, fn: fn})
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// run はジョブを実行し、エラーや panic をログに記録する
+func run(ctx context.Context, j job) {
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+			}
+		}()
+		return j.fn(ctx)
+	}()
+	if err != nil {
+		slog.ErrorContext(ctx, "job failed", "job", j.name, "elapsed", time.Since(start), "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "job finished", "job", j.name, "elapsed", time.Since(start))
+}
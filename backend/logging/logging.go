@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"api/config"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type ctxKey struct{}
+
+// Setup は設定に応じて既定のロガーを設定する
+// log パッケージの出力も同じロガーに流れる
+func Setup(cfg config.Log) error {
+	logger, err := New(os.Stdout, cfg.Level, cfg.Format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// New はリクエストIDの付与と機密情報の伏せ字化を行うロガーを作成する
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lv slog.Level
+	if level != "" {
+		if err := lv.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL: %s", level)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: lv, ReplaceAttr: redactAttr}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT: %s", format)
+	}
+	return slog.New(&contextHandler{Handler: h}), nil
+}
+
+// WithRequestID はリクエストIDを ctx に設定する
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// RequestID は ctx のリクエストIDを返す
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// contextHandler は ctx のリクエストIDをログに付与し、メッセージ中の機密情報を伏せる
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	// メッセージはキーを持たないため、値のパターンで伏せ字にする
+	msg := Scrub(r.Message)
+	if msg != r.Message {
+		nr := slog.NewRecord(r.Time, r.Level, msg, r.PC)
+		r.Attrs(func(a slog.Attr) bool {
+			nr.AddAttrs(a)
+			return true
+		})
+		r = nr
+	}
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,72 @@
+package mailer
+
+import (
+	"api/config"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"time"
+)
+
+// Message は送信するメール（本文はプレーンテキスト）
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer はメールの送信先を抽象化する
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var mailer Mailer
+
+// Initialize は cfg.Driver（file: MAIL_DIR に保存 / smtp）に応じて送信方法を初期化する
+func Initialize(cfg config.Mail) error {
+	driver := cfg.Driver
+
+	var err error
+	switch driver {
+	case "file":
+		mailer, err = NewFileMailer(cfg.Dir, cfg.From)
+	case "smtp":
+		mailer, err = NewSMTPMailer(SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		})
+	default:
+		return fmt.Errorf("unknown MAIL_DRIVER: %s", driver)
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Mailer initialized", "driver", driver)
+	return nil
+}
+
+// Get returns the configured Mailer
+func Get() Mailer {
+	return mailer
+}
+
+// render は RFC 5322 形式のメッセージを組み立てる
+func render(from string, msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return b.Bytes()
+}
@@ -1,83 +1,430 @@
 package main
 
 import (
+	"api/apierror"
+	"api/audit"
+	"api/bulk"
+	"api/category"
+	"api/config"
+	"api/contentfilter"
 	"api/db"
+	"api/handlers"
+	"api/health"
+	"api/jobs"
+	"api/logging"
+	"api/mailer"
+	"api/middleware"
+	"api/ratelimit"
 	"api/routes"
+	"api/seed"
+	"api/server"
+	"api/storage"
+	"api/telemetry"
+	"api/types"
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
+	"log/slog"
 	"os"
-	"strings"
 	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
 func main() {
+	// 設定の読み込み（既定値 < config.yaml < .env・環境変数）
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// docker の HEALTHCHECK 用: api healthcheck [URL]
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		url := server.HealthURL(cfg.Server)
+		if len(os.Args) > 2 {
+			url = os.Args[2]
+		}
+		if err := health.Probe(url); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := logging.Setup(cfg.Log); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	slog.Info("Configuration loaded", "env", cfg.Env, "files", cfg.Files())
+
 	// データベース初期化
-	if err := db.Initialize(); err != nil {
+	if err := db.Initialize(cfg.Database); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	// Ginエンジンの作成
-	r := gin.Default()
+	// カテゴリの読み込み（未登録の場合は既定のカテゴリを登録する）
+	if err := category.Initialize(context.Background()); err != nil {
+		log.Fatalf("Failed to initialize categories: %v", err)
+	}
+
+	// 管理用のサブコマンド
+	//   開発・負荷試験用のデータ投入: api seed [--seed N] [--profile small|large] [--reset] [--enrich]
+	//   投稿等の一括エクスポート: api export --out FILE [--format geojson|ndjson|csv] [--type ...] [--bbox ...] [--from/--to YYYY-MM-DD] [--category ...]
+	//   投稿等の一括取り込み: api import --author EMAIL [--format ...] [--type ...] [--dry-run] FILE
+	//   権限の変更（最初の管理者の登録等）: api role --email EMAIL --role user|moderator|admin
+	if len(os.Args) > 1 {
+		var run func(*config.Config, []string) error
+		switch os.Args[1] {
+		case "seed":
+			run = runSeed
+		case "export":
+			run = runExport
+		case "import":
+			run = runImport
+		case "role":
+			run = runRole
+		}
+		if run != nil {
+			if err := run(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("%s failed: %v", os.Args[1], err)
+			}
+			return
+		}
+	}
+
+	// メトリクスとトレース（トレースは OTEL_TRACING_ENABLED=true の場合のみ送信する）
+	if err := telemetry.Initialize(cfg.Telemetry); err != nil {
+		log.Fatalf("Failed to initialize telemetry: %v", err)
+	}
+	if err := telemetry.InstrumentGORM(db.GetDB()); err != nil {
+		log.Fatalf("Failed to instrument database: %v", err)
+	}
+	sqlDB, err := db.GetDB().DB()
+	if err != nil {
+		log.Fatalf("Failed to get database instance: %v", err)
+	}
+	if err := telemetry.RegisterDBStats(sqlDB, "postgres"); err != nil {
+		log.Fatalf("Failed to register database metrics: %v", err)
+	}
+
+	// ファイル保存先の初期化
+	if err := storage.Initialize(cfg.Storage); err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	// 投稿内容のフィルタの初期化
+	if err := contentfilter.Initialize(cfg.ContentFilter, cfg.Gemini); err != nil {
+		log.Fatalf("Failed to initialize content filter: %v", err)
+	}
+
+	// レート制限の初期化
+	if err := ratelimit.Initialize(cfg.RateLimit); err != nil {
+		log.Fatalf("Failed to initialize rate limiter: %v", err)
+	}
+
+	// メール送信の初期化
+	if err := mailer.Initialize(cfg.Mail); err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
+	// ハンドラーが使う設定（JWT・Gemini・退会の猶予期間等）
+	handlers.Configure(cfg)
+
+	// /readyz で確認する依存先
+	registerHealthChecks(cfg)
+
+	// バックグラウンドジョブ（データエクスポート、退会ユーザー・期限切れエクスポートの削除、期限切れの利用停止の解除）
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobs.Start(jobsCtx, 2, 100)
+	jobs.Every(jobsCtx, time.Hour, "purge deleted accounts", handlers.PurgeDeletedAccounts)
+	jobs.Every(jobsCtx, time.Hour, "purge expired exports", handlers.PurgeExpiredExports)
+	jobs.Every(jobsCtx, time.Hour, "lift expired suspensions", handlers.LiftExpiredSuspensions)
+
+	// Ginエンジンの作成（アクセスログは slog で出力する）
+	r := gin.New()
+	r.Use(telemetry.Tracing(), middleware.RequestID(), middleware.AccessLog(), telemetry.Metrics(), apierror.Recovery(), apierror.Middleware())
+
+	// X-Forwarded-For を信頼するプロキシ（既定は nginx 等の同一ホスト・プライベートネットワーク）
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// CORS設定（厳格に制限）
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000", "https://chap-app.jp", "https://www.chap-app.jp"}, // Next.jsの開発サーバーのみ許可
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true, // cookieを使用する場合
-		MaxAge:           12 * time.Hour,
-	}))
-
-	routes.SetupRoutes(r)
-
-	// サーバー起動
-	log.Println("Starting server on :8080...")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	r.Use(middleware.CORS(cfg.Server))
+
+	routes.SetupRoutes(r, cfg)
+
+	// サーバー起動（SIGTERM を受けると処理中のリクエストの完了を待って終了する）
+	err = server.Run(cfg.Server, r, server.Hooks{
+		Cleanup: func(ctx context.Context) {
+			stopJobs()
+			done := make(chan struct{})
+			go func() {
+				jobs.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				slog.Warn("Background jobs did not finish before shutdown timeout")
+			}
+			if err := telemetry.Shutdown(ctx); err != nil {
+				slog.Warn("Failed to flush traces", "error", err)
+			}
+			if err := db.Close(); err != nil {
+				slog.Warn("Failed to close database", "error", err)
+			}
+		},
+	})
+	if err != nil {
+		log.Fatalf("Server error: %v", err)
 	}
 }
 
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
-			return
+// registerHealthChecks は /readyz のチェックを登録する
+// データベースとスキーマは必須、それ以外は問題があっても degraded として受け付けを続ける
+func registerHealthChecks(cfg *config.Config) {
+	health.Register("database", true, db.Ping)
+	health.Register("migrations", true, db.CheckSchema)
+	health.Register("blob_store", false, storage.Get().Ping)
+	if redis, ok := ratelimit.Get().(*ratelimit.RedisStore); ok {
+		health.Register("rate_limit_store", false, redis.Ping)
+	}
+	health.Register("llm", false, func(ctx context.Context) error {
+		if cfg.Gemini.APIKey == "" {
+			return fmt.Errorf("GEMINI_API_KEY is not set")
 		}
+		return nil
+	})
+}
 
-		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			// HS256 を使用
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+// runSeed は seed サブコマンド（本番環境では実行しない）
+func runSeed(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	seedValue := fs.Int64("seed", 1, "random seed (the same seed and base date generate the same data)")
+	profile := fs.String("profile", "small", "data volume: small or large")
+	users := fs.Int("users", 0, "override the number of users in the profile")
+	base := fs.String("base", "", "base date YYYY-MM-DD (default: today in UTC)")
+	reset := fs.Bool("reset", false, "delete previously seeded users and their data first")
+	enrich := fs.Bool("enrich", false, "rewrite generated text with Gemini (requires GEMINI_API_KEY; not deterministic)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			return
+	if cfg.Env == config.EnvProduction {
+		return fmt.Errorf("refusing to seed in %s", cfg.Env)
+	}
+	p, ok := seed.Profiles[*profile]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", *profile)
+	}
+	if *users > 0 {
+		p.Users = *users
+	}
+	baseDate := time.Now().UTC().Truncate(24 * time.Hour)
+	if *base != "" {
+		t, err := time.Parse(time.DateOnly, *base)
+		if err != nil {
+			return fmt.Errorf("invalid --base: %w", err)
 		}
+		baseDate = t
+	}
+	if *enrich && cfg.Gemini.APIKey == "" {
+		return fmt.Errorf("--enrich requires GEMINI_API_KEY")
+	}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
-			return
+	ctx := context.Background()
+	defer db.Close()
+	if *reset {
+		deleted, err := seed.Reset(ctx)
+		if err != nil {
+			return fmt.Errorf("reset: %w", err)
 		}
+		slog.Info("Deleted seeded data", "summary", deleted.String())
+	}
 
-		userID, ok := claims["sub"].(string) // SupabaseのJWTはsubがuser_id
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id"})
-			return
+	ds, err := seed.Generate(seed.Options{
+		Seed:       *seedValue,
+		Profile:    p,
+		Base:       baseDate,
+		Categories: category.Get().All(),
+	})
+	if err != nil {
+		return err
+	}
+	if *enrich {
+		n, err := seed.Enrich(ctx, cfg.Gemini, ds)
+		if err != nil {
+			return fmt.Errorf("enrich: %w", err)
+		}
+		slog.Info("Enriched seed content", "count", n)
+	}
+	inserted, err := seed.Insert(ctx, ds)
+	if err != nil {
+		return err
+	}
+	slog.Info("Seeded data", "seed", *seedValue, "profile", *profile, "summary", inserted.String())
+	fmt.Printf("seeded %s (login: %s / %s)\n", inserted, seed.Email(*seedValue, 0), seed.Password)
+	return nil
+}
+
+// runExport は export サブコマンド（ログは標準出力に出すため、書き出し先のファイルを指定する）
+func runExport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	out := fs.String("out", "", "output file (required)")
+	format := fs.String("format", "", "geojson, ndjson or csv (default: from the output file extension)")
+	kinds := fs.String("type", "", "comma-separated types: post, thread, event (default: all)")
+	bbox := fs.String("bbox", "", "minLng,minLat,maxLng,maxLat")
+	from := fs.String("from", "", "created on or after YYYY-MM-DD (UTC)")
+	to := fs.String("to", "", "created before YYYY-MM-DD (UTC)")
+	categories := fs.String("category", "", "comma-separated category IDs")
+	includeHidden := fs.Bool("include-hidden", false, "include hidden content")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *out == "" {
+		return fmt.Errorf("--out is required")
+	}
+	if *format == "" {
+		*format = bulk.FormatOf(*out)
+	}
+	if !bulk.ValidFormat(*format) {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	f := bulk.Filter{
+		Kinds:         bulk.ParseList(*kinds),
+		Categories:    bulk.ParseList(*categories),
+		IncludeHidden: *includeHidden,
+	}
+	if *bbox != "" {
+		b, err := bulk.ParseBBox(*bbox)
+		if err != nil {
+			return err
 		}
+		f.BBox = b
+	}
+	for _, d := range []struct {
+		value string
+		dst   *time.Time
+	}{{*from, &f.From}, {*to, &f.To}} {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.DateOnly, d.value)
+		if err != nil {
+			return fmt.Errorf("invalid date %q: %w", d.value, err)
+		}
+		*d.dst = t
+	}
 
-		c.Set("user_id", userID)
-		c.Next()
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	count, err := bulk.Export(context.Background(), file, *format, f)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
 	}
+	slog.Info("Content exported", "format", *format, "count", count, "file", *out)
+	return audit.Record(db.Ctx(context.Background()), audit.Entry{
+		Action:     audit.ActionContentExport,
+		TargetType: audit.TargetContent,
+		Detail:     map[string]any{"format": *format, "filter": f, "count": count, "file": *out},
+	})
+}
+
+// runImport は import サブコマンド（結果は JSON で標準出力に出す）
+func runImport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	author := fs.String("author", "", "email address of the user who will own the imported content (required)")
+	format := fs.String("format", "", "geojson, ndjson or csv (default: from the file extension)")
+	kind := fs.String("type", "", "type for rows without one: post, thread or event")
+	dryRun := fs.Bool("dry-run", false, "validate and check duplicates without saving")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: api import --author EMAIL [--format FORMAT] [--type TYPE] [--dry-run] FILE")
+	}
+	path := fs.Arg(0)
+	if *author == "" {
+		return fmt.Errorf("--author is required")
+	}
+	if *format == "" {
+		*format = bulk.FormatOf(path)
+	}
+	if !bulk.ValidFormat(*format) {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	ctx := context.Background()
+	var user types.User
+	if err := db.Ctx(ctx).Where("email = ?", *author).First(&user).Error; err != nil {
+		return fmt.Errorf("author %s: %w", *author, err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	res, err := bulk.Import(ctx, file, bulk.ImportOptions{
+		Format: *format,
+		Kind:   *kind,
+		Author: user,
+		DryRun: *dryRun,
+		Audit:  &audit.Entry{Action: audit.ActionContentImport, TargetType: audit.TargetContent},
+	})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// runRole は role サブコマンド（API の PUT /admin/users/:id/role には管理者が必要なため、最初の管理者はこれで登録する）
+func runRole(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("role", flag.ExitOnError)
+	email := fs.String("email", "", "email address of the user (required)")
+	role := fs.String("role", "", "user, moderator or admin (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *email == "" || *role == "" {
+		return fmt.Errorf("usage: api role --email EMAIL --role user|moderator|admin")
+	}
+	if *role != types.RoleUser && *role != types.RoleModerator && *role != types.RoleAdmin {
+		return fmt.Errorf("unknown role %q", *role)
+	}
+
+	return db.SafeTransaction(context.Background(), func(tx *gorm.DB) error {
+		var user types.User
+		if err := tx.Where("LOWER(email) = LOWER(?)", *email).First(&user).Error; err != nil {
+			return fmt.Errorf("user %s: %w", *email, err)
+		}
+		from := user.Role
+		if err := tx.Model(&user).Update("role", *role).Error; err != nil {
+			return err
+		}
+		slog.Info("Role updated", "user_id", user.ID, "from", from, "to", *role)
+		return audit.Record(tx, audit.Entry{
+			Action:     audit.ActionUserRole,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID.String(),
+			Detail:     map[string]any{"from": from, "to": *role},
+		})
+	})
 }
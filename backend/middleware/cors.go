@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"api/config"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS は cfg.CORSOrigins のオリジンのみに Cookie 付きのクロスオリジンのリクエストを許可する
+// プリフライト（OPTIONS）もここで応答する（許可していないオリジンは 403）
+func CORS(cfg config.Server) gin.HandlerFunc {
+	return cors.New(cors.Config{
+		AllowOrigins:     cfg.CORSOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Request-ID", "Deprecation", "Sunset", "Link"},
+		AllowCredentials: true, // cookieを使用する場合
+		MaxAge:           12 * time.Hour,
+	})
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated は廃止予定の API の応答に Deprecation（RFC 9745）・Sunset（RFC 8594）・Link ヘッダーを付ける
+// since は廃止予定にした日時、sunset は提供を終了する日時（ゼロ値の場合は Sunset を付けない）、successor は移行先
+func Deprecated(since, sunset time.Time, successor string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	link := "<" + successor + `>; rel="successor-version"`
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if successor != "" {
+			c.Header("Link", link)
+		}
+		c.Next()
+	}
+}
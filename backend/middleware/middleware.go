@@ -1,16 +1,33 @@
 package middleware
 
 import (
+	"api/apierror"
+	"api/config"
+	"api/db"
+	"api/types"
+	"errors"
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware は JWT（Authorization ヘッダーまたは token Cookie）を検証し、user_id を設定する
+// 退会手続き中のユーザーは 403 を返す（退会はログインし直すと取り消される）
+func AuthMiddleware(cfg config.Auth) gin.HandlerFunc {
+	return authenticate(cfg, false)
+}
+
+// AllowPendingDeletion は AuthMiddleware と同じだが、退会手続き中のユーザーも通す（猶予期間中の個人データのエクスポート用）
+func AllowPendingDeletion(cfg config.Auth) gin.HandlerFunc {
+	return authenticate(cfg, true)
+}
+
+func authenticate(cfg config.Auth, allowPendingDeletion bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// OPTIONSリクエスト（プリフライト）は認証をスキップ
 		if c.Request.Method == "OPTIONS" {
@@ -26,8 +43,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			// "Bearer TOKEN"形式から"TOKEN"部分を取得
 			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
 			if tokenString == authHeader {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
-				c.Abort()
+				apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidToken, "invalid authorization header format"))
 				return
 			}
 		} else {
@@ -35,17 +51,15 @@ func AuthMiddleware() gin.HandlerFunc {
 			var err error
 			tokenString, err = c.Cookie("token")
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization required"})
-				c.Abort()
+				apierror.Abort(c, apierror.Unauthorized(apierror.CodeUnauthorized, "authorization required"))
 				return
 			}
 		}
 
 		// JWT Secret取得
-		jwtSecret := os.Getenv("JWT_SECRET")
+		jwtSecret := cfg.JWTSecret
 		if jwtSecret == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "JWT secret not configured"})
-			c.Abort()
+			apierror.Abort(c, apierror.Internal("JWT secret not configured", nil))
 			return
 		}
 
@@ -59,21 +73,79 @@ func AuthMiddleware() gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidToken, "invalid token"))
 			return
 		}
 
 		// ユーザーIDを取得してcontextに設定
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userID := claims["user_id"].(string)
-			c.Set("user_id", userID)
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
+		claims, ok := token.Claims.(jwt.MapClaims)
+		userID, _ := claims["user_id"].(string)
+		if _, err := uuid.Parse(userID); !ok || err != nil {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidToken, "invalid token claims"))
 			return
 		}
 
+		// 利用停止中・退会手続き中のアカウントはトークンの期限内でも拒否する
+		var user types.User
+		err = db.Ctx(c.Request.Context()).Unscoped().Select("id", "valid", "suspended_until", "password_set_at", "deleted_at").Where("id = ?", userID).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeUnauthorized, "user not found"))
+			return
+		}
+		if err != nil {
+			apierror.Abort(c, apierror.Internal("failed to fetch user", err))
+			return
+		}
+		if suspended := Suspended(user); suspended != nil {
+			apierror.Abort(c, suspended)
+			return
+		}
+		if user.DeletedAt.Valid && !allowPendingDeletion {
+			apierror.Abort(c, apierror.Forbidden(apierror.CodeAccountPendingDeletion, "account is scheduled for deletion; log in again to cancel"))
+			return
+		}
+		// パスワードの変更・再設定より前に発行されたトークンは無効（iat は秒単位）
+		if user.PasswordSetAt != nil {
+			issuedAt, err := claims.GetIssuedAt()
+			if err != nil || issuedAt == nil || issuedAt.Unix() < user.PasswordSetAt.Unix() {
+				apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidToken, "token has been revoked"))
+				return
+			}
+		}
+		c.Set("user_id", userID)
+
 		c.Next()
 	}
 }
+
+// Suspended はアカウントが利用停止中なら 403 のエラーを返す（ログイン時にも使う）
+func Suspended(user types.User) *apierror.Error {
+	switch user.Status(time.Now()) {
+	case types.UserSuspended:
+		return apierror.Forbidden(apierror.CodeAccountSuspended, "account suspended until "+user.SuspendedUntil.Format(time.RFC3339))
+	case types.UserBanned:
+		return apierror.Forbidden(apierror.CodeAccountSuspended, "account suspended")
+	}
+	return nil
+}
+
+// RequireRole は AuthMiddleware の後に使い、ユーザーが指定した権限のいずれかを持つか確認する
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user types.User
+		if err := db.Ctx(c.Request.Context()).Select("role").Where("id = ?", c.GetString("user_id")).First(&user).Error; err != nil {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeUnauthorized, "user not found"))
+			return
+		}
+
+		for _, role := range roles {
+			if user.Role == role {
+				c.Set("role", user.Role)
+				c.Next()
+				return
+			}
+		}
+
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeForbidden, "insufficient permissions"))
+	}
+}
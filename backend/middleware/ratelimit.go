@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"api/apierror"
+	"api/ratelimit"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit は group の制限をかける。認証済みならユーザー単位、未認証ならIP単位で数える
+// ユーザー単位で制限する場合は AuthMiddleware の後に使う
+func RateLimit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, ok := ratelimit.LimitFor(group)
+		if !ok || c.Request.Method == "OPTIONS" {
+			c.Next()
+			return
+		}
+
+		key := group + ":ip:" + c.ClientIP()
+		if userID := c.GetString("user_id"); userID != "" {
+			key = group + ":user:" + userID
+		}
+
+		result, err := ratelimit.Get().Take(c.Request.Context(), key, limit)
+		if err != nil {
+			// 保存先の障害でサービス全体を止めないよう、制限せずに通す
+			slog.ErrorContext(c.Request.Context(), "rate limit store error", "group", group, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", seconds(result.Reset))
+		if !result.Allowed {
+			c.Header("Retry-After", seconds(result.RetryAfter))
+			apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, "too many requests"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// seconds は期間を切り上げた秒数の文字列にする
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
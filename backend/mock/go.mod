@@ -1,9 +0,0 @@
-module mock
-
-go 1.23.10
-
-require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/lib/pq v1.10.9 // indirect
-)
@@ -0,0 +1,322 @@
+// Package openapi はルートの定義と DTO から OpenAPI 3 のドキュメントを組み立てる
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+// Document は OpenAPI のドキュメント
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem はメソッド（小文字）毎の操作
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path / query / header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Response は応答。Ref を指定した場合は components.responses を参照する
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	Responses       map[string]*Response      `json:"responses,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Route は1つのエンドポイントの説明
+type Route struct {
+	Method      string
+	Path        string // gin の書式（/api/v1/user/:id）
+	Tag         string
+	Summary     string
+	Description string
+	// Bearer トークン（JWT）が必要
+	Auth bool
+	// パスパラメータは Path から作る。型や説明を指定する場合は同名のパラメータを指定する
+	Params []Parameter
+	// JSON のリクエストボディ（nil なら無し）
+	Request         any
+	RequestOptional bool
+	// multipart/form-data でアップロードするファイルの項目名
+	Upload string
+	// 成功時のステータス毎の data の中身。nil の場合は本文無し
+	// File を指定した場合は JSON 以外の本文、Raw を指定した場合は {"data": ...} で包まない
+	Responses map[int]any
+	// 主なエラーのステータスと説明（空の場合はステータスの名前）
+	// 400（リクエストボディがある場合）・401 と 403（Auth の場合。403 は利用停止中・退会手続き中のアカウント）・500 は自動で追加する
+	Errors map[int]string
+	// 非推奨（Sunset 等のヘッダーで廃止予定を伝えている）
+	Deprecated bool
+}
+
+// File は JSON 以外の応答（ZIP・テキスト等）
+type File string
+
+// Files は形式を選べる JSON 以外の応答（いずれかの MIME タイプで返す）
+type Files []string
+
+// Raw は {"data": ...} で包まずに返す応答（ヘルスチェック等）
+type Raw struct{ Body any }
+
+// Object はキー毎の値の型で表すオブジェクト（gin.H で返す応答等）
+// キーに ",omitempty" を付けると省略可能な項目になる
+type Object map[string]any
+
+// Spec は Build の入力
+type Spec struct {
+	Info    Info
+	Servers []Server
+	Tags    []Tag
+	// エラー時の本文（全てのエンドポイントで共通）
+	Error  any
+	Routes []Route
+}
+
+const (
+	errorResponse = "Error"
+	errorSchema   = "ErrorResponse"
+	bearerAuth    = "bearerAuth"
+)
+
+// Build はルートの定義からドキュメントを組み立てる
+func Build(spec Spec) *Document {
+	g := newGenerator()
+	g.schemas[errorSchema] = g.schema(spec.Error)
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    spec.Info,
+		Servers: spec.Servers,
+		Tags:    spec.Tags,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: g.schemas,
+			Responses: map[string]*Response{
+				errorResponse: {
+					Description: "エラー",
+					Content:     jsonContent(&Schema{Ref: "#/components/schemas/" + errorSchema}),
+				},
+			},
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	for _, r := range spec.Routes {
+		path, params := convertPath(r.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(r.Method)] = g.operation(r, params)
+	}
+	return doc
+}
+
+func (g *generator) operation(r Route, pathParams []string) *Operation {
+	op := &Operation{
+		Summary:     r.Summary,
+		Description: r.Description,
+		Responses:   map[string]*Response{},
+		Deprecated:  r.Deprecated,
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+
+	for _, name := range pathParams {
+		p := Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		for _, override := range r.Params {
+			if override.In == "path" && override.Name == name {
+				p = override
+				p.Required = true
+			}
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+	for _, p := range r.Params {
+		if p.In != "path" {
+			op.Parameters = append(op.Parameters, p)
+		}
+	}
+
+	if r.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: !r.RequestOptional,
+			Content:  jsonContent(g.schema(r.Request)),
+		}
+	}
+	if r.Upload != "" {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{"multipart/form-data": {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{r.Upload: {Type: "string", Format: "binary"}},
+				Required:   []string{r.Upload},
+			}}},
+		}
+	}
+	if r.Auth {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+
+	for status, body := range r.Responses {
+		op.Responses[strconv.Itoa(status)] = g.response(status, body)
+	}
+
+	errs := map[int]string{http.StatusInternalServerError: ""}
+	if r.Request != nil || r.Upload != "" {
+		errs[http.StatusBadRequest] = ""
+	}
+	if r.Auth {
+		errs[http.StatusUnauthorized] = ""
+		errs[http.StatusForbidden] = ""
+	}
+	for status, desc := range r.Errors {
+		errs[status] = desc
+	}
+	for status, desc := range errs {
+		if desc == "" {
+			op.Responses[strconv.Itoa(status)] = &Response{Ref: "#/components/responses/" + errorResponse}
+			continue
+		}
+		op.Responses[strconv.Itoa(status)] = &Response{
+			Description: desc,
+			Content:     jsonContent(&Schema{Ref: "#/components/schemas/" + errorSchema}),
+		}
+	}
+	return op
+}
+
+func (g *generator) response(status int, body any) *Response {
+	res := &Response{Description: http.StatusText(status)}
+	switch b := body.(type) {
+	case nil:
+	case File:
+		res.Content = map[string]MediaType{string(b): {}}
+	case Files:
+		res.Content = map[string]MediaType{}
+		for _, contentType := range b {
+			res.Content[contentType] = MediaType{}
+		}
+	case Raw:
+		res.Content = jsonContent(g.schema(b.Body))
+	default:
+		res.Content = jsonContent(&Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"data": g.schema(body)},
+			Required:   []string{"data"},
+		})
+	}
+	return res
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+// convertPath は gin のパス（/user/:id）を OpenAPI の書式（/user/{id}）にし、パスパラメータの名前を返す
+func convertPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			params = append(params, s[1:])
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// PathOf は gin のパスを OpenAPI の書式にする
+func PathOf(ginPath string) string {
+	path, _ := convertPath(ginPath)
+	return path
+}
+
+// Query はクエリパラメータ
+func Query(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// PathParam は型や説明を指定したパスパラメータ
+func PathParam(name string, schema *Schema, description string) Parameter {
+	return Parameter{Name: name, In: "path", Required: true, Description: description, Schema: schema}
+}
+
+// Operations はドキュメントの全ての操作を "METHOD path" の形で返す
+func (d *Document) Operations() []string {
+	var ops []string
+	for path, item := range d.Paths {
+		for method := range item {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
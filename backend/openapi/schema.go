@@ -0,0 +1,276 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Schema は JSON Schema（OpenAPI 3.0 の部分集合）
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	deletedAtType  = reflect.TypeOf(gorm.DeletedAt{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator は Go の型からスキーマを作り、名前付きの構造体を components.schemas に登録する
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// schema は v の型のスキーマを返す（v は型を表すためのゼロ値）
+func (g *generator) schema(v any) *Schema {
+	if obj, ok := v.(Object); ok {
+		return g.object(obj)
+	}
+	if v == nil {
+		return &Schema{}
+	}
+	return g.typeSchema(reflect.TypeOf(v))
+}
+
+func (g *generator) object(obj Object) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for key, v := range obj {
+		name, opts, _ := strings.Cut(key, ",")
+		s.Properties[name] = g.schema(v)
+		if opts != "omitempty" {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+func (g *generator) typeSchema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case deletedAtType:
+		return &Schema{Type: "string", Format: "date-time", Nullable: true}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.typeSchema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	}
+	// interface{} 等は任意の値
+	return &Schema{}
+}
+
+// structRef は名前付きの構造体を components.schemas に登録して参照を返す
+func (g *generator) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := g.schemas[name]; taken {
+			// 別のパッケージの同名の型
+			pkg := path.Base(t.PkgPath())
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		g.names[t] = name
+		g.schemas[name] = &Schema{} // 再帰する型のため先に登録する
+		*g.schemas[name] = *g.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema は構造体のスキーマを作る
+// binding タグがある構造体（リクエスト）は binding:"required" の項目を、それ以外（レスポンス）は omitempty でない項目を必須にする
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	request := hasBindingTags(t)
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+
+			binding := f.Tag.Get("binding")
+			fs := g.typeSchema(f.Type)
+			if binding != "" {
+				fs = applyBinding(fs, binding)
+			}
+			s.Properties[name] = fs
+
+			required := !strings.Contains(opts, "omitempty")
+			if request {
+				required = hasRule(binding, "required")
+			}
+			if required {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+	walk(t)
+	sort.Strings(s.Required)
+	return s
+}
+
+func hasBindingTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("binding") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRule(binding, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == "dive" {
+			return false
+		}
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// applyBinding は binding タグの検証ルールをスキーマの制約にする（dive 以降は配列の要素に適用する）
+func applyBinding(s *Schema, binding string) *Schema {
+	if s.Ref != "" {
+		return s
+	}
+	rules := strings.Split(binding, ",")
+	for i, rule := range rules {
+		if rule == "dive" {
+			if s.Items != nil {
+				s.Items = applyBinding(s.Items, strings.Join(rules[i+1:], ","))
+			}
+			return s
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "max", "len":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			applyLength(s, name, n)
+		case "gte", "gt", "lte", "lt":
+			f, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			if name[0] == 'g' {
+				s.Minimum = &f
+			} else {
+				s.Maximum = &f
+			}
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, v)
+			}
+		case "email":
+			s.Format = "email"
+		case "future":
+			s.Description = "現在より後の日時"
+		}
+	}
+	return s
+}
+
+// applyLength は min / max / len を型に応じて文字数・要素数・値の範囲にする
+func applyLength(s *Schema, rule string, n int) {
+	switch s.Type {
+	case "string":
+		if rule != "max" {
+			s.MinLength = &n
+		}
+		if rule != "min" {
+			s.MaxLength = &n
+		}
+	case "array":
+		if rule != "max" {
+			s.MinItems = &n
+		}
+		if rule != "min" {
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		f := float64(n)
+		if rule != "max" {
+			s.Minimum = &f
+		}
+		if rule != "min" {
+			s.Maximum = &f
+		}
+	}
+}
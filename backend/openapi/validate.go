@@ -0,0 +1,175 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidateResponse は応答がドキュメントの定義に合うか確認する
+// ginPath は登録したルートのパス（c.FullPath()）
+func (d *Document) ValidateResponse(method, ginPath string, status int, contentType string, body []byte) error {
+	op := d.Operation(method, ginPath)
+	if op == nil {
+		return fmt.Errorf("%s %s is not documented", method, PathOf(ginPath))
+	}
+	res, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return fmt.Errorf("%s %s: status %d is not documented", method, PathOf(ginPath), status)
+	}
+	res = d.resolveResponse(res)
+
+	if len(res.Content) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("%s %s: status %d should have no body", method, PathOf(ginPath), status)
+		}
+		return nil
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	media, ok := res.Content[strings.TrimSpace(mediaType)]
+	if !ok {
+		return fmt.Errorf("%s %s: content type %q is not documented for status %d", method, PathOf(ginPath), contentType, status)
+	}
+	if media.Schema == nil {
+		return nil
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Errorf("%s %s: invalid json: %w", method, PathOf(ginPath), err)
+	}
+	if err := d.Validate(media.Schema, v); err != nil {
+		return fmt.Errorf("%s %s (%d): %w", method, PathOf(ginPath), status, err)
+	}
+	return nil
+}
+
+// Operation は method と gin のパスに対応する操作を返す（無ければ nil）
+func (d *Document) Operation(method, ginPath string) *Operation {
+	item, ok := d.Paths[PathOf(ginPath)]
+	if !ok {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+func (d *Document) resolveResponse(res *Response) *Response {
+	if name, ok := strings.CutPrefix(res.Ref, "#/components/responses/"); ok {
+		if r, ok := d.Components.Responses[name]; ok {
+			return r
+		}
+	}
+	return res
+}
+
+// Validate は v（JSON をデコードした値）がスキーマに合うか確認する
+// プロパティが定義されたオブジェクトに定義外の項目がある場合もエラーにする
+func (d *Document) Validate(s *Schema, v any) error {
+	return d.validate(s, v, "$")
+}
+
+func (d *Document) validate(s *Schema, v any, at string) error {
+	if name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/"); ok {
+		ref, ok := d.Components.Schemas[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %s", at, name)
+		}
+		s = ref
+	}
+	if v == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s: must not be null", at)
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: must be an object", at)
+		}
+		return d.validateObject(s, obj, at)
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: must be an array", at)
+		}
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range arr {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: must be a string", at)
+		}
+		return checkEnum(s, str, at)
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: must be an integer", at)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s: must be a number", at)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: must be a boolean", at)
+		}
+	}
+	return nil
+}
+
+func (d *Document) validateObject(s *Schema, obj map[string]any, at string) error {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s: missing property %q", at, name)
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		prop, ok := s.Properties[k]
+		switch {
+		case ok:
+		case s.AdditionalProperties != nil:
+			prop = s.AdditionalProperties
+		case len(s.Properties) == 0:
+			continue
+		default:
+			return fmt.Errorf("%s: unexpected property %q", at, k)
+		}
+		if err := d.validate(prop, obj[k], at+"."+k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkEnum(s *Schema, v string, at string) error {
+	if len(s.Enum) == 0 {
+		return nil
+	}
+	for _, e := range s.Enum {
+		if e == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %q is not one of %v", at, v, s.Enum)
+}
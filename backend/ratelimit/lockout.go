@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// LockoutPolicy はログイン失敗時のロックアウト設定
+// Threshold 回連続で失敗するとロックし、以降は失敗する度にロック時間を倍にする（Max まで）
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	// Window の間失敗がなければ失敗回数をリセットする
+	Window time.Duration
+}
+
+var DefaultLockoutPolicy = LockoutPolicy{
+	Threshold: 5,
+	Base:      time.Minute,
+	Max:       time.Hour,
+	Window:    24 * time.Hour,
+}
+
+// Lockout はアカウント単位でログイン失敗を数え、総当たり攻撃を防ぐ
+type Lockout struct {
+	store  Store
+	policy LockoutPolicy
+}
+
+func NewLockout(store Store, policy LockoutPolicy) *Lockout {
+	return &Lockout{store: store, policy: policy}
+}
+
+// Locked はアカウントがロック中であれば解除までの残り時間を返す
+func (l *Lockout) Locked(ctx context.Context, account string) (time.Duration, error) {
+	return l.store.Blocked(ctx, lockKey(account))
+}
+
+// Fail はログイン失敗を記録し、ロックした場合はロック時間を返す
+func (l *Lockout) Fail(ctx context.Context, account string) (time.Duration, error) {
+	n, err := l.store.Incr(ctx, failureKey(account), l.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+	if n < int64(l.policy.Threshold) {
+		return 0, nil
+	}
+
+	d := l.policy.Base
+	for i := int64(l.policy.Threshold); i < n && d < l.policy.Max; i++ {
+		d *= 2
+	}
+	if d > l.policy.Max {
+		d = l.policy.Max
+	}
+	if err := l.store.Block(ctx, lockKey(account), d); err != nil {
+		return 0, err
+	}
+	return d, nil
+}
+
+// Succeed はログイン成功時に失敗回数をリセットする
+func (l *Lockout) Succeed(ctx context.Context, account string) error {
+	return l.store.Reset(ctx, failureKey(account), lockKey(account))
+}
+
+// メールアドレスの大文字・小文字の違いで回避されないよう正規化する
+func failureKey(account string) string {
+	return "login-fail:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func lockKey(account string) string {
+	return "login-lock:" + strings.ToLower(strings.TrimSpace(account))
+}
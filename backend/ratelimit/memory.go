@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 期限切れのエントリを掃除する間隔
+const memoryCleanupInterval = time.Minute
+
+// MemoryStore はプロセス内で状態を持つ Store（単一インスタンス向け）
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*memoryBucket
+	counters map[string]*memoryCounter
+	now      func() time.Time
+}
+
+type memoryBucket struct {
+	tokens float64
+	last   time.Time
+	expire time.Time
+}
+
+type memoryCounter struct {
+	n      int64
+	expire time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		buckets:  map[string]*memoryBucket{},
+		counters: map[string]*memoryCounter{},
+		now:      time.Now,
+	}
+	go s.cleanup()
+	return s
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok || now.After(b.expire) {
+		b = &memoryBucket{tokens: float64(limit.Requests), last: now}
+		s.buckets[key] = b
+	}
+	tokens, allowed := bucket(b.tokens, now.Sub(b.last), limit)
+	b.tokens = tokens
+	b.last = now
+	// 満杯に戻った後は保持する必要がない
+	b.expire = now.Add(limit.Per)
+
+	return result(tokens, allowed, limit), nil
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expire) {
+		c = &memoryCounter{expire: now.Add(ttl)}
+		s.counters[key] = c
+	}
+	c.n++
+	return c.n, nil
+}
+
+func (s *MemoryStore) Block(_ context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counters[key] = &memoryCounter{n: 1, expire: s.now().Add(d)}
+	return nil
+}
+
+func (s *MemoryStore) Blocked(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok {
+		return 0, nil
+	}
+	remaining := c.expire.Sub(s.now())
+	if remaining <= 0 {
+		delete(s.counters, key)
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.buckets, key)
+		delete(s.counters, key)
+	}
+	return nil
+}
+
+func (s *MemoryStore) cleanup() {
+	ticker := time.NewTicker(memoryCleanupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		now := s.now()
+		for key, b := range s.buckets {
+			if now.After(b.expire) {
+				delete(s.buckets, key)
+			}
+		}
+		for key, c := range s.counters {
+			if now.After(c.expire) {
+				delete(s.counters, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"api/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit はトークンバケットの設定（Per の間に Requests 回まで、最大 Requests 回まで連続で許可）
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// rate は1ミリ秒あたりに補充されるトークン数
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / float64(l.Per.Milliseconds())
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// ParseLimit は "30/1m" 形式の文字列を Limit に変換する
+func ParseLimit(s string) (Limit, error) {
+	n, per, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q (expected <requests>/<duration>)", s)
+	}
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad request count", s)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d < time.Millisecond {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad duration", s)
+	}
+	return Limit{Requests: requests, Per: d}, nil
+}
+
+// Result は1回のリクエストに対する判定結果
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter は拒否された場合に次のトークンが補充されるまでの時間
+	RetryAfter time.Duration
+	// Reset はバケットが満杯に戻るまでの時間
+	Reset time.Duration
+}
+
+// Store はバケットとログイン失敗回数の保存先（複数インスタンスで共有する場合は Redis を使う）
+type Store interface {
+	// Take は key のバケットからトークンを1つ取り出す
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Incr は key のカウンタを1増やして返す。新しく作られたカウンタは ttl 後に消える
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Block は key を d の間ブロック状態にする
+	Block(ctx context.Context, key string, d time.Duration) error
+	// Blocked は key のブロックが解除されるまでの残り時間を返す（ブロックされていなければ0）
+	Blocked(ctx context.Context, key string) (time.Duration, error)
+	// Reset は keys のカウンタとブロックを削除する
+	Reset(ctx context.Context, keys ...string) error
+}
+
+// グループ毎の既定の制限
+var defaultLimits = map[string]Limit{
+	// ログイン・登録（IP単位）
+	"auth": {Requests: 10, Per: time.Minute},
+	// 投稿の作成・編集・削除、アップロード、通報（ユーザー単位）
+	"write": {Requests: 30, Per: time.Minute},
+	// 確認メール・パスワード再設定メールの送信（宛先アドレス単位）
+	"mail": {Requests: 3, Per: time.Hour},
+}
+
+var (
+	store   Store
+	limits  = map[string]Limit{}
+	lockout *Lockout
+)
+
+// Initialize は cfg.Backend（memory / redis）に応じて保存先を初期化する
+// 各グループの制限は RATE_LIMIT_<GROUP>（例: RATE_LIMIT_WRITE=30/1m）で上書きできる
+func Initialize(cfg config.RateLimit) error {
+	backend := cfg.Backend
+
+	switch backend {
+	case "memory":
+		store = NewMemoryStore()
+	case "redis":
+		s, err := NewRedisStore(cfg.RedisURL)
+		if err != nil {
+			return err
+		}
+		store = s
+	default:
+		return fmt.Errorf("unknown RATE_LIMIT_BACKEND: %s", backend)
+	}
+
+	overrides := cfg.Limits()
+	for group, def := range defaultLimits {
+		limits[group] = def
+		if v, ok := overrides[group]; ok {
+			l, err := ParseLimit(v)
+			if err != nil {
+				return fmt.Errorf("invalid RATE_LIMIT_%s: %w", strings.ToUpper(group), err)
+			}
+			limits[group] = l
+		}
+	}
+
+	policy := DefaultLockoutPolicy
+	if cfg.LockoutThreshold > 0 {
+		policy.Threshold = cfg.LockoutThreshold
+	}
+	lockout = NewLockout(store, policy)
+
+	slog.Info("Rate limiter initialized", "backend", backend)
+	return nil
+}
+
+// Get returns the configured Store
+func Get() Store {
+	return store
+}
+
+// LimitFor はグループの制限を返す
+func LimitFor(group string) (Limit, bool) {
+	l, ok := limits[group]
+	return l, ok
+}
+
+// Logins はログイン失敗によるロックアウトを返す
+func Logins() *Lockout {
+	return lockout
+}
+
+// bucket はトークンバケットの補充と消費を計算する（メモリ・Redis 共通）
+func bucket(tokens float64, elapsed time.Duration, limit Limit) (float64, bool) {
+	tokens = math.Min(float64(limit.Requests), tokens+float64(elapsed.Milliseconds())*limit.rate())
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+// result は消費後のトークン数から Result を組み立てる
+func result(tokens float64, allowed bool, limit Limit) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     msDuration((float64(limit.Requests) - tokens) / limit.rate()),
+	}
+	if !allowed {
+		r.RetryAfter = msDuration((1 - tokens) / limit.rate())
+	}
+	return r
+}
+
+func msDuration(ms float64) time.Duration {
+	return time.Duration(math.Ceil(ms)) * time.Millisecond
+}
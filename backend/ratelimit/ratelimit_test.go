@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock はテスト用の時計
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+func newTestStore(c *clock) *MemoryStore {
+	return &MemoryStore{
+		buckets:  map[string]*memoryBucket{},
+		counters: map[string]*memoryCounter{},
+		now:      c.now,
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{"30/1m", Limit{Requests: 30, Per: time.Minute}, false},
+		{" 3/1h ", Limit{Requests: 3, Per: time.Hour}, false},
+		{"30", Limit{}, true},
+		{"0/1m", Limit{}, true},
+		{"x/1m", Limit{}, true},
+		{"10/soon", Limit{}, true},
+		{"10/1us", Limit{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// 満杯のバケットから Requests 回まで連続で許可し、時間の経過に応じて補充すること
+func TestMemoryStoreTake(t *testing.T) {
+	limit := Limit{Requests: 3, Per: 3 * time.Second} // 1秒に1つ補充
+	type step struct {
+		advance   time.Duration
+		allowed   bool
+		remaining int
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"burst", []step{{0, true, 2}, {0, true, 1}, {0, true, 0}, {0, false, 0}}},
+		{"refill one", []step{{0, true, 2}, {0, true, 1}, {0, true, 0}, {time.Second, true, 0}, {0, false, 0}}},
+		{"partial refill", []step{{0, true, 2}, {0, true, 1}, {0, true, 0}, {500 * time.Millisecond, false, 0}, {500 * time.Millisecond, true, 0}}},
+		{"refill caps at burst", []step{{0, true, 2}, {time.Hour, true, 2}, {0, true, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &clock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+			s := newTestStore(c)
+			for i, st := range tt.steps {
+				c.advance(st.advance)
+				r, err := s.Take(context.Background(), "k", limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Allowed != st.allowed || r.Remaining != st.remaining {
+					t.Errorf("step %d: allowed=%v remaining=%d, want %v %d", i, r.Allowed, r.Remaining, st.allowed, st.remaining)
+				}
+				if !r.Allowed && r.RetryAfter <= 0 {
+					t.Errorf("step %d: RetryAfter = %s, want > 0", i, r.RetryAfter)
+				}
+			}
+		})
+	}
+}
+
+// 閾値の回数で失敗するとロックし、以降は失敗する度にロック時間を倍にする（上限あり）
+func TestLockout(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 3, Base: time.Minute, Max: 4 * time.Minute, Window: time.Hour}
+	c := &clock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewLockout(newTestStore(c), policy)
+	ctx := context.Background()
+
+	want := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute}
+	for i, w := range want {
+		d, err := l.Fail(ctx, "User@Example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != w {
+			t.Errorf("failure %d: lock = %s, want %s", i+1, d, w)
+		}
+	}
+
+	// 大文字・小文字の違いは同じアカウントとして扱う
+	if d, _ := l.Locked(ctx, " user@example.com"); d != 4*time.Minute {
+		t.Errorf("locked = %s, want %s", d, 4*time.Minute)
+	}
+	c.advance(4*time.Minute - time.Second)
+	if d, _ := l.Locked(ctx, "user@example.com"); d != time.Second {
+		t.Errorf("locked = %s, want 1s", d)
+	}
+	c.advance(time.Second)
+	if d, _ := l.Locked(ctx, "user@example.com"); d != 0 {
+		t.Errorf("lock should expire, got %s", d)
+	}
+}
+
+// 成功でリセットし、Window の間失敗がなければ回数をリセットすること
+func TestLockoutReset(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 2, Base: time.Minute, Max: time.Hour, Window: time.Hour}
+	c := &clock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewLockout(newTestStore(c), policy)
+	ctx := context.Background()
+
+	l.Fail(ctx, "a")
+	if err := l.Succeed(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := l.Fail(ctx, "a"); d != 0 {
+		t.Errorf("first failure after success locked for %s", d)
+	}
+
+	c.advance(time.Hour + time.Second)
+	if d, _ := l.Fail(ctx, "a"); d != 0 {
+		t.Errorf("first failure after window locked for %s", d)
+	}
+	if d, _ := l.Fail(ctx, "a"); d != time.Minute {
+		t.Errorf("second failure locked for %s, want 1m", d)
+	}
+	if err := l.Succeed(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := l.Locked(ctx, "a"); d != 0 {
+		t.Errorf("lock should be cleared by success, got %s", d)
+	}
+}
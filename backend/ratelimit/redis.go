@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 上のキーの接頭辞
+const redisKeyPrefix = "ratelimit:"
+
+// takeScript はトークンバケットの補充と消費を原子的に行う
+// 時刻はアプリ側から渡す（TIME コマンドを持たない互換実装にも対応するため）
+var takeScript = redis.NewScript(`
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local burst = tonumber(ARGV[2])
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+local now = tonumber(ARGV[3])
+if tokens == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * tonumber(ARGV[1]))
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {allowed, tostring(tokens)}
+`)
+
+// incrScript はカウンタを1増やし、期限が無ければ設定する
+// EXPIRE の NX オプション（Redis 7 以降）を使わず、PTTL が負（期限なし）の場合のみ設定する
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// RedisStore は Redis 互換サーバーに状態を保存する Store（複数インスタンス向け）
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore は redis://[:password@]host:port/db 形式の URL から接続する
+func NewRedisStore(url string) (*RedisStore, error) {
+	if url == "" {
+		return nil, errors.New("REDIS_URL is required for the redis rate limit backend")
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &RedisStore{client: client}, nil
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	res, err := takeScript.Run(ctx, s.client, []string{redisKeyPrefix + key},
+		strconv.FormatFloat(limit.rate(), 'f', -1, 64),
+		limit.Requests,
+		time.Now().UnixMilli(),
+		limit.Per.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(res) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+	allowed, _ := res[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprint(res[1]), 64)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(tokens, allowed == 1, limit), nil
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.client, []string{redisKeyPrefix + key}, ttl.Milliseconds()).Int64()
+}
+
+func (s *RedisStore) Block(ctx context.Context, key string, d time.Duration) error {
+	return s.client.Set(ctx, redisKeyPrefix+key, 1, d).Err()
+}
+
+func (s *RedisStore) Blocked(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, redisKeyPrefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// キーが存在しない場合は負の値が返る
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *RedisStore) Reset(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = redisKeyPrefix + key
+	}
+	return s.client.Del(ctx, prefixed...).Err()
+}
+
+// Ping は Redis に接続できるか確認する（ヘルスチェック用）
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
@@ -0,0 +1,840 @@
+package routes
+
+import (
+	"api/apierror"
+	"api/bulk"
+	"api/handlers"
+	"api/health"
+	"api/openapi"
+	"api/types"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OpenAPI ドキュメントのパス（GET /openapi.json、GET /docs）
+const (
+	SpecPath = "/openapi.json"
+	DocsPath = "/docs"
+)
+
+var (
+	specOnce sync.Once
+	spec     *openapi.Document
+)
+
+// Spec は API の OpenAPI ドキュメントを返す
+// SetupRoutes で登録したルートと routes_test.go で照合するため、ルートを追加・変更したら apiRoutes も更新する
+func Spec() *openapi.Document {
+	specOnce.Do(func() {
+		spec = openapi.Build(openapi.Spec{
+			Info: openapi.Info{
+				Title:   "CHAP API",
+				Version: "2.0",
+				Description: "CHAPアプリのAPI仕様書（routes/openapi.go から生成）\n\n" +
+					"成功時のレスポンスは `{\"data\": ...}` の形で返す（各エンドポイントのスキーマは data を含む）。\n" +
+					"エラー時は `{\"error\": {\"code\", \"message\", \"details\", \"request_id\"}}` の形で返す（ErrorResponse）。\n" +
+					"クライアントは code で分岐すること（message は変わることがある）。\n" +
+					"認証が必要なエンドポイントは、管理者が利用停止にしたアカウントでは 403 ACCOUNT_SUSPENDED を返す。\n" +
+					"退会手続き中のアカウントでは個人データのエクスポート以外は 403 ACCOUNT_PENDING_DELETION を返す（ログインし直すと退会を取り消す）。\n\n" +
+					"/api/v1 は廃止予定（deprecated）。応答に Deprecation・Sunset（提供終了日）・Link（移行先）ヘッダーを付けている。",
+			},
+			Servers: []openapi.Server{{URL: "https://api.chap-app.jp"}},
+			Error:   openapi.Object{"error": apierror.Body{}},
+			Routes:  apiRoutes,
+		})
+	})
+	return spec
+}
+
+// レスポンスの data の形（gin.H で返すもの）
+var (
+	message = openapi.Object{"message": ""}
+	userRes = openapi.Object{"user": types.User{}}
+	authRes = handlers.AuthResponse{}
+	// 非表示・再表示の結果
+	moderationRes = openapi.Object{"target_type": "", "target_id": uint(0), "valid": false, "resolved_reports": int64(0)}
+)
+
+// page はページングした一覧
+func page(items any) openapi.Object {
+	return openapi.Object{"items": items, "page": 0, "limit": 0, "total": int64(0)}
+}
+
+// 管理者によるユーザーの変更のエラー
+var adminUserErrors = map[int]string{
+	http.StatusBadRequest: "自分自身のアカウントは変更できない",
+	http.StatusNotFound:   "",
+}
+
+// レート制限のかかるエンドポイントのエラー
+var limited = map[int]string{http.StatusTooManyRequests: ""}
+
+func with(errs map[int]string, extra map[int]string) map[int]string {
+	merged := map[int]string{}
+	for k, v := range errs {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+var (
+	contentTypeParam = openapi.PathParam("type", &openapi.Schema{Type: "string", Enum: []any{"post", "thread", "event", "comment"}}, "")
+	fromParam        = openapi.PathParam("from", &openapi.Schema{Type: "integer"}, "この日時（Unix 秒）より後に更新されたもの")
+	paginationParams = []openapi.Parameter{
+		openapi.Query("page", "integer", "1始まり（既定 1）"),
+		openapi.Query("limit", "integer", "1ページの件数（既定 20、最大 100）"),
+	}
+)
+
+const listScopeDescription = "scope が nationwide のカテゴリは常に、local のカテゴリはリクエストの座標から radius_meters 以内のものを返す。\n" +
+	"座標を指定しない場合は nationwide のカテゴリのみ。"
+
+const listQueryDescription = "scope が nationwide のカテゴリは常に、local のカテゴリは lat・lng から radius（省略時は各カテゴリの radius_meters）以内のものを返す。\n" +
+	"lat・lng を指定しない場合は nationwide のカテゴリのみ。since を指定した場合はその日時より後に作成・更新されたもののみ返す。"
+
+// v2 の一覧のクエリ（types.ListQuery）
+var listQueryParams = []openapi.Parameter{
+	openapi.Query("lat", "number", "緯度（lng と同時に指定する）"),
+	openapi.Query("lng", "number", "経度（lat と同時に指定する）"),
+	openapi.Query("radius", "integer", "local のカテゴリの表示範囲（m、最大 50000）"),
+	openapi.Query("since", "integer", "この日時（Unix 秒）より後に作成・更新されたもの"),
+}
+
+// apiRoutes は SetupRoutes で登録する全てのルートの説明
+var apiRoutes = concatRoutes(
+	versioned("/api/v1", true, commonAPIRoutes, v1Routes),
+	versioned("/api/v2", false, commonAPIRoutes, v2Routes),
+	systemRoutes,
+)
+
+// versioned は各ルートのパスに prefix を付ける（deprecated の場合は廃止予定にする）
+func versioned(prefix string, deprecated bool, groups ...[]openapi.Route) []openapi.Route {
+	var routes []openapi.Route
+	for _, group := range groups {
+		for _, r := range group {
+			r.Path = prefix + r.Path
+			r.Deprecated = deprecated
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+func concatRoutes(groups ...[]openapi.Route) []openapi.Route {
+	var routes []openapi.Route
+	for _, group := range groups {
+		routes = append(routes, group...)
+	}
+	return routes
+}
+
+// commonAPIRoutes は v1 と v2 で同じパスのルート（routes.go の commonRoutes で登録する）
+var commonAPIRoutes = []openapi.Route{
+	// 認証
+	{
+		Method: http.MethodPost, Path: "/auth/login", Tag: "auth", Summary: "ログイン",
+		Request:   handlers.LoginRequest{},
+		Responses: map[int]any{http.StatusOK: authRes},
+		Errors: map[int]string{
+			http.StatusUnauthorized:    "メールアドレスまたはパスワードが正しくない",
+			http.StatusForbidden:       "利用停止中のアカウント（ACCOUNT_SUSPENDED）",
+			http.StatusTooManyRequests: "リクエスト数の上限を超えた、またはログイン失敗が続いたため一時的にロックされている（ACCOUNT_LOCKED）",
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/auth/register", Tag: "auth", Summary: "ユーザー登録",
+		Request:   handlers.RegisterRequest{},
+		Responses: map[int]any{http.StatusCreated: authRes},
+		Errors:    with(limited, map[int]string{http.StatusConflict: "同じメールアドレスのアカウントがある"}),
+	},
+	{
+		Method: http.MethodPost, Path: "/auth/google", Tag: "auth", Summary: "Googleログイン",
+		Description: "アクセストークンを Google に問い合わせて検証する。email / name は省略可能（Google から取得した値を優先する）。\n" +
+			"同じメールアドレスの確認済みアカウントがあれば自動で紐付け、なければ新規登録する。",
+		Request:   handlers.GoogleLoginRequest{},
+		Responses: map[int]any{http.StatusOK: authRes},
+		Errors: with(limited, map[int]string{
+			http.StatusUnauthorized: "アクセストークンが無効",
+			http.StatusForbidden:    "利用停止中のアカウント（ACCOUNT_SUSPENDED）",
+			http.StatusConflict:     "同じメールアドレスの未確認アカウントがある（パスワードでログインしてから紐付ける）",
+		}),
+	},
+	{
+		Method: http.MethodPost, Path: "/auth/verify-email", Tag: "auth", Summary: "メールアドレスの確認",
+		Description: "登録時に送信したメールのトークンでメールアドレスを確認済みにする。トークンは1回のみ有効（24時間）。",
+		Request:     handlers.VerifyEmailRequest{},
+		Responses:   map[int]any{http.StatusOK: message},
+		Errors:      limited,
+	},
+	{
+		Method: http.MethodPost, Path: "/auth/password/forgot", Tag: "auth", Summary: "パスワード再設定メールの送信",
+		Description: "登録されていないアドレスでも同じ応答を返す。",
+		Request:     handlers.ForgotPasswordRequest{},
+		Responses:   map[int]any{http.StatusAccepted: message},
+		Errors:      map[int]string{http.StatusTooManyRequests: "同じアドレスへの送信回数の上限を超えた（Retry-After ヘッダーを参照）"},
+	},
+	{
+		Method: http.MethodPost, Path: "/auth/password/reset", Tag: "auth", Summary: "パスワードの再設定",
+		Description: "再設定後はそのユーザーの未使用のトークンと発行済みのログイントークンが全て無効になる。トークンは1回のみ有効（1時間）。",
+		Request:     handlers.ResetPasswordRequest{},
+		Responses:   map[int]any{http.StatusOK: message},
+		Errors:      limited,
+	},
+	{
+		Method: http.MethodGet, Path: "/auth/me", Tag: "auth", Summary: "現在のユーザー情報取得", Auth: true,
+		Responses: map[int]any{http.StatusOK: userRes},
+		Errors:    map[int]string{http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/auth/logout", Tag: "auth", Summary: "ログアウト", Auth: true,
+		Responses: map[int]any{http.StatusOK: message},
+	},
+	{
+		Method: http.MethodPost, Path: "/auth/verify-email/resend", Tag: "auth", Summary: "確認メールの再送", Auth: true,
+		Responses: map[int]any{http.StatusAccepted: message},
+		Errors: map[int]string{
+			http.StatusConflict:        "確認済み",
+			http.StatusTooManyRequests: "同じアドレスへの送信回数の上限を超えた（Retry-After ヘッダーを参照）",
+		},
+	},
+
+	// アカウント
+	{
+		Method: http.MethodPut, Path: "/me", Tag: "account", Summary: "プロフィールの編集", Auth: true,
+		Request:   handlers.UpdateProfileRequest{},
+		Responses: map[int]any{http.StatusOK: userRes},
+		Errors:    limited,
+	},
+	{
+		Method: http.MethodDelete, Path: "/me", Tag: "account", Summary: "退会", Auth: true,
+		Description: "すぐには削除せず、猶予期間（既定30日）の経過後に削除する。猶予期間中にログインすると退会を取り消せる。\n" +
+			"削除時、投稿等は保持方針に従って匿名化または削除され、いいねは取り消される。",
+		Request: handlers.DeleteAccountRequest{}, RequestOptional: true,
+		Responses: map[int]any{http.StatusOK: openapi.Object{"message": "", "deletion_scheduled_at": time.Time{}}},
+		Errors:    with(limited, map[int]string{http.StatusUnauthorized: "パスワードが正しくない"}),
+	},
+	{
+		Method: http.MethodPost, Path: "/me/avatar", Tag: "account", Summary: "アバター画像のアップロード", Auth: true,
+		Upload:    "avatar",
+		Responses: map[int]any{http.StatusOK: userRes},
+		Errors:    with(limited, map[int]string{http.StatusRequestEntityTooLarge: "", http.StatusUnsupportedMediaType: ""}),
+	},
+	{
+		Method: http.MethodPut, Path: "/me/password", Tag: "account", Summary: "パスワードの変更", Auth: true,
+		Description: "変更前に発行したトークンは全て無効になる。新しいトークンを返し、Cookie も置き換える。",
+		Request:     handlers.ChangePasswordRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"message": "", "token": ""}},
+		Errors:      with(limited, map[int]string{http.StatusUnauthorized: "現在のパスワードが正しくない"}),
+	},
+	{
+		Method: http.MethodGet, Path: "/me/export", Tag: "account", Summary: "個人データのエクスポート", Auth: true,
+		Description: "有効なエクスポートがあればその情報を返す。なければバックグラウンドで ZIP（JSON と画像）の作成を始めて 202 を返す。\n" +
+			"完了後は7日間ダウンロードできる。",
+		Responses: map[int]any{
+			http.StatusOK:       openapi.Object{"export": types.DataExport{}, "download_url": ""},
+			http.StatusAccepted: openapi.Object{"export": types.DataExport{}},
+		},
+		Errors: with(limited, map[int]string{http.StatusServiceUnavailable: ""}),
+	},
+	{
+		Method: http.MethodGet, Path: "/me/export/download", Tag: "account", Summary: "エクスポートのダウンロード", Auth: true,
+		Responses: map[int]any{http.StatusOK: openapi.File("application/zip")},
+		Errors:    map[int]string{http.StatusNotFound: "ダウンロードできるエクスポートがない"},
+	},
+	{
+		Method: http.MethodGet, Path: "/me/identities", Tag: "account", Summary: "ログイン方法の一覧", Auth: true,
+		Responses: map[int]any{http.StatusOK: openapi.Object{"identities": []types.Identity{}}},
+	},
+	{
+		Method: http.MethodPost, Path: "/me/identities/google", Tag: "account", Summary: "Google アカウントの紐付け", Auth: true,
+		Request:   handlers.LinkGoogleRequest{},
+		Responses: map[int]any{http.StatusCreated: message},
+		Errors: with(limited, map[int]string{
+			http.StatusConflict: "既に Google アカウントが紐付いている、またはその Google アカウントは他のユーザーに紐付いている",
+		}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/me/identities/:provider", Tag: "account", Summary: "ログイン方法の紐付け解除", Auth: true,
+		Params:    []openapi.Parameter{openapi.PathParam("provider", &openapi.Schema{Type: "string", Enum: []any{types.ProviderEmail, types.ProviderGoogle}}, "")},
+		Responses: map[int]any{http.StatusOK: message},
+		Errors: with(limited, map[int]string{
+			http.StatusNotFound: "紐付いていない",
+			http.StatusConflict: "最後のログイン方法は解除できない",
+		}),
+	},
+
+	// カテゴリ
+	{
+		Method: http.MethodGet, Path: "/categories", Tag: "categories", Summary: "カテゴリ一覧取得",
+		Description: "投稿・スレッド・イベントに指定できるカテゴリを表示順に返す。\n" +
+			"作成・編集時に一覧に無いカテゴリを指定すると 400 VALIDATION_FAILED になる（未指定の場合は other）。",
+		Responses: map[int]any{http.StatusOK: []types.CategoryResponse{}},
+	},
+
+	// ソーシャルセンシング
+	{
+		Method: http.MethodGet, Path: "/social-sensing/heatmap", Tag: "social-sensing", Summary: "投稿のヒートマップ",
+		Description: "投稿の分布を Gemini で要約したもの（1日キャッシュする）。",
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"summary": "", "geojson": map[string]any{}}},
+	},
+
+	// モデレーション（モデレーター・管理者のみ）
+	{
+		Method: http.MethodGet, Path: "/moderation/reports", Tag: "moderation", Summary: "通報の一覧", Auth: true,
+		Params: append([]openapi.Parameter{
+			openapi.Query("status", "string", "open（既定）/ actioned / dismissed"),
+		}, paginationParams...),
+		Responses: map[int]any{http.StatusOK: page([]types.Report{})},
+		Errors:    map[int]string{http.StatusBadRequest: "", http.StatusForbidden: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/moderation/reports/:id/dismiss", Tag: "moderation", Summary: "通報の却下", Auth: true,
+		Responses: map[int]any{http.StatusOK: openapi.Object{"report": types.Report{}}},
+		Errors:    map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusConflict: "対応済み"},
+	},
+	{
+		Method: http.MethodPost, Path: "/moderation/content/:type/:id/hide", Tag: "moderation", Summary: "投稿の非表示", Auth: true,
+		Description: "対象を非表示にし、未対応の通報を対応済みにする。",
+		Params:      []openapi.Parameter{contentTypeParam},
+		Responses:   map[int]any{http.StatusOK: moderationRes},
+		Errors:      map[int]string{http.StatusBadRequest: "", http.StatusForbidden: "", http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/moderation/content/:type/:id/restore", Tag: "moderation", Summary: "投稿の再表示", Auth: true,
+		Description: "対象を再表示し、未対応の通報は却下扱いにする。",
+		Params:      []openapi.Parameter{contentTypeParam},
+		Responses:   map[int]any{http.StatusOK: moderationRes},
+		Errors:      map[int]string{http.StatusBadRequest: "", http.StatusForbidden: "", http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/moderation/filter-results", Tag: "moderation", Summary: "コンテンツフィルタの判定の一覧", Auth: true,
+		Params: append([]openapi.Parameter{
+			openapi.Query("decision", "string", "hold（既定）/ reject"),
+		}, paginationParams...),
+		Responses: map[int]any{http.StatusOK: page([]types.FilterResult{})},
+		Errors:    map[int]string{http.StatusBadRequest: "", http.StatusForbidden: ""},
+	},
+}
+
+// v1Routes は /api/v1 のみのルート（廃止予定。v2Routes に移行する）
+var v1Routes = []openapi.Route{
+	// ユーザー
+	{
+		Method: http.MethodGet, Path: "/user/:id", Tag: "users", Summary: "ユーザーの公開プロフィール取得",
+		Description: "メールアドレス等は含まない。",
+		Responses:   map[int]any{http.StatusOK: types.UserProfile{}},
+		Errors:      map[int]string{http.StatusBadRequest: "", http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/user/:id/posts", Tag: "users", Summary: "ユーザーの投稿一覧取得",
+		Params:    paginationParams,
+		Responses: map[int]any{http.StatusOK: page([]types.PostResponse{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/user/:id/threads", Tag: "users", Summary: "ユーザーのスレッド一覧取得",
+		Params:    paginationParams,
+		Responses: map[int]any{http.StatusOK: page([]types.ThreadResponse{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/user/:id/events", Tag: "users", Summary: "ユーザーのイベント一覧取得",
+		Params:    paginationParams,
+		Responses: map[int]any{http.StatusOK: page([]types.EventResponse{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/user/:id/comments", Tag: "users", Summary: "ユーザーのコメント一覧取得",
+		Params:    paginationParams,
+		Responses: map[int]any{http.StatusOK: page([]types.CommentResponse{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+
+	// 投稿
+	{
+		Method: http.MethodPost, Path: "/getall/post", Tag: "posts", Summary: "投稿一覧取得",
+		Description: listScopeDescription,
+		Request:     types.Coordinate{}, RequestOptional: true,
+		Responses: map[int]any{http.StatusOK: []types.PostResponse{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/create/post", Tag: "posts", Summary: "投稿の作成", Auth: true,
+		Description: "コンテンツフィルタで保留になった場合、またはカテゴリの moderation が review の場合は valid=false で保存する。",
+		Request:     types.CreatePostRequest{},
+		Responses:   map[int]any{http.StatusCreated: types.PostResponse{}},
+		Errors:      with(limited, map[int]string{http.StatusUnprocessableEntity: "コンテンツフィルタで拒否された"}),
+	},
+	{
+		Method: http.MethodGet, Path: "/update/post/:from", Tag: "posts", Summary: "更新された投稿の取得", Auth: true,
+		Params:    []openapi.Parameter{fromParam},
+		Responses: map[int]any{http.StatusOK: []types.PostResponse{}},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodPut, Path: "/edit/post/:id", Tag: "posts", Summary: "投稿の編集", Auth: true,
+		Description: "指定した項目のみ更新する。attachment_ids を指定した場合は添付ファイルを置き換える。",
+		Request:     types.UpdatePostRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"post": types.PostResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusUnprocessableEntity: ""}),
+	},
+	{
+		Method: http.MethodPatch, Path: "/edit/post/:id", Tag: "posts", Summary: "投稿の編集", Auth: true,
+		Description: "PUT と同じ（指定した項目のみ更新する）。",
+		Request:     types.UpdatePostRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"post": types.PostResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusUnprocessableEntity: ""}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/delete/post/:id", Tag: "posts", Summary: "投稿の削除", Auth: true,
+		Responses: map[int]any{http.StatusOK: message},
+		Errors:    with(limited, map[int]string{http.StatusForbidden: "投稿者以外", http.StatusNotFound: ""}),
+	},
+
+	// スレッド
+	{
+		Method: http.MethodPost, Path: "/getall/thread", Tag: "threads", Summary: "スレッド一覧取得",
+		Description: listScopeDescription,
+		Request:     types.Coordinate{}, RequestOptional: true,
+		Responses: map[int]any{http.StatusOK: []types.ThreadResponse{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/thread/:id/details", Tag: "threads", Summary: "スレッド詳細取得",
+		Description: "スレッド本体とレス一覧を返す。",
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"thread": types.ThreadResponse{}, "replies": []types.CommentResponse{}}},
+		Errors:      map[int]string{http.StatusBadRequest: "", http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/create/thread", Tag: "threads", Summary: "スレッドの作成", Auth: true,
+		Description: "コンテンツフィルタで保留になった場合、またはカテゴリの moderation が review の場合は valid=false で保存する。",
+		Request:     types.CreateThreadRequest{},
+		Responses:   map[int]any{http.StatusCreated: types.ThreadResponse{}},
+		Errors:      with(limited, map[int]string{http.StatusUnprocessableEntity: "コンテンツフィルタで拒否された"}),
+	},
+	{
+		Method: http.MethodGet, Path: "/update/thread/:from", Tag: "threads", Summary: "更新されたスレッドの取得", Auth: true,
+		Params:    []openapi.Parameter{fromParam},
+		Responses: map[int]any{http.StatusOK: []types.ThreadResponse{}},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodPut, Path: "/edit/thread/:id", Tag: "threads", Summary: "スレッドの編集", Auth: true,
+		Description: "指定した項目のみ更新する。attachment_ids を指定した場合は添付ファイルを置き換える。",
+		Request:     types.UpdateThreadRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"thread": types.ThreadResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusUnprocessableEntity: ""}),
+	},
+	{
+		Method: http.MethodPatch, Path: "/edit/thread/:id", Tag: "threads", Summary: "スレッドの編集", Auth: true,
+		Description: "PUT と同じ（指定した項目のみ更新する）。",
+		Request:     types.UpdateThreadRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"thread": types.ThreadResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusUnprocessableEntity: ""}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/delete/thread/:id", Tag: "threads", Summary: "スレッドの削除", Auth: true,
+		Responses: map[int]any{http.StatusOK: message},
+		Errors:    with(limited, map[int]string{http.StatusForbidden: "投稿者以外", http.StatusNotFound: ""}),
+	},
+
+	// イベント
+	{
+		Method: http.MethodPost, Path: "/getall/event", Tag: "events", Summary: "イベント一覧取得",
+		Description: listScopeDescription,
+		Request:     types.Coordinate{}, RequestOptional: true,
+		Responses: map[int]any{http.StatusOK: []types.EventResponse{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/create/event", Tag: "events", Summary: "イベントの作成", Auth: true,
+		Description: "コンテンツフィルタで保留になった場合、またはカテゴリの moderation が review の場合は valid=false で保存する。",
+		Request:     types.CreateEventRequest{},
+		Responses:   map[int]any{http.StatusCreated: types.EventResponse{}},
+		Errors:      with(limited, map[int]string{http.StatusUnprocessableEntity: "コンテンツフィルタで拒否された"}),
+	},
+	{
+		Method: http.MethodGet, Path: "/update/event/:from", Tag: "events", Summary: "更新されたイベントの取得", Auth: true,
+		Params:    []openapi.Parameter{fromParam},
+		Responses: map[int]any{http.StatusOK: []types.EventResponse{}},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodPut, Path: "/edit/event/:id", Tag: "events", Summary: "イベントの編集", Auth: true,
+		Description: "指定した項目のみ更新する。attachment_ids を指定した場合は添付ファイルを置き換える。",
+		Request:     types.UpdateEventRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"event": types.EventResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusUnprocessableEntity: ""}),
+	},
+	{
+		Method: http.MethodPatch, Path: "/edit/event/:id", Tag: "events", Summary: "イベントの編集", Auth: true,
+		Description: "PUT と同じ（指定した項目のみ更新する）。",
+		Request:     types.UpdateEventRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"event": types.EventResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusUnprocessableEntity: ""}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/delete/event/:id", Tag: "events", Summary: "イベントの削除", Auth: true,
+		Responses: map[int]any{http.StatusOK: message},
+		Errors:    with(limited, map[int]string{http.StatusForbidden: "投稿者以外", http.StatusNotFound: ""}),
+	},
+
+	// コメント
+	{
+		Method: http.MethodGet, Path: "/comments/:thread_id", Tag: "comments", Summary: "スレッドのコメント一覧取得",
+		Responses: map[int]any{http.StatusOK: openapi.Object{"comments": []types.CommentResponse{}}},
+		Errors:    map[int]string{http.StatusBadRequest: "", http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/create/comment", Tag: "comments", Summary: "コメントの作成", Auth: true,
+		Description: "thread_id が必要。",
+		Request:     types.CreateCommentRequest{},
+		Responses:   map[int]any{http.StatusCreated: openapi.Object{"message": "", "comment": types.CommentResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusNotFound: "", http.StatusUnprocessableEntity: "コンテンツフィルタで拒否された"}),
+	},
+	{
+		Method: http.MethodPost, Path: "/thread/:id/reply", Tag: "comments", Summary: "スレッドへのレス投稿", Auth: true,
+		Description: "パスのスレッドにコメントする（thread_id は不要）。",
+		Request:     types.CreateCommentRequest{},
+		Responses:   map[int]any{http.StatusCreated: openapi.Object{"message": "", "comment": types.CommentResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusNotFound: "", http.StatusUnprocessableEntity: "コンテンツフィルタで拒否された"}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/delete/comment/:id", Tag: "comments", Summary: "コメントの削除", Auth: true,
+		Responses: map[int]any{http.StatusOK: message},
+		Errors:    with(limited, map[int]string{http.StatusForbidden: "投稿者以外", http.StatusBadRequest: "", http.StatusNotFound: ""}),
+	},
+
+	// 添付ファイル・通報
+	{
+		Method: http.MethodPost, Path: "/upload", Tag: "attachments", Summary: "添付ファイル（画像）のアップロード", Auth: true,
+		Description: "返された id を投稿の attachment_ids に指定する。",
+		Upload:      "file",
+		Responses:   map[int]any{http.StatusCreated: types.Attachment{}},
+		Errors:      with(limited, map[int]string{http.StatusRequestEntityTooLarge: "", http.StatusUnsupportedMediaType: ""}),
+	},
+	{
+		Method: http.MethodPost, Path: "/report", Tag: "moderation", Summary: "通報", Auth: true,
+		Description: "一定数のユーザーから通報された投稿はモデレーターの確認を待たずに非表示にする（hidden=true）。",
+		Request:     handlers.ReportRequest{},
+		Responses:   map[int]any{http.StatusCreated: openapi.Object{"report": types.Report{}, "hidden": false}},
+		Errors:      with(limited, map[int]string{http.StatusNotFound: "", http.StatusConflict: "既に通報している"}),
+	},
+}
+
+// v2Routes は /api/v2 のみのルート
+var v2Routes = []openapi.Route{
+	// 投稿
+	{
+		Method: http.MethodGet, Path: "/posts", Tag: "posts", Summary: "投稿一覧取得",
+		Description: listQueryDescription,
+		Params:      listQueryParams,
+		Responses:   map[int]any{http.StatusOK: []types.PostResponse{}},
+		Errors:      map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/posts/:id", Tag: "posts", Summary: "投稿の取得",
+		Responses: map[int]any{http.StatusOK: types.PostResponse{}},
+		Errors:    map[int]string{http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/posts", Tag: "posts", Summary: "投稿の作成", Auth: true,
+		Description: "コンテンツフィルタで保留になった場合、またはカテゴリの moderation が review の場合は valid=false で保存する。",
+		Request:     types.CreatePostRequest{},
+		Responses:   map[int]any{http.StatusCreated: types.PostResponse{}},
+		Errors:      with(limited, map[int]string{http.StatusUnprocessableEntity: "コンテンツフィルタで拒否された"}),
+	},
+	{
+		Method: http.MethodPatch, Path: "/posts/:id", Tag: "posts", Summary: "投稿の編集", Auth: true,
+		Description: "指定した項目のみ更新する。attachment_ids を指定した場合は添付ファイルを置き換える。",
+		Request:     types.UpdatePostRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"post": types.PostResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusUnprocessableEntity: ""}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/posts/:id", Tag: "posts", Summary: "投稿の削除", Auth: true,
+		Responses: map[int]any{http.StatusOK: message},
+		Errors:    with(limited, map[int]string{http.StatusForbidden: "投稿者以外", http.StatusNotFound: ""}),
+	},
+
+	// スレッド
+	{
+		Method: http.MethodGet, Path: "/threads", Tag: "threads", Summary: "スレッド一覧取得",
+		Description: listQueryDescription,
+		Params:      listQueryParams,
+		Responses:   map[int]any{http.StatusOK: []types.ThreadResponse{}},
+		Errors:      map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/threads/:id", Tag: "threads", Summary: "スレッドの取得",
+		Description: "レスは /threads/{id}/comments で取得する。",
+		Responses:   map[int]any{http.StatusOK: types.ThreadResponse{}},
+		Errors:      map[int]string{http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/threads", Tag: "threads", Summary: "スレッドの作成", Auth: true,
+		Description: "コンテンツフィルタで保留になった場合、またはカテゴリの moderation が review の場合は valid=false で保存する。",
+		Request:     types.CreateThreadRequest{},
+		Responses:   map[int]any{http.StatusCreated: types.ThreadResponse{}},
+		Errors:      with(limited, map[int]string{http.StatusUnprocessableEntity: "コンテンツフィルタで拒否された"}),
+	},
+	{
+		Method: http.MethodPatch, Path: "/threads/:id", Tag: "threads", Summary: "スレッドの編集", Auth: true,
+		Description: "指定した項目のみ更新する。attachment_ids を指定した場合は添付ファイルを置き換える。",
+		Request:     types.UpdateThreadRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"thread": types.ThreadResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusUnprocessableEntity: ""}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/threads/:id", Tag: "threads", Summary: "スレッドの削除", Auth: true,
+		Responses: map[int]any{http.StatusOK: message},
+		Errors:    with(limited, map[int]string{http.StatusForbidden: "投稿者以外", http.StatusNotFound: ""}),
+	},
+
+	// イベント
+	{
+		Method: http.MethodGet, Path: "/events", Tag: "events", Summary: "イベント一覧取得",
+		Description: listQueryDescription,
+		Params:      listQueryParams,
+		Responses:   map[int]any{http.StatusOK: []types.EventResponse{}},
+		Errors:      map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/events/:id", Tag: "events", Summary: "イベントの取得",
+		Responses: map[int]any{http.StatusOK: types.EventResponse{}},
+		Errors:    map[int]string{http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/events", Tag: "events", Summary: "イベントの作成", Auth: true,
+		Description: "コンテンツフィルタで保留になった場合、またはカテゴリの moderation が review の場合は valid=false で保存する。",
+		Request:     types.CreateEventRequest{},
+		Responses:   map[int]any{http.StatusCreated: types.EventResponse{}},
+		Errors:      with(limited, map[int]string{http.StatusUnprocessableEntity: "コンテンツフィルタで拒否された"}),
+	},
+	{
+		Method: http.MethodPatch, Path: "/events/:id", Tag: "events", Summary: "イベントの編集", Auth: true,
+		Description: "指定した項目のみ更新する。attachment_ids を指定した場合は添付ファイルを置き換える。",
+		Request:     types.UpdateEventRequest{},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"event": types.EventResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusForbidden: "", http.StatusNotFound: "", http.StatusUnprocessableEntity: ""}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/events/:id", Tag: "events", Summary: "イベントの削除", Auth: true,
+		Responses: map[int]any{http.StatusOK: message},
+		Errors:    with(limited, map[int]string{http.StatusForbidden: "投稿者以外", http.StatusNotFound: ""}),
+	},
+
+	// コメント
+	{
+		Method: http.MethodGet, Path: "/threads/:id/comments", Tag: "comments", Summary: "スレッドのレス一覧取得",
+		Description: "古い順に返す。",
+		Responses:   map[int]any{http.StatusOK: []types.CommentResponse{}},
+		Errors:      map[int]string{http.StatusBadRequest: "", http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/threads/:id/comments", Tag: "comments", Summary: "スレッドへのレス投稿", Auth: true,
+		Description: "パスのスレッドにコメントする（thread_id は不要）。",
+		Request:     types.CreateCommentRequest{},
+		Responses:   map[int]any{http.StatusCreated: openapi.Object{"message": "", "comment": types.CommentResponse{}}},
+		Errors:      with(limited, map[int]string{http.StatusNotFound: "", http.StatusUnprocessableEntity: "コンテンツフィルタで拒否された"}),
+	},
+	{
+		Method: http.MethodDelete, Path: "/comments/:id", Tag: "comments", Summary: "コメントの削除", Auth: true,
+		Responses: map[int]any{http.StatusOK: message},
+		Errors:    with(limited, map[int]string{http.StatusForbidden: "投稿者以外", http.StatusBadRequest: "", http.StatusNotFound: ""}),
+	},
+
+	// ユーザー
+	{
+		Method: http.MethodGet, Path: "/users/:id", Tag: "users", Summary: "ユーザーの公開プロフィール取得",
+		Description: "メールアドレス等は含まない。",
+		Responses:   map[int]any{http.StatusOK: types.UserProfile{}},
+		Errors:      map[int]string{http.StatusBadRequest: "", http.StatusNotFound: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/users/:id/posts", Tag: "users", Summary: "ユーザーの投稿一覧取得",
+		Params:    paginationParams,
+		Responses: map[int]any{http.StatusOK: page([]types.PostResponse{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/users/:id/threads", Tag: "users", Summary: "ユーザーのスレッド一覧取得",
+		Params:    paginationParams,
+		Responses: map[int]any{http.StatusOK: page([]types.ThreadResponse{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/users/:id/events", Tag: "users", Summary: "ユーザーのイベント一覧取得",
+		Params:    paginationParams,
+		Responses: map[int]any{http.StatusOK: page([]types.EventResponse{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/users/:id/comments", Tag: "users", Summary: "ユーザーのコメント一覧取得",
+		Params:    paginationParams,
+		Responses: map[int]any{http.StatusOK: page([]types.CommentResponse{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+
+	// 添付ファイル・通報
+	{
+		Method: http.MethodPost, Path: "/attachments", Tag: "attachments", Summary: "添付ファイル（画像）のアップロード", Auth: true,
+		Description: "返された id を投稿の attachment_ids に指定する。",
+		Upload:      "file",
+		Responses:   map[int]any{http.StatusCreated: types.Attachment{}},
+		Errors:      with(limited, map[int]string{http.StatusRequestEntityTooLarge: "", http.StatusUnsupportedMediaType: ""}),
+	},
+	{
+		Method: http.MethodPost, Path: "/reports", Tag: "moderation", Summary: "通報", Auth: true,
+		Description: "一定数のユーザーから通報された投稿はモデレーターの確認を待たずに非表示にする（hidden=true）。",
+		Request:     handlers.ReportRequest{},
+		Responses:   map[int]any{http.StatusCreated: openapi.Object{"report": types.Report{}, "hidden": false}},
+		Errors:      with(limited, map[int]string{http.StatusNotFound: "", http.StatusConflict: "既に通報している"}),
+	},
+
+	// 管理者のみ
+	{
+		Method: http.MethodGet, Path: "/admin/stats", Tag: "admin", Summary: "システムの統計",
+		Description: "ユーザーの状態・権限毎の数、種類毎の投稿等の数（表示中・非表示・削除済み）、未対応の通報の数。",
+		Auth:        true,
+		Responses:   map[int]any{http.StatusOK: types.AdminStats{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/users", Tag: "admin", Summary: "ユーザーの検索", Auth: true,
+		Description: "登録の新しい順に返す。status=deleted は退会手続き中のユーザー。",
+		Params: append([]openapi.Parameter{
+			openapi.Query("q", "string", "名前・メールアドレスの部分一致"),
+			openapi.Query("role", "string", "user / moderator / admin"),
+			openapi.Query("status", "string", "active / suspended / banned / deleted"),
+		}, paginationParams...),
+		Responses: map[int]any{http.StatusOK: page([]types.AdminUserResponse{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/users/:id/suspend", Tag: "admin", Summary: "ユーザーの利用停止（期限付き）", Auth: true,
+		Description: "until までログインと認証が必要なリクエストを 403 ACCOUNT_SUSPENDED で拒否する（発行済みのトークンも含む）。",
+		Request:     types.SuspendUserRequest{},
+		Responses:   map[int]any{http.StatusOK: types.AdminUserResponse{}},
+		Errors:      adminUserErrors,
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/users/:id/ban", Tag: "admin", Summary: "ユーザーの利用停止（無期限）", Auth: true,
+		Description: "reinstate で解除するまでログインと認証が必要なリクエストを 403 ACCOUNT_SUSPENDED で拒否する。",
+		Request:     types.BanUserRequest{},
+		Responses:   map[int]any{http.StatusOK: types.AdminUserResponse{}},
+		Errors:      adminUserErrors,
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/users/:id/reinstate", Tag: "admin", Summary: "利用停止の解除", Auth: true,
+		Responses: map[int]any{http.StatusOK: types.AdminUserResponse{}},
+		Errors:    with(adminUserErrors, map[int]string{http.StatusConflict: "利用停止中ではない"}),
+	},
+	{
+		Method: http.MethodPut, Path: "/admin/users/:id/role", Tag: "admin", Summary: "権限の変更", Auth: true,
+		Request:   types.UpdateRoleRequest{},
+		Responses: map[int]any{http.StatusOK: types.AdminUserResponse{}},
+		Errors:    adminUserErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/deleted/:type", Tag: "admin", Summary: "削除済みの投稿等の一覧", Auth: true,
+		Description: "削除の新しい順に返す。",
+		Params:      append([]openapi.Parameter{contentTypeParam}, paginationParams...),
+		Responses:   map[int]any{http.StatusOK: page([]types.DeletedContentResponse{})},
+		Errors:      map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/deleted/:type/:id/restore", Tag: "admin", Summary: "削除の取り消し", Auth: true,
+		Description: "削除時に消えた添付ファイルは戻らない。",
+		Params:      []openapi.Parameter{contentTypeParam},
+		Responses:   map[int]any{http.StatusOK: openapi.Object{"target_type": "", "target_id": uint(0)}},
+		Errors: map[int]string{
+			http.StatusBadRequest: "",
+			http.StatusNotFound:   "",
+			http.StatusConflict:   "退会したユーザーの投稿等で、保持方針に従って内容を消去している",
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/audit-logs", Tag: "admin", Summary: "監査ログの一覧", Auth: true,
+		Description: "新しい順に返す。投稿等の作成・編集・削除、通報、モデレーション、アカウントの変更（パスワードは記録しない）、管理者の操作を記録している（action は post.update・user.suspend 等）。\n" +
+			"before・after は編集の場合は変更した項目のみ、作成は after のみ、削除は before のみ。actor_id が null のものは CLI・定期実行・自動の処理。",
+		Params: append([]openapi.Parameter{
+			openapi.Query("actor_id", "string", "操作したユーザー ID"),
+			openapi.Query("action", "string", "操作（post. のように . で終わる場合は前方一致）"),
+			openapi.Query("target_type", "string", "対象の種類（post / thread / event / comment / report / user / content）"),
+			openapi.Query("target_id", "string", "対象の ID"),
+			openapi.Query("request_id", "string", "リクエスト ID（X-Request-ID）"),
+			openapi.Query("from", "integer", "日時（Unix 秒）がこれ以降"),
+			openapi.Query("to", "integer", "日時（Unix 秒）がこれより前"),
+		}, paginationParams...),
+		Responses: map[int]any{http.StatusOK: page([]types.AuditLog{})},
+		Errors:    map[int]string{http.StatusBadRequest: ""},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/export", Tag: "admin", Summary: "投稿・スレッド・イベントの一括エクスポート", Auth: true,
+		Description: "条件に合うものを ID 順に書き出す。座標は公開 API と同じく投稿者が選んだ精度でぼかす。\n" +
+			"GeoJSON は Point の FeatureCollection、NDJSON は1行に1件、CSV はヘッダー行付き（tags は | 区切り）。",
+		Params: []openapi.Parameter{
+			openapi.Query("format", "string", "geojson（既定）/ ndjson / csv"),
+			openapi.Query("type", "string", "post・thread・event のカンマ区切り（省略時は全て）"),
+			openapi.Query("bbox", "string", "範囲（minLng,minLat,maxLng,maxLat）"),
+			openapi.Query("from", "integer", "作成日時（Unix 秒）がこれ以降"),
+			openapi.Query("to", "integer", "作成日時（Unix 秒）がこれより前"),
+			openapi.Query("category", "string", "カテゴリ ID のカンマ区切り"),
+			openapi.Query("include_hidden", "boolean", "非表示のものも含める"),
+		},
+		Responses: map[int]any{http.StatusOK: openapi.Files{"application/geo+json", "application/x-ndjson", "text/csv"}},
+		Errors:    map[int]string{http.StatusBadRequest: "", http.StatusForbidden: ""},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/import", Tag: "admin", Summary: "投稿・スレッド・イベントの一括取り込み", Auth: true,
+		Description: "エクスポートと同じ形式のファイルを取り込む（type・content・category・座標・precision・tags・event_date 以外の項目は無視する）。\n" +
+			"誤りのある行と、種類・本文・座標・開催日時が既存のものやファイル内の前の行と同じ行は飛ばし、行番号を errors・duplicates で返す。\n" +
+			"dry_run=true の場合は検証のみ行い保存しない。コンテンツフィルタにはかけない（review のカテゴリは非表示で保存する）。",
+		Upload: "file",
+		Params: []openapi.Parameter{
+			openapi.Query("format", "string", "geojson / ndjson / csv（省略時はファイルの拡張子から判定）"),
+			openapi.Query("type", "string", "type の無い行の種類（post / thread / event）"),
+			openapi.Query("user_id", "string", "投稿者のユーザー ID（省略時は取り込んだ管理者）"),
+			openapi.Query("dry_run", "boolean", "検証のみ行い保存しない"),
+		},
+		Responses: map[int]any{http.StatusOK: bulk.ImportResult{}},
+		Errors: with(limited, map[int]string{
+			http.StatusForbidden:             "",
+			http.StatusNotFound:              "user_id のユーザーが存在しない",
+			http.StatusRequestEntityTooLarge: "",
+		}),
+	},
+}
+
+// systemRoutes はバージョンの無いルート（運用・ドキュメント）
+var systemRoutes = []openapi.Route{
+	{
+		Method: http.MethodGet, Path: "/livez", Tag: "system", Summary: "生存確認",
+		Responses: map[int]any{http.StatusOK: openapi.Raw{Body: openapi.Object{"status": ""}}},
+	},
+	{
+		Method: http.MethodGet, Path: "/health", Tag: "system", Summary: "生存確認（互換性のため残している）",
+		Responses: map[int]any{http.StatusOK: openapi.Raw{Body: openapi.Object{"status": ""}}},
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", Tag: "system", Summary: "リクエストを受け付けられるか",
+		Description: "依存先のチェック結果を返す。必須のチェックが失敗している場合と終了処理中は 503。",
+		Responses: map[int]any{
+			http.StatusOK:                 openapi.Raw{Body: health.Report{}},
+			http.StatusServiceUnavailable: openapi.Raw{Body: health.Report{}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/metrics", Tag: "system", Summary: "Prometheus メトリクス",
+		Responses: map[int]any{http.StatusOK: openapi.File("text/plain")},
+	},
+	{
+		Method: http.MethodGet, Path: SpecPath, Tag: "system", Summary: "この OpenAPI ドキュメント",
+		Responses: map[int]any{http.StatusOK: openapi.Raw{Body: map[string]any{}}},
+	},
+	{
+		Method: http.MethodGet, Path: DocsPath, Tag: "system", Summary: "API ドキュメント（Swagger UI）",
+		Responses: map[int]any{http.StatusOK: openapi.File("text/html")},
+	},
+}
@@ -1,80 +1,240 @@
 package routes
 
 import (
+	"api/apierror"
+	"api/config"
 	"api/handlers"
+	"api/health"
 	"api/middleware"
+	"api/openapi"
+	"api/storage"
+	"api/telemetry"
+	"api/types"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// v1DeprecatedAt は /api/v1 を廃止予定にした日時（Deprecation ヘッダー）
+var v1DeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
 // SetupRoutes configures all API routes
-func SetupRoutes(r *gin.Engine) {
-	// プリフライトリクエスト（OPTIONS）の明示的なハンドリング
-	r.OPTIONS("/*path", func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Requested-With")
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Status(204)
-	})
+// プリフライト（OPTIONS）は呼び出し側で設定する middleware.CORS が応答する
+// ルートを追加・変更した場合は openapi.go の apiRoutes も更新する（routes_test.go で照合する）
+func SetupRoutes(r *gin.Engine, cfg *config.Config) {
+	// Prometheus メトリクス
+	r.GET("/metrics", telemetry.Handler())
+
+	// API ドキュメント（routes/openapi.go から生成する）
+	r.GET(SpecPath, openapi.Handler(Spec()))
+	r.GET(DocsPath, openapi.UI("CHAP API", SpecPath))
 
-	// API v1グループ
-	v1 := r.Group("/api/v1")
+	// レート制限（制限値は RATE_LIMIT_<GROUP> で変更可能。v1 と v2 で同じ制限を共有する）
+	authLimit := middleware.RateLimit("auth")
+	writeLimit := middleware.RateLimit("write")
+	requireAuth := middleware.AuthMiddleware(cfg.Auth)
+	allowPendingDeletion := middleware.AllowPendingDeletion(cfg.Auth)
+
+	// API v1グループ（v2 への移行期間中は Deprecation / Sunset ヘッダーを付けて残す）
+	v1 := r.Group("/api/v1", middleware.Deprecated(v1DeprecatedAt, cfg.Server.V1SunsetTime(), "/api/v2"))
+	commonRoutes(v1, requireAuth, allowPendingDeletion, authLimit, writeLimit)
 	{
 		v1.GET("/thread/:id/details", handlers.GetThreadDetails)
 		v1.GET("/comments/:thread_id", handlers.GetCommentsByThreadID)
-		v1.POST("/auth/login", handlers.Login)
-		v1.POST("/auth/register", handlers.Register)
-		v1.POST("/auth/google", handlers.GoogleLogin)
 
 		// ユーザー関連（認証不要）
 		v1.GET("/user/:id", handlers.GetUserByID)
+		v1.GET("/user/:id/posts", handlers.GetUserPosts)
+		v1.GET("/user/:id/threads", handlers.GetUserThreads)
+		v1.GET("/user/:id/events", handlers.GetUserEvents)
+		v1.GET("/user/:id/comments", handlers.GetUserComments)
 		v1.POST("/getall/post", handlers.GetAllPosts)
 		v1.POST("/getall/event", handlers.GetAllEvents)
 		v1.POST("/getall/thread", handlers.GetAllThreads)
-		v1.GET("/social-sensing/heatmap", handlers.GetSocialSensingHeatmap)
 
 		// 認証が必要なエンドポイント
 		auth := v1.Group("")
-		auth.Use(middleware.AuthMiddleware())
+		auth.Use(requireAuth)
 		{
-			// 現在のユーザー情報取得
-			auth.GET("/auth/me", handlers.GetCurrentUser)
-
-			// ログアウト
-			auth.POST("/auth/logout", handlers.Logout)
+			// 添付ファイルのアップロード
+			auth.POST("/upload", writeLimit, handlers.UploadAttachment)
 
-			// 投稿関連
-			auth.POST("/create/post", handlers.CreatePost)
-			auth.GET("/update/post/:id", handlers.GetUpdatePost)
-			auth.PUT("/edit/post/:id", handlers.EditPost)
-			auth.DELETE("/delete/post/:id", handlers.DeletePost)
+			// 投稿関連（編集は PUT・PATCH とも指定した項目のみ更新する）
+			auth.POST("/create/post", writeLimit, handlers.CreatePost)
+			auth.GET("/update/post/:from", handlers.GetUpdatePost)
+			auth.PUT("/edit/post/:id", writeLimit, handlers.EditPost)
+			auth.PATCH("/edit/post/:id", writeLimit, handlers.EditPost)
+			auth.DELETE("/delete/post/:id", writeLimit, handlers.DeletePost)
 
 			// スレッド関連
-			auth.POST("/create/thread", handlers.CreateThread)
-			auth.GET("/update/thread/:id", handlers.GetUpdateThread)
-			auth.PUT("/edit/thread/:id", handlers.EditThread)
-			auth.DELETE("/delete/thread/:id", handlers.DeleteThread)
+			auth.POST("/create/thread", writeLimit, handlers.CreateThread)
+			auth.GET("/update/thread/:from", handlers.GetUpdateThread)
+			auth.PUT("/edit/thread/:id", writeLimit, handlers.EditThread)
+			auth.PATCH("/edit/thread/:id", writeLimit, handlers.EditThread)
+			auth.DELETE("/delete/thread/:id", writeLimit, handlers.DeleteThread)
 
 			// イベント関連
-			auth.POST("/create/event", handlers.CreateEvent)
-			auth.GET("/update/event/:id", handlers.GetUpdateEvent)
-			auth.PUT("/edit/event/:id", handlers.EditEvent)
-			auth.DELETE("/delete/event/:id", handlers.DeleteEvent)
+			auth.POST("/create/event", writeLimit, handlers.CreateEvent)
+			auth.GET("/update/event/:from", handlers.GetUpdateEvent)
+			auth.PUT("/edit/event/:id", writeLimit, handlers.EditEvent)
+			auth.PATCH("/edit/event/:id", writeLimit, handlers.EditEvent)
+			auth.DELETE("/delete/event/:id", writeLimit, handlers.DeleteEvent)
 
 			// コメント関連
-			auth.POST("/create/comment", handlers.CreateComment)
+			auth.POST("/create/comment", writeLimit, handlers.CreateComment)
 			// Replies for thread
-			auth.POST("/thread/:id/reply", handlers.CreateComment)
-			auth.DELETE("/delete/comment/:id", handlers.DeleteComment)
+			auth.POST("/thread/:id/reply", writeLimit, handlers.CreateComment)
+			auth.DELETE("/delete/comment/:id", writeLimit, handlers.DeleteComment)
+
+			// 通報
+			auth.POST("/report", writeLimit, handlers.CreateReport)
+		}
+	}
+
+	// API v2グループ（リソース単位のパス。ハンドラーは v1 と共通）
+	v2 := r.Group("/api/v2")
+	commonRoutes(v2, requireAuth, allowPendingDeletion, authLimit, writeLimit)
+	{
+		// 一覧は ?lat=&lng=&radius=&since= で絞り込む（v1 の /getall と /update を兼ねる）
+		v2.GET("/posts", handlers.ListPosts)
+		v2.GET("/posts/:id", handlers.GetPost)
+		v2.GET("/threads", handlers.ListThreads)
+		v2.GET("/threads/:id", handlers.GetThread)
+		v2.GET("/threads/:id/comments", handlers.ListThreadComments)
+		v2.GET("/events", handlers.ListEvents)
+		v2.GET("/events/:id", handlers.GetEvent)
+
+		// ユーザー関連（認証不要）
+		v2.GET("/users/:id", handlers.GetUserByID)
+		v2.GET("/users/:id/posts", handlers.GetUserPosts)
+		v2.GET("/users/:id/threads", handlers.GetUserThreads)
+		v2.GET("/users/:id/events", handlers.GetUserEvents)
+		v2.GET("/users/:id/comments", handlers.GetUserComments)
+
+		// 認証が必要なエンドポイント
+		auth := v2.Group("")
+		auth.Use(requireAuth)
+		{
+			auth.POST("/attachments", writeLimit, handlers.UploadAttachment)
+
+			// 編集は指定した項目のみ更新する
+			auth.POST("/posts", writeLimit, handlers.CreatePost)
+			auth.PATCH("/posts/:id", writeLimit, handlers.EditPost)
+			auth.DELETE("/posts/:id", writeLimit, handlers.DeletePost)
+
+			auth.POST("/threads", writeLimit, handlers.CreateThread)
+			auth.PATCH("/threads/:id", writeLimit, handlers.EditThread)
+			auth.DELETE("/threads/:id", writeLimit, handlers.DeleteThread)
+			auth.POST("/threads/:id/comments", writeLimit, handlers.CreateComment)
+
+			auth.POST("/events", writeLimit, handlers.CreateEvent)
+			auth.PATCH("/events/:id", writeLimit, handlers.EditEvent)
+			auth.DELETE("/events/:id", writeLimit, handlers.DeleteEvent)
+
+			auth.DELETE("/comments/:id", writeLimit, handlers.DeleteComment)
+
+			auth.POST("/reports", writeLimit, handlers.CreateReport)
+
+			// 管理者のみ（変更を伴う操作は監査ログに記録する）
+			admin := auth.Group("/admin")
+			admin.Use(middleware.RequireRole(types.RoleAdmin))
+			{
+				admin.GET("/stats", handlers.GetAdminStats)
+
+				// ユーザーの検索・利用停止・権限の変更
+				admin.GET("/users", handlers.ListUsers)
+				admin.POST("/users/:id/suspend", handlers.SuspendUser)
+				admin.POST("/users/:id/ban", handlers.BanUser)
+				admin.POST("/users/:id/reinstate", handlers.ReinstateUser)
+				admin.PUT("/users/:id/role", handlers.UpdateUserRole)
+
+				// 削除済みの投稿・スレッド・イベント・コメントの確認と削除の取り消し
+				admin.GET("/deleted/:type", handlers.ListDeletedContent)
+				admin.POST("/deleted/:type/:id/restore", handlers.RestoreDeletedContent)
+
+				// 投稿・スレッド・イベントの一括エクスポート・取り込み（GeoJSON / NDJSON / CSV）
+				admin.GET("/export", handlers.ExportContent)
+				admin.POST("/import", writeLimit, handlers.ImportContent)
+
+				// 状態を変更した操作の監査ログ
+				admin.GET("/audit-logs", handlers.ListAuditLogs)
+			}
 		}
 	}
 
-	// ヘルスチェック
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"message": "API server is running",
+	// ローカル保存のアップロードファイルを配信（エクスポート等の非公開ファイルは除く）
+	if local, ok := storage.Get().(*storage.LocalStore); ok {
+		media := r.Group(storage.LocalMountPath, func(c *gin.Context) {
+			if storage.IsPrivate(c.Param("filepath")) {
+				c.AbortWithStatus(http.StatusNotFound)
+			}
 		})
+		media.Static("/", local.Dir())
+	}
+
+	// 存在しないパスも {"error": {...}} の形で返す
+	r.NoRoute(func(c *gin.Context) {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeNotFound, "route not found"))
 	})
+
+	// ヘルスチェック（/livez: プロセスの生存、/readyz: 依存先を含めてリクエストを受け付けられるか）
+	r.GET("/livez", health.Livez)
+	r.GET("/readyz", health.Readyz)
+	// 互換性のため残す
+	r.GET("/health", health.Livez)
+}
+
+// commonRoutes は v1 と v2 で同じパスのルートを登録する（認証・アカウント・カテゴリ・モデレーション）
+func commonRoutes(g *gin.RouterGroup, requireAuth, allowPendingDeletion, authLimit, writeLimit gin.HandlerFunc) {
+	g.POST("/auth/login", authLimit, handlers.Login)
+	g.POST("/auth/register", authLimit, handlers.Register)
+	g.POST("/auth/google", authLimit, handlers.GoogleLogin)
+	g.POST("/auth/verify-email", authLimit, handlers.VerifyEmail)
+	g.POST("/auth/password/forgot", authLimit, handlers.ForgotPassword)
+	g.POST("/auth/password/reset", authLimit, handlers.ResetPassword)
+	g.GET("/social-sensing/heatmap", handlers.GetSocialSensingHeatmap)
+	g.GET("/categories", handlers.ListCategories)
+
+	// 個人データのエクスポート（退会手続き中も利用できる）
+	g.GET("/me/export", allowPendingDeletion, writeLimit, handlers.ExportData)
+	g.GET("/me/export/download", allowPendingDeletion, handlers.DownloadExport)
+
+	// 認証が必要なエンドポイント
+	auth := g.Group("")
+	auth.Use(requireAuth)
+	{
+		// 現在のユーザー情報取得
+		auth.GET("/auth/me", handlers.GetCurrentUser)
+
+		// ログアウト
+		auth.POST("/auth/logout", handlers.Logout)
+
+		// メールアドレス確認の再送
+		auth.POST("/auth/verify-email/resend", handlers.ResendVerification)
+
+		// プロフィール編集
+		auth.PUT("/me", writeLimit, handlers.UpdateProfile)
+		auth.POST("/me/avatar", writeLimit, handlers.UploadAvatar)
+		auth.PUT("/me/password", authLimit, handlers.ChangePassword)
+
+		// 退会
+		auth.DELETE("/me", authLimit, handlers.DeleteAccount)
+
+		// ログイン方法の紐付け
+		auth.GET("/me/identities", handlers.ListIdentities)
+		auth.POST("/me/identities/google", authLimit, handlers.LinkGoogle)
+		auth.DELETE("/me/identities/:provider", authLimit, handlers.UnlinkIdentity)
+
+		// モデレーション（モデレーター・管理者のみ）
+		mod := auth.Group("/moderation")
+		mod.Use(middleware.RequireRole(types.RoleModerator, types.RoleAdmin))
+		{
+			mod.GET("/reports", handlers.ListReports)
+			mod.POST("/reports/:id/dismiss", handlers.DismissReport)
+			mod.POST("/content/:type/:id/hide", handlers.HideContent)
+			mod.POST("/content/:type/:id/restore", handlers.RestoreContent)
+			mod.GET("/filter-results", handlers.ListFilterResults)
+		}
+	}
 }
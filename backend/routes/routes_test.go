@@ -0,0 +1,240 @@
+package routes
+
+import (
+	"api/apierror"
+	"api/config"
+	"api/middleware"
+	"api/openapi"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// go test ./routes -run TestSpecFile -update で docs/openapi.json を更新する
+var update = flag.Bool("update", false, "update docs/openapi.json")
+
+const specFile = "../../docs/openapi.json"
+
+func newEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	// DB 等を初期化しないため、ハンドラーの panic（500）のログを抑える
+	gin.DefaultErrorWriter = io.Discard
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := gin.New()
+	r.Use(apierror.Recovery(), apierror.Middleware())
+	SetupRoutes(r, &config.Config{
+		Auth:   config.Auth{JWTSecret: strings.Repeat("x", 32)},
+		Server: config.Server{V1Sunset: "2027-04-01"},
+	})
+	return r
+}
+
+// 登録した全てのルートがドキュメントにあり、ドキュメントの全ての操作が登録されていること
+func TestRoutesMatchSpec(t *testing.T) {
+	r := newEngine(t)
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		if route.Method == http.MethodOptions {
+			continue
+		}
+		registered[route.Method+" "+openapi.PathOf(route.Path)] = true
+	}
+
+	documented := map[string]bool{}
+	for _, op := range Spec().Operations() {
+		documented[op] = true
+		if !registered[op] {
+			t.Errorf("%s is documented but not registered", op)
+		}
+	}
+	for op := range registered {
+		if !documented[op] {
+			t.Errorf("%s is registered but not documented in routes/openapi.go", op)
+		}
+	}
+}
+
+// docs/openapi.json が生成したドキュメントと一致すること
+func TestSpecFile(t *testing.T) {
+	got, err := json.MarshalIndent(Spec(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := os.WriteFile(specFile, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date; run: go test ./routes -run TestSpecFile -update", specFile)
+	}
+}
+
+// 全てのルートの応答（認証無し・空のボディ）のステータスと本文がドキュメントの定義に合うこと
+// DB を使わずに返せる応答（認証エラー・検証エラー・カテゴリ一覧・ヘルスチェック等）の形を確認する
+func TestResponsesMatchSpec(t *testing.T) {
+	r := newEngine(t)
+	doc := Spec()
+
+	for _, route := range apiRoutes {
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			var body io.Reader
+			if route.Request != nil {
+				body = strings.NewReader("{}")
+			}
+			req := httptest.NewRequest(route.Method, samplePath(route.Path), body)
+			if body != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if route.Auth && w.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want 401 without a token", w.Code)
+			}
+			if err := doc.ValidateResponse(route.Method, route.Path, w.Code, w.Header().Get("Content-Type"), w.Body.Bytes()); err != nil {
+				t.Errorf("%v\nbody: %s", err, w.Body.String())
+			}
+		})
+	}
+}
+
+// 応答の形が変わった場合に検出できること
+func TestValidateResponseDetectsDrift(t *testing.T) {
+	doc := Spec()
+	path := "/api/v1/categories"
+
+	ok := `{"data":[{"id":"other","labels":{"ja":"その他"},"icon":"map-pin","scope":"local","radius_meters":1100,"moderation":"standard"}]}`
+	if err := doc.ValidateResponse(http.MethodGet, path, http.StatusOK, "application/json", []byte(ok)); err != nil {
+		t.Fatalf("valid response rejected: %v", err)
+	}
+
+	cases := map[string]string{
+		"missing property":    `{"data":[{"id":"other","labels":{},"icon":"","scope":"local"}]}`,
+		"unexpected property": `{"data":[{"id":"other","labels":{},"icon":"","scope":"local","moderation":"standard","label":"x"}]}`,
+		"wrong type":          `{"data":[{"id":1,"labels":{},"icon":"","scope":"local","moderation":"standard"}]}`,
+		"not enveloped":       `[]`,
+	}
+	for name, body := range cases {
+		if err := doc.ValidateResponse(http.MethodGet, path, http.StatusOK, "application/json", []byte(body)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := doc.ValidateResponse(http.MethodGet, path, http.StatusTeapot, "application/json", []byte(`{}`)); err == nil {
+		t.Error("undocumented status: expected an error")
+	}
+}
+
+// v1 の応答（エラーを含む）には廃止予定のヘッダーが付き、v2 には付かないこと
+func TestV1Deprecation(t *testing.T) {
+	r := newEngine(t)
+
+	for _, path := range []string{"/api/v1/categories", "/api/v1/auth/me"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if got := w.Header().Get("Deprecation"); !strings.HasPrefix(got, "@") {
+			t.Errorf("%s: Deprecation = %q", path, got)
+		}
+		if got, want := w.Header().Get("Sunset"), "Thu, 01 Apr 2027 00:00:00 GMT"; got != want {
+			t.Errorf("%s: Sunset = %q, want %q", path, got, want)
+		}
+		if got, want := w.Header().Get("Link"), `</api/v2>; rel="successor-version"`; got != want {
+			t.Errorf("%s: Link = %q, want %q", path, got, want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/categories", nil))
+	if got := w.Header().Get("Deprecation"); got != "" {
+		t.Errorf("v2: Deprecation = %q, want none", got)
+	}
+}
+
+// v2 の一覧のクエリの検証エラー（DB に問い合わせる前に返す）
+func TestV2ListQueryValidation(t *testing.T) {
+	r := newEngine(t)
+
+	for _, query := range []string{
+		"lat=35.6",
+		"lng=139.7",
+		"lat=91&lng=139.7",
+		"lat=35.6&lng=139.7&radius=50001",
+		"radius=500",
+		"since=abc",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/posts?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
+// samplePath はパスパラメータに値を入れる
+func samplePath(path string) string {
+	values := map[string]string{"type": "post", "provider": "google", "from": "0"}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if name, ok := strings.CutPrefix(s, ":"); ok {
+			v, ok := values[name]
+			if !ok {
+				v = "1"
+			}
+			segments[i] = v
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// プリフライトは設定したオリジンのみ許可し、Cookie 付きの応答に * を返さないこと
+func TestPreflight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const allowed = "https://chap-app.jp"
+	server := config.Server{V1Sunset: "2027-04-01", CORSOrigins: []string{allowed}}
+	r := gin.New()
+	r.Use(middleware.CORS(server), apierror.Recovery(), apierror.Middleware())
+	SetupRoutes(r, &config.Config{Auth: config.Auth{JWTSecret: strings.Repeat("x", 32)}, Server: server})
+
+	tests := []struct {
+		origin     string
+		wantStatus int
+		wantOrigin string
+	}{
+		{allowed, http.StatusNoContent, allowed},
+		{"https://evil.example", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v2/posts", nil)
+		req.Header.Set("Origin", tt.origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "Content-Type, Authorization")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.origin, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.origin, got, tt.wantOrigin)
+		}
+	}
+}
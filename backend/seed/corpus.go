@@ -0,0 +1,136 @@
+package seed
+
+import "api/types"
+
+// city は投稿を生成する都市（Weight は選ばれる比率）
+type city struct {
+	Name   string
+	Lat    float64
+	Lng    float64
+	Weight int
+}
+
+var cities = []city{
+	{"札幌", 43.0618, 141.3545, 5},
+	{"仙台", 38.2682, 140.8694, 3},
+	{"新潟", 37.9162, 139.0364, 2},
+	{"さいたま", 35.8617, 139.6455, 3},
+	{"千葉", 35.6074, 140.1065, 3},
+	{"渋谷", 35.6580, 139.7016, 8},
+	{"新宿", 35.6896, 139.7006, 8},
+	{"横浜", 35.4437, 139.6380, 6},
+	{"金沢", 36.5613, 136.6562, 2},
+	{"静岡", 34.9756, 138.3828, 2},
+	{"名古屋", 35.1709, 136.8815, 6},
+	{"京都", 35.0116, 135.7681, 4},
+	{"大阪", 34.7025, 135.4959, 8},
+	{"神戸", 34.6901, 135.1955, 4},
+	{"岡山", 34.6551, 133.9195, 2},
+	{"広島", 34.3853, 132.4553, 3},
+	{"高松", 34.3401, 134.0434, 1},
+	{"福岡", 33.5902, 130.4017, 5},
+	{"熊本", 32.8031, 130.7079, 2},
+	{"那覇", 26.2124, 127.6809, 2},
+}
+
+var places = []string{"駅前", "商店街", "中央公園", "川沿い", "市立図書館", "市役所の近く", "港", "大学の周辺", "駅の東口", "ショッピングモール"}
+
+var names = []string{
+	"さくら", "はると", "ゆい", "そうた", "ひなた", "れん", "あおい", "ゆうと", "めい", "いつき",
+	"みお", "こうき", "ほのか", "だいち", "りこ", "けんた", "まこと", "なつみ", "しょう", "あかり",
+}
+
+var bios = []string{"", "散歩が好きです", "地元の情報を発信しています", "週末はカフェ巡り", "防災士の勉強中", "写真が趣味", "子育て中です"}
+
+// template は本文の雛形（{city} と {place} を置き換える）とタグ
+type template struct {
+	Text string
+	Tags []string
+}
+
+// templates はカテゴリ・種類（post / thread / event）毎の雛形
+// 登録されていないカテゴリは other の雛形を使う
+var templates = map[string]map[string][]template{
+	types.CategoryEntertainment: {
+		"post": {
+			{"{city}の{place}でストリートライブをやっていました。思わず足を止めて聴き入りました", []string{"音楽", "ライブ"}},
+			{"{place}に新しくできた映画館、座席が広くて快適でした", []string{"映画"}},
+			{"{city}のご当地グルメフェアに行ってきました。どれも美味しかった！", []string{"グルメ", "イベント"}},
+		},
+		"thread": {
+			{"{city}でおすすめのライブハウスを教えてください", []string{"音楽"}},
+			{"{place}周辺で子どもと遊べる場所はありますか？", []string{"おでかけ", "子育て"}},
+			{"{city}の今年の花火大会、どこから見るのがいいでしょう", []string{"花火", "夏"}},
+		},
+		"event": {
+			{"{place}で週末マルシェを開催します。地元の野菜や雑貨が並びます", []string{"マルシェ", "週末"}},
+			{"{city}の{place}で野外映画上映会をやります。レジャーシート持参でどうぞ", []string{"映画", "野外"}},
+			{"{place}でアマチュアバンドのライブイベントを行います", []string{"音楽", "ライブ"}},
+		},
+	},
+	types.CategoryCommunity: {
+		"post": {
+			{"{place}の清掃活動に参加しました。思ったよりごみが多かったです", []string{"ボランティア", "清掃"}},
+			{"{city}の{place}にある掲示板に町内会のお知らせが出ていました", []string{"町内会"}},
+			{"{place}の桜がそろそろ見頃です", []string{"季節", "桜"}},
+		},
+		"thread": {
+			{"{place}の自転車置き場が不足している件について", []string{"地域の課題"}},
+			{"{city}で引っ越してきたばかりです。住みやすいエリアの情報交換をしませんか", []string{"引っ越し", "情報交換"}},
+			{"{place}の夜道が暗いと感じる方はいますか？", []string{"防犯"}},
+		},
+		"event": {
+			{"{place}で地域の交流会を開きます。初めての方も歓迎です", []string{"交流会"}},
+			{"{city}の{place}で古本の交換会をします", []string{"本", "交換会"}},
+			{"{place}の清掃ボランティアを募集しています", []string{"ボランティア", "清掃"}},
+		},
+	},
+	types.CategoryDisaster: {
+		"post": {
+			{"{city}で強い雨が降っています。{place}付近は冠水しかけているので注意してください", []string{"大雨", "注意"}},
+			{"{place}の避難所の場所を改めて確認しておきました", []string{"避難所", "防災"}},
+			{"{city}で少し揺れました。皆さん大丈夫ですか", []string{"地震"}},
+		},
+		"thread": {
+			{"{city}の避難所の備蓄について情報を集めています", []string{"防災", "備蓄"}},
+			{"大雨のとき{place}周辺で危ない場所を共有しましょう", []string{"大雨", "ハザードマップ"}},
+			{"家庭での防災グッズ、何を用意していますか", []string{"防災グッズ"}},
+		},
+		"event": {
+			{"{place}で防災訓練を実施します。消火器の使い方も体験できます", []string{"防災訓練"}},
+			{"{city}の{place}で応急手当の講習会を行います", []string{"講習会", "防災"}},
+			{"{place}で非常食の試食会と備蓄の相談会をします", []string{"非常食", "備蓄"}},
+		},
+	},
+	types.CategoryOther: {
+		"post": {
+			{"{place}のパン屋さん、朝7時から開いていて便利です", []string{"日常", "グルメ"}},
+			{"{city}は今日も良い天気でした", []string{"天気", "日常"}},
+			{"{place}で落とし物の財布を見かけたので交番に届けました", []string{"落とし物"}},
+		},
+		"thread": {
+			{"{place}で美味しいランチのお店を探しています", []string{"ランチ"}},
+			{"{city}で静かに作業できるカフェはありますか", []string{"カフェ"}},
+			{"{place}の駐車場はいつも混んでいますか？", []string{"駐車場"}},
+		},
+		"event": {
+			{"{place}でフリーマーケットを開催します", []string{"フリマ"}},
+			{"{city}の{place}で朝のウォーキング会をやります", []string{"健康", "ウォーキング"}},
+			{"{place}で写真の撮り方の勉強会をします", []string{"写真", "勉強会"}},
+		},
+	},
+}
+
+// replies はスレッドへのコメントの雛形
+var replies = []string{
+	"情報ありがとうございます！",
+	"私も気になっていました",
+	"{place}の近くなら何度か行ったことがあります",
+	"週末に行ってみます",
+	"同じく{city}在住です。参考になります",
+	"最近は少し改善したと聞きました",
+	"詳しい場所を教えてもらえますか？",
+	"去年も同じようなことがありましたね",
+	"友達にも共有しておきます",
+	"役所に問い合わせてみるのが良さそうです",
+}
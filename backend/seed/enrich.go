@@ -0,0 +1,116 @@
+package seed
+
+import (
+	"api/config"
+	"api/telemetry"
+	"api/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// enrichBatch は1回の問い合わせで書き換える本文の数
+const enrichBatch = 20
+
+const enrichPrompt = `
+あなたは日本の地域SNSのユーザーです。次の JSON 配列の各投稿文を、地名と意味を変えずに
+より自然で具体的な投稿文（80文字以内）に書き換えてください。
+同じ件数・同じ順序の JSON 文字列配列のみで回答してください。
+投稿: `
+
+var jsonArrayPattern = regexp.MustCompile(`\[[\s\S]*\]`)
+
+// Enrich は生成した本文を Gemini で書き換える（書き換えた本文は決定的ではない）
+// 失敗したバッチは元の本文のまま残し、書き換えた件数を返す
+func Enrich(ctx context.Context, cfg config.Gemini, ds *Dataset) (int, error) {
+	if cfg.APIKey == "" {
+		return 0, fmt.Errorf("GEMINI_API_KEY is required for enrichment")
+	}
+	client, err := genai.NewClient(ctx, option.WithAPIKey(cfg.APIKey))
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+	model := client.GenerativeModel(cfg.Model)
+
+	var texts []*string
+	for i := range ds.Posts {
+		texts = append(texts, &ds.Posts[i].Content)
+	}
+	for i := range ds.Threads {
+		texts = append(texts, &ds.Threads[i].Content)
+	}
+	for i := range ds.Events {
+		texts = append(texts, &ds.Events[i].Content)
+	}
+
+	enriched := 0
+	for start := 0; start < len(texts); start += enrichBatch {
+		if err := ctx.Err(); err != nil {
+			return enriched, err
+		}
+		batch := texts[start:min(start+enrichBatch, len(texts))]
+		rewritten, err := rewrite(ctx, model, cfg.Model, batch)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to enrich seed content; keeping generated text", "offset", start, "error", err)
+			continue
+		}
+		for i, text := range rewritten {
+			if text != "" {
+				*batch[i] = text
+				enriched++
+			}
+		}
+	}
+	return enriched, nil
+}
+
+func rewrite(ctx context.Context, model *genai.GenerativeModel, modelName string, batch []*string) ([]string, error) {
+	in := make([]string, len(batch))
+	for i, p := range batch {
+		in[i] = *p
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *genai.GenerateContentResponse
+	err = telemetry.ObserveLLM(ctx, "seed_enrich", modelName, func(ctx context.Context) error {
+		resp, err = model.GenerateContent(ctx, genai.Text(enrichPrompt+string(data)))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	raw := ""
+	for _, candidate := range resp.Candidates {
+		if candidate.Content == nil {
+			continue
+		}
+		for _, part := range candidate.Content.Parts {
+			if text, ok := part.(genai.Text); ok {
+				raw += string(text)
+			}
+		}
+	}
+	var out []string
+	if err := json.Unmarshal([]byte(jsonArrayPattern.FindString(raw)), &out); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if len(out) != len(batch) {
+		return nil, fmt.Errorf("expected %d texts, got %d", len(batch), len(out))
+	}
+	for i, text := range out {
+		if len([]rune(text)) > types.MaxContentLength {
+			out[i] = ""
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,336 @@
+// Package seed は開発・負荷試験用のデータ（ユーザー・投稿・スレッド・イベント・コメント・いいね）を生成して投入する
+//
+// 同じシード値・基準日からは同じデータを生成する（LLM で本文を書き換える場合を除く）
+// 生成したユーザーのメールアドレスは EmailDomain のドメインにし、Reset はそのユーザーとその投稿等のみ削除する
+package seed
+
+import (
+	"api/types"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailDomain は生成したユーザーのメールアドレスのドメイン（実在しない .invalid を使う）
+const EmailDomain = "seed.invalid"
+
+// Password は生成したユーザーのパスワード（開発環境でログインして確認するため）
+const Password = "seed-password"
+
+// Profile は生成する件数
+type Profile struct {
+	Users    int
+	Posts    int
+	Threads  int
+	Events   int
+	Comments int
+	Likes    int
+}
+
+// Profiles は --profile で指定できる件数（large は負荷試験用）
+var Profiles = map[string]Profile{
+	"small": {Users: 20, Posts: 120, Threads: 40, Events: 30, Comments: 150, Likes: 400},
+	"large": {Users: 2000, Posts: 50000, Threads: 10000, Events: 5000, Comments: 40000, Likes: 200000},
+}
+
+// Options は生成の条件
+type Options struct {
+	Seed    int64
+	Profile Profile
+	// 投稿日時の基準（これより前の90日間に投稿し、イベントはこれより後の60日間に開催する）
+	Base time.Time
+	// カテゴリと公開方針（review のカテゴリはモデレーター確認待ちとして非表示にする）
+	Categories []types.Category
+}
+
+// Comment はスレッドへのコメント（ThreadIndex は Dataset.Threads の添字）
+type Comment struct {
+	types.Comment
+	ThreadIndex int
+}
+
+// いいねの対象の種類
+const (
+	LikePost   = "post"
+	LikeThread = "thread"
+	LikeEvent  = "event"
+)
+
+// Like はいいね（Target は Kind に対応する Dataset のスライスの添字、User は Dataset.Users の添字）
+type Like struct {
+	Kind   string
+	Target int
+	User   int
+}
+
+// Dataset は生成したデータ（ID は投入時に DB が採番する。ユーザーの ID はシード値から決める）
+type Dataset struct {
+	Users    []types.User
+	Posts    []types.Post
+	Threads  []types.Thread
+	Events   []types.Event
+	Comments []Comment
+	Likes    []Like
+}
+
+// Generate は opts のシード値からデータを生成する
+func Generate(opts Options) (*Dataset, error) {
+	p := opts.Profile
+	if p.Users <= 0 {
+		return nil, fmt.Errorf("at least one user is required")
+	}
+	if len(opts.Categories) == 0 {
+		return nil, fmt.Errorf("no categories")
+	}
+	if p.Comments > 0 && p.Threads == 0 {
+		return nil, fmt.Errorf("comments require at least one thread")
+	}
+
+	g := &generator{
+		rng:  rand.New(rand.NewPCG(uint64(opts.Seed), 0x9e3779b97f4a7c15)),
+		opts: opts,
+	}
+	for _, c := range cities {
+		g.totalWeight += c.Weight
+	}
+
+	ds := &Dataset{}
+	for i := 0; i < p.Users; i++ {
+		ds.Users = append(ds.Users, g.user(i))
+	}
+	for i := 0; i < p.Posts; i++ {
+		ds.Posts = append(ds.Posts, g.post(ds.Users))
+	}
+	for i := 0; i < p.Threads; i++ {
+		ds.Threads = append(ds.Threads, g.thread(ds.Users))
+	}
+	for i := 0; i < p.Events; i++ {
+		ds.Events = append(ds.Events, g.event(ds.Users))
+	}
+	for i := 0; i < p.Comments; i++ {
+		ds.Comments = append(ds.Comments, g.comment(ds.Users, ds.Threads))
+	}
+	g.likes(ds, p.Likes)
+	return ds, nil
+}
+
+type generator struct {
+	rng         *rand.Rand
+	opts        Options
+	totalWeight int
+}
+
+// UserID はシード値と添字から決まるユーザーの ID
+func UserID(seed int64, i int) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("https://chap-app.jp/seed/%d/users/%d", seed, i)))
+}
+
+// Email はシード値と添字から決まるユーザーのメールアドレス
+func Email(seed int64, i int) string {
+	return fmt.Sprintf("user%d.s%d@%s", i+1, seed, EmailDomain)
+}
+
+func (g *generator) user(i int) types.User {
+	created := g.past(180 * 24 * time.Hour)
+	return types.User{
+		ID:               UserID(g.opts.Seed, i),
+		Name:             fmt.Sprintf("%s%d", pick(g.rng, names), i+1),
+		Bio:              pick(g.rng, bios),
+		HomeArea:         g.city().Name,
+		DefaultPrecision: g.precision(),
+		Email:            Email(g.opts.Seed, i),
+		EmailVerified:    true,
+		Valid:            true,
+		LoginType:        types.ProviderEmail,
+		Role:             types.RoleUser,
+		CreatedAt:        created,
+		UpdatedAt:        created,
+	}
+}
+
+// item は投稿・スレッド・イベントの共通の項目
+type item struct {
+	author    types.User
+	at        types.Coordinate
+	precision string
+	category  string
+	valid     bool
+	content   string
+	tags      []string
+	created   time.Time
+}
+
+func (g *generator) item(users []types.User, kind string) item {
+	author := users[g.rng.IntN(len(users))]
+	c := g.city()
+	cat := g.opts.Categories[g.rng.IntN(len(g.opts.Categories))]
+	byKind, ok := templates[cat.ID]
+	if !ok {
+		byKind = templates[types.CategoryOther]
+	}
+	tmpl := pick(g.rng, byKind[kind])
+	created := g.past(90 * 24 * time.Hour)
+	if created.Before(author.CreatedAt) {
+		created = author.CreatedAt.Add(time.Duration(g.rng.IntN(3600)) * time.Second)
+	}
+	return item{
+		author:    author,
+		at:        g.near(c),
+		precision: g.precision(),
+		category:  cat.ID,
+		valid:     cat.Moderation != types.ModerationReview,
+		content:   g.fill(tmpl.Text, c.Name),
+		tags:      tmpl.Tags,
+		created:   created,
+	}
+}
+
+func (g *generator) post(users []types.User) types.Post {
+	it := g.item(users, "post")
+	return types.Post{
+		Type: "post", UserID: it.author.ID, Username: it.author.Name,
+		Coordinate: it.at, Precision: it.precision, Content: it.content, Category: it.category,
+		Valid: it.valid, Tags: it.tags, CreatedAt: it.created, UpdatedAt: it.created,
+	}
+}
+
+func (g *generator) thread(users []types.User) types.Thread {
+	it := g.item(users, "thread")
+	return types.Thread{
+		Type: "thread", UserID: it.author.ID, Username: it.author.Name,
+		Coordinate: it.at, Precision: it.precision, Content: it.content, Category: it.category,
+		Valid: it.valid, Tags: it.tags, CreatedAt: it.created, UpdatedAt: it.created,
+	}
+}
+
+func (g *generator) event(users []types.User) types.Event {
+	it := g.item(users, "event")
+	// 開催日は基準日の翌日から60日以内の10時〜19時
+	day := g.opts.Base.Truncate(24*time.Hour).AddDate(0, 0, 1+g.rng.IntN(60))
+	date := day.Add(time.Duration(10+g.rng.IntN(10)) * time.Hour)
+	return types.Event{
+		Type: "event", UserID: it.author.ID, Username: it.author.Name,
+		Coordinate: it.at, Precision: it.precision, Content: it.content, Category: it.category,
+		Valid: it.valid, Tags: it.tags, EventDate: date, CreatedAt: it.created, UpdatedAt: it.created,
+	}
+}
+
+func (g *generator) comment(users []types.User, threads []types.Thread) Comment {
+	ti := g.rng.IntN(len(threads))
+	thread := threads[ti]
+	author := users[g.rng.IntN(len(users))]
+	// スレッドの作成から基準日までの間
+	span := g.opts.Base.Sub(thread.CreatedAt)
+	created := thread.CreatedAt
+	if span > 0 {
+		created = created.Add(time.Duration(g.rng.Int64N(int64(span))))
+	}
+	c := g.nearestCity(thread.Coordinate)
+	return Comment{
+		Comment: types.Comment{
+			UserID: author.ID, Username: author.Name,
+			Coordinate: thread.Coordinate, Precision: thread.Precision,
+			Content: g.fill(pick(g.rng, replies), c.Name), Valid: true,
+			CreatedAt: created, UpdatedAt: created,
+		},
+		ThreadIndex: ti,
+	}
+}
+
+// likes は重複しないいいねを n 件（対象が少ない場合はそれ以下）生成し、いいね数に反映する
+func (g *generator) likes(ds *Dataset, n int) {
+	total := len(ds.Posts) + len(ds.Threads) + len(ds.Events)
+	if total == 0 {
+		return
+	}
+	seen := map[Like]bool{}
+	for attempts := 0; len(ds.Likes) < n && attempts < n*3; attempts++ {
+		i := g.rng.IntN(total)
+		like := Like{User: g.rng.IntN(len(ds.Users))}
+		switch {
+		case i < len(ds.Posts):
+			like.Kind, like.Target = LikePost, i
+		case i < len(ds.Posts)+len(ds.Threads):
+			like.Kind, like.Target = LikeThread, i-len(ds.Posts)
+		default:
+			like.Kind, like.Target = LikeEvent, i-len(ds.Posts)-len(ds.Threads)
+		}
+		if seen[like] {
+			continue
+		}
+		seen[like] = true
+		ds.Likes = append(ds.Likes, like)
+		switch like.Kind {
+		case LikePost:
+			ds.Posts[like.Target].Like++
+		case LikeThread:
+			ds.Threads[like.Target].Like++
+		case LikeEvent:
+			ds.Events[like.Target].Like++
+		}
+	}
+}
+
+// city は人口の多い都市ほど選ばれやすいよう重み付きで選ぶ
+func (g *generator) city() city {
+	n := g.rng.IntN(g.totalWeight)
+	for _, c := range cities {
+		if n < c.Weight {
+			return c
+		}
+		n -= c.Weight
+	}
+	return cities[len(cities)-1]
+}
+
+func (g *generator) nearestCity(at types.Coordinate) city {
+	best, dist := cities[0], -1.0
+	for _, c := range cities {
+		d := (c.Lat-at.Lat)*(c.Lat-at.Lat) + (c.Lng-at.Lng)*(c.Lng-at.Lng)
+		if dist < 0 || d < dist {
+			best, dist = c, d
+		}
+	}
+	return best
+}
+
+// near は都市の中心から約3km以内の座標を返す
+func (g *generator) near(c city) types.Coordinate {
+	return types.Coordinate{
+		Lat: round6(c.Lat + (g.rng.Float64()*2-1)*0.03),
+		Lng: round6(c.Lng + (g.rng.Float64()*2-1)*0.03),
+	}
+}
+
+// precision は neighborhood を中心に公開精度を選ぶ
+func (g *generator) precision() string {
+	switch n := g.rng.IntN(20); {
+	case n < 5:
+		return "exact"
+	case n < 17:
+		return "neighborhood"
+	default:
+		return "city"
+	}
+}
+
+// past は基準日から d 以内の過去の日時を秒単位で返す
+func (g *generator) past(d time.Duration) time.Time {
+	return g.opts.Base.Add(-time.Duration(g.rng.Int64N(int64(d/time.Second))) * time.Second)
+}
+
+func (g *generator) fill(text, cityName string) string {
+	return strings.NewReplacer("{city}", cityName, "{place}", pick(g.rng, places)).Replace(text)
+}
+
+func pick[T any](rng *rand.Rand, items []T) T {
+	return items[rng.IntN(len(items))]
+}
+
+func round6(f float64) float64 {
+	return math.Round(f*1e6) / 1e6
+}
@@ -0,0 +1,93 @@
+package seed
+
+import (
+	"api/category"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func options(seed int64) Options {
+	return Options{
+		Seed:       seed,
+		Profile:    Profiles["small"],
+		Base:       time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		Categories: category.Defaults,
+	}
+}
+
+// 同じシード値からは同じデータを生成し、異なるシード値からは異なるデータを生成する
+func TestGenerateDeterministic(t *testing.T) {
+	a, err := Generate(options(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Generate(options(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Error("the same seed generated different datasets")
+	}
+
+	c, err := Generate(options(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(a.Posts, c.Posts) {
+		t.Error("different seeds generated the same posts")
+	}
+	if a.Users[0].ID == c.Users[0].ID || a.Users[0].Email == c.Users[0].Email {
+		t.Error("different seeds generated the same user")
+	}
+}
+
+func TestGenerateProfile(t *testing.T) {
+	opts := options(1)
+	ds, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := opts.Profile
+	counts := []struct {
+		name      string
+		got, want int
+	}{
+		{"users", len(ds.Users), p.Users},
+		{"posts", len(ds.Posts), p.Posts},
+		{"threads", len(ds.Threads), p.Threads},
+		{"events", len(ds.Events), p.Events},
+		{"comments", len(ds.Comments), p.Comments},
+		{"likes", len(ds.Likes), p.Likes},
+	}
+	for _, c := range counts {
+		if c.got != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, c.got, c.want)
+		}
+	}
+
+	likes := 0
+	for _, post := range ds.Posts {
+		likes += post.Like
+		if post.CreatedAt.After(opts.Base) {
+			t.Errorf("post created after base date: %v", post.CreatedAt)
+		}
+	}
+	for _, thread := range ds.Threads {
+		likes += thread.Like
+	}
+	for _, event := range ds.Events {
+		likes += event.Like
+		if !event.EventDate.After(opts.Base) {
+			t.Errorf("event held before base date: %v", event.EventDate)
+		}
+	}
+	if likes != len(ds.Likes) {
+		t.Errorf("like counters %d do not match likes %d", likes, len(ds.Likes))
+	}
+	for _, c := range ds.Comments {
+		if c.CreatedAt.Before(ds.Threads[c.ThreadIndex].CreatedAt) {
+			t.Errorf("comment created before its thread")
+		}
+	}
+}
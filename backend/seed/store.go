@@ -0,0 +1,172 @@
+package seed
+
+import (
+	"api/db"
+	"api/types"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// batchSize は一度に INSERT する行数
+const batchSize = 500
+
+// Summary は投入・削除した件数
+type Summary struct {
+	Users    int64
+	Posts    int64
+	Threads  int64
+	Events   int64
+	Comments int64
+	Likes    int64
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("users=%d posts=%d threads=%d events=%d comments=%d likes=%d",
+		s.Users, s.Posts, s.Threads, s.Events, s.Comments, s.Likes)
+}
+
+// Insert は ds を API と同じモデルで1つのトランザクションで保存する
+// ユーザーにはメールアドレスのログイン方法（パスワードは Password）を作る
+func Insert(ctx context.Context, ds *Dataset) (Summary, error) {
+	// 全員同じパスワードのため、ハッシュは1回だけ計算する
+	hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.DefaultCost)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	emails := make([]string, len(ds.Users))
+	for i, u := range ds.Users {
+		emails[i] = u.Email
+	}
+	var existing int64
+	if err := db.Ctx(ctx).Unscoped().Model(&types.User{}).Where("email IN ?", emails).Count(&existing).Error; err != nil {
+		return Summary{}, err
+	}
+	if existing > 0 {
+		return Summary{}, fmt.Errorf("%d seeded users already exist; run with --reset to replace them", existing)
+	}
+
+	err = db.SafeTransaction(ctx, func(tx *gorm.DB) error {
+		identities := make([]types.Identity, len(ds.Users))
+		for i, u := range ds.Users {
+			identities[i] = types.Identity{
+				UserID:       u.ID,
+				Provider:     types.ProviderEmail,
+				Subject:      strings.ToLower(u.Email),
+				Email:        u.Email,
+				PasswordHash: string(hash),
+				CreatedAt:    u.CreatedAt,
+				UpdatedAt:    u.CreatedAt,
+			}
+		}
+		if err := createAll(tx, ds.Users, identities, ds.Posts, ds.Threads, ds.Events); err != nil {
+			return err
+		}
+
+		comments := make([]types.Comment, len(ds.Comments))
+		for i, c := range ds.Comments {
+			comments[i] = c.Comment
+			comments[i].ThreadID = ds.Threads[c.ThreadIndex].ID
+		}
+		if err := createAll(tx, comments); err != nil {
+			return err
+		}
+
+		var postLikes []types.PostLikes
+		var threadLikes []types.ThreadLikes
+		var eventLikes []types.EventLikes
+		for _, l := range ds.Likes {
+			uid := ds.Users[l.User].ID
+			switch l.Kind {
+			case LikePost:
+				postLikes = append(postLikes, types.PostLikes{UserID: uid, PostID: ds.Posts[l.Target].ID})
+			case LikeThread:
+				threadLikes = append(threadLikes, types.ThreadLikes{UserID: uid, ThreadID: ds.Threads[l.Target].ID})
+			case LikeEvent:
+				eventLikes = append(eventLikes, types.EventLikes{UserID: uid, EventID: ds.Events[l.Target].ID})
+			}
+		}
+		return createAll(tx, postLikes, threadLikes, eventLikes)
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+	return Summary{
+		Users:    int64(len(ds.Users)),
+		Posts:    int64(len(ds.Posts)),
+		Threads:  int64(len(ds.Threads)),
+		Events:   int64(len(ds.Events)),
+		Comments: int64(len(ds.Comments)),
+		Likes:    int64(len(ds.Likes)),
+	}, nil
+}
+
+// createAll はスライス毎に batchSize 行ずつ INSERT する（空のスライスは飛ばす）
+// 関連（User 等）は保存済みのため保存しない
+func createAll(tx *gorm.DB, slices ...any) error {
+	for _, rows := range slices {
+		if reflect.ValueOf(rows).Len() == 0 {
+			continue
+		}
+		if err := tx.Omit(clause.Associations).CreateInBatches(rows, batchSize).Error; err != nil {
+			return fmt.Errorf("failed to insert %T: %w", rows, err)
+		}
+	}
+	return nil
+}
+
+// Reset は生成したユーザー（EmailDomain のメールアドレス）とその投稿・コメント・いいね等を完全に削除する
+// 他のユーザーのコメント・いいねのうち、削除する投稿等に付いたものも削除する
+func Reset(ctx context.Context) (Summary, error) {
+	var s Summary
+	err := db.SafeTransaction(ctx, func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		if err := tx.Unscoped().Model(&types.User{}).Where("email LIKE ?", "%@"+EmailDomain).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		threads := tx.Unscoped().Model(&types.Thread{}).Select("id").Where("user_id IN ?", ids)
+		posts := tx.Unscoped().Model(&types.Post{}).Select("id").Where("user_id IN ?", ids)
+		events := tx.Unscoped().Model(&types.Event{}).Select("id").Where("user_id IN ?", ids)
+
+		steps := []struct {
+			count *int64
+			query *gorm.DB
+			model any
+		}{
+			{&s.Likes, tx.Where("user_id IN ? OR post_id IN (?)", ids, posts), &types.PostLikes{}},
+			{&s.Likes, tx.Where("user_id IN ? OR thread_id IN (?)", ids, threads), &types.ThreadLikes{}},
+			{&s.Likes, tx.Where("user_id IN ? OR event_id IN (?)", ids, events), &types.EventLikes{}},
+			{&s.Comments, tx.Unscoped().Where("user_id IN ? OR thread_id IN (?)", ids, threads), &types.Comment{}},
+			{&s.Posts, tx.Unscoped().Where("user_id IN ?", ids), &types.Post{}},
+			{&s.Threads, tx.Unscoped().Where("user_id IN ?", ids), &types.Thread{}},
+			{&s.Events, tx.Unscoped().Where("user_id IN ?", ids), &types.Event{}},
+			{nil, tx.Where("reporter_id IN ?", ids), &types.Report{}},
+			{nil, tx.Where("user_id IN ?", ids), &types.FilterResult{}},
+			{nil, tx.Where("user_id IN ?", ids), &types.Identity{}},
+			{nil, tx.Where("user_id IN ?", ids), &types.UserToken{}},
+			{nil, tx.Where("user_id IN ?", ids), &types.DataExport{}},
+			{&s.Users, tx.Unscoped().Where("id IN ?", ids), &types.User{}},
+		}
+		for _, step := range steps {
+			result := step.query.Delete(step.model)
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete %T: %w", step.model, result.Error)
+			}
+			if step.count != nil {
+				*step.count += result.RowsAffected
+			}
+		}
+		return nil
+	})
+	return s, err
+}
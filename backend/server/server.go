@@ -0,0 +1,110 @@
+package server
+
+import (
+	"api/config"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Hooks は終了処理の各段階で呼ばれる
+type Hooks struct {
+	// HTTP サーバーの停止後（バックグラウンドジョブ・DB 接続の終了等）
+	Cleanup func(ctx context.Context)
+}
+
+var draining = make(chan struct{})
+
+// Draining はシグナルを受けると閉じられる
+// 閉じた後は /readyz が 503 を返し、ShutdownDelay の間にロードバランサーの振り分け先から外れる
+// SSE 等の長時間の接続はこれを監視して自分で接続を閉じる（閉じないと ShutdownTimeout まで終了が遅れる）
+func Draining() <-chan struct{} {
+	return draining
+}
+
+// ExtendWriteDeadline は WriteTimeout を超えて応答を書き込むハンドラーで使う（ゼロ値で無期限）
+func ExtendWriteDeadline(w http.ResponseWriter, deadline time.Time) error {
+	return http.NewResponseController(w).SetWriteDeadline(deadline)
+}
+
+// Run は SIGINT / SIGTERM を受けるまで handler を提供し、受けた後は処理中のリクエストの完了を待って終了する
+func Run(cfg config.Server, handler http.Handler, hooks Hooks) error {
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	if cfg.TLS() {
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Starting server", "addr", cfg.Addr, "tls", cfg.TLS())
+		var err error
+		if cfg.TLS() {
+			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-sigCtx.Done():
+	}
+	// 2回目のシグナルでは待たずに終了する
+	stop()
+
+	slog.Info("Shutting down server", "delay", cfg.ShutdownDelay, "timeout", cfg.ShutdownTimeout)
+	close(draining)
+	time.Sleep(cfg.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	shutdownErr := srv.Shutdown(ctx)
+	if shutdownErr != nil {
+		slog.Warn("Server did not shut down gracefully", "error", shutdownErr)
+		srv.Close()
+	}
+
+	if hooks.Cleanup != nil {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		hooks.Cleanup(cleanupCtx)
+	}
+	slog.Info("Server stopped")
+	return shutdownErr
+}
+
+// HealthURL は同じコンテナ内から /readyz を確認するための URL（healthcheck サブコマンド用）
+func HealthURL(cfg config.Server) string {
+	_, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil || port == "" {
+		port = "8080"
+	}
+	scheme := "http"
+	if cfg.TLS() {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://127.0.0.1:%s/readyz", scheme, port)
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"api/config"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// BlobStore はアップロードされたファイルの保存先を抽象化する
+type BlobStore interface {
+	// Put は key に r の内容を保存する
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open は key のファイルを読み出す
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete は key のファイルを削除する（存在しない場合もエラーにしない）
+	Delete(ctx context.Context, key string) error
+	// URL は key に対応する公開URLを返す
+	URL(key string) string
+	// Ping は保存先に接続できるか確認する（ヘルスチェック用）
+	Ping(ctx context.Context) error
+}
+
+// PrivatePrefix 配下のファイルは公開URLで配信しない（データエクスポート等）
+// S3 を使う場合はバケットポリシーでもこの接頭辞を非公開にする
+const PrivatePrefix = "exports/"
+
+var store BlobStore
+
+// Initialize は cfg.Driver（local / s3）に応じて保存先を初期化する
+func Initialize(cfg config.Storage) error {
+	driver := cfg.Driver
+
+	var err error
+	switch driver {
+	case "local":
+		publicURL := cfg.PublicURL
+		if publicURL == "" {
+			publicURL = LocalMountPath
+		}
+		store, err = NewLocalStore(cfg.LocalDir, publicURL)
+	case "s3":
+		store, err = NewS3Store(S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			UseSSL:    cfg.S3.UseSSL,
+			PublicURL: cfg.PublicURL,
+		})
+	default:
+		return fmt.Errorf("unknown STORAGE_DRIVER: %s", driver)
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Blob storage initialized", "driver", driver)
+	return nil
+}
+
+// Get returns the configured BlobStore
+func Get() BlobStore {
+	return store
+}
+
+// IsPrivate は key が公開してはいけないファイルか判定する
+func IsPrivate(key string) bool {
+	return strings.HasPrefix(strings.TrimLeft(key, "/"), PrivatePrefix)
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(key, "/")
+}
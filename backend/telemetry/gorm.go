@@ -0,0 +1,77 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// InstrumentGORM はクエリ毎にスパンを記録するコールバックを登録する
+// db.Ctx(c.Request.Context()) のように ctx を渡したクエリはリクエストのスパンの子になる
+func InstrumentGORM(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("otel:before_create", startSpan("create")),
+		cb.Create().After("gorm:create").Register("otel:after_create", endSpan),
+		cb.Query().Before("gorm:query").Register("otel:before_query", startSpan("query")),
+		cb.Query().After("gorm:query").Register("otel:after_query", endSpan),
+		cb.Update().Before("gorm:update").Register("otel:before_update", startSpan("update")),
+		cb.Update().After("gorm:update").Register("otel:after_update", endSpan),
+		cb.Delete().Before("gorm:delete").Register("otel:before_delete", startSpan("delete")),
+		cb.Delete().After("gorm:delete").Register("otel:after_delete", endSpan),
+		cb.Row().Before("gorm:row").Register("otel:before_row", startSpan("row")),
+		cb.Row().After("gorm:row").Register("otel:after_row", endSpan),
+		cb.Raw().Before("gorm:raw").Register("otel:before_raw", startSpan("raw")),
+		cb.Raw().After("gorm:raw").Register("otel:after_raw", endSpan),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const parentContextKey = "otel:parent_context"
+
+func startSpan(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if !trace.SpanFromContext(ctx).IsRecording() {
+			// リクエスト外のクエリ（起動時のマイグレーション等）は記録しない
+			return
+		}
+		spanCtx, _ := tracer().Start(ctx, "gorm."+operation, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.String("db.operation.name", operation)))
+		// 同じ Statement で続けてクエリを実行する場合に備え、終了時に元の ctx に戻す
+		tx.InstanceSet(parentContextKey, ctx)
+		tx.Statement.Context = spanCtx
+	}
+}
+
+func endSpan(tx *gorm.DB) {
+	v, _ := tx.InstanceGet(parentContextKey)
+	parent, ok := v.(context.Context)
+	if !ok {
+		return
+	}
+	tx.InstanceSet(parentContextKey, nil)
+	span := trace.SpanFromContext(tx.Statement.Context)
+	tx.Statement.Context = parent
+	defer span.End()
+
+	// 値はバインド変数のまま記録する（個人情報を含めない）
+	span.SetAttributes(
+		attribute.String("db.query.text", tx.Statement.SQL.String()),
+		attribute.String("db.collection.name", tx.Statement.Table),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+	if err := tx.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chap"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	heatmapCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "heatmap_cache_requests_total",
+		Help:      "Social sensing heatmap cache lookups by result (hit / miss).",
+	}, []string{"result"})
+
+	llmDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "LLM call latency by operation and outcome (ok / error).",
+		// 生成には数十秒かかることがある
+		Buckets: []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80},
+	}, []string{"operation", "outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		heatmapCache,
+		llmDuration,
+	)
+}
+
+// Handler は /metrics のハンドラー
+// 内部の情報を含むため、公開する場合はリバースプロキシ等でアクセスを制限すること
+func Handler() gin.HandlerFunc {
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	return gin.WrapH(h)
+}
+
+// Metrics はルート毎のリクエスト数とレイテンシを記録する
+// パスパラメータで系列が増えないよう、ルートのパターン（/api/v1/post/:id 等）で集計する
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDBStats はコネクションプールの統計（sql.DB.Stats()）を公開する
+func RegisterDBStats(db *sql.DB, name string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// HeatmapCacheHit はヒートマップのキャッシュが使われたことを記録する
+func HeatmapCacheHit() {
+	heatmapCache.WithLabelValues("hit").Inc()
+}
+
+// HeatmapCacheMiss はヒートマップを作り直したことを記録する
+func HeatmapCacheMiss() {
+	heatmapCache.WithLabelValues("miss").Inc()
+}
@@ -0,0 +1,109 @@
+package telemetry
+
+import (
+	"api/config"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultServiceName = "chap-api"
+	tracerName         = "api/telemetry"
+)
+
+var provider *sdktrace.TracerProvider
+
+// Initialize はトレースの送信を設定する（既定では無効）
+//
+//	OTEL_TRACING_ENABLED         true でトレースを OTLP/HTTP で送信する
+//	OTEL_EXPORTER_OTLP_ENDPOINT  送信先（例: http://localhost:4318）
+//	OTEL_SERVICE_NAME            サービス名（既定: chap-api）
+//	OTEL_TRACES_SAMPLER 等、その他の OTEL_* 環境変数も SDK がそのまま読み込む
+func Initialize(cfg config.Telemetry) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !cfg.TracingEnabled {
+		// 無効の場合は既定の noop の TracerProvider のままにする
+		return nil
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(defaultServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return err
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("Tracing initialized (exporter: otlp/http)")
+	return nil
+}
+
+// Shutdown は送信待ちのスパンを送信して終了する
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// Tracing はリクエスト毎のスパンを開始し、c.Request.Context() に設定する
+func Tracing() gin.HandlerFunc {
+	return otelgin.Middleware(defaultServiceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		// ヘルスチェックとメトリクスの収集は記録しない
+		switch c.FullPath() {
+		case "/metrics", "/livez", "/readyz", "/health":
+			return false
+		}
+		return true
+	}))
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// ObserveLLM は LLM の呼び出しをスパンとレイテンシのヒストグラムに記録する
+func ObserveLLM(ctx context.Context, operation, model string, fn func(ctx context.Context) error) error {
+	ctx, span := tracer().Start(ctx, "llm."+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.system", "gemini"),
+			attribute.String("gen_ai.request.model", model),
+		))
+	defer span.End()
+
+	start := time.Now()
+	err := fn(ctx)
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	llmDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+	return err
+}
@@ -0,0 +1,512 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// リクエストの検証ルール（binding タグの値と揃える）
+const (
+	MaxContentLength = 2000  // 本文の最大文字数
+	MaxTags          = 10    // タグの最大数
+	MaxTagLength     = 30    // タグ1つの最大文字数
+	MaxListRadius    = 50000 // 一覧で指定できる半径（m）の上限
+)
+
+// 作成・更新のリクエスト
+// ID・投稿者・いいね数・表示状態・日時はクライアントから変更できないよう、モデルに直接バインドしない
+
+type CreatePostRequest struct {
+	Content       string      `json:"content" binding:"required,max=2000"`
+	Category      string      `json:"category" binding:"omitempty,max=50"`
+	Coordinate    *Coordinate `json:"coordinate" binding:"required"`
+	Precision     string      `json:"precision" binding:"omitempty,oneof=exact neighborhood city"`
+	Tags          []string    `json:"tags" binding:"max=10,dive,min=1,max=30"`
+	AttachmentIDs []uint      `json:"attachment_ids"`
+}
+
+// UpdatePostRequest は指定した項目のみ更新する（attachment_ids は指定した場合に置き換える）
+type UpdatePostRequest struct {
+	Content       *string     `json:"content" binding:"omitempty,min=1,max=2000"`
+	Category      *string     `json:"category" binding:"omitempty,max=50"`
+	Coordinate    *Coordinate `json:"coordinate"`
+	Precision     *string     `json:"precision" binding:"omitempty,oneof=exact neighborhood city"`
+	Tags          []string    `json:"tags" binding:"omitempty,max=10,dive,min=1,max=30"`
+	AttachmentIDs []uint      `json:"attachment_ids"`
+}
+
+type CreateThreadRequest struct {
+	Content       string      `json:"content" binding:"required,max=2000"`
+	Category      string      `json:"category" binding:"omitempty,max=50"`
+	Coordinate    *Coordinate `json:"coordinate" binding:"required"`
+	Precision     string      `json:"precision" binding:"omitempty,oneof=exact neighborhood city"`
+	Tags          []string    `json:"tags" binding:"max=10,dive,min=1,max=30"`
+	AttachmentIDs []uint      `json:"attachment_ids"`
+}
+
+type UpdateThreadRequest struct {
+	Content       *string     `json:"content" binding:"omitempty,min=1,max=2000"`
+	Category      *string     `json:"category" binding:"omitempty,max=50"`
+	Coordinate    *Coordinate `json:"coordinate"`
+	Precision     *string     `json:"precision" binding:"omitempty,oneof=exact neighborhood city"`
+	Tags          []string    `json:"tags" binding:"omitempty,max=10,dive,min=1,max=30"`
+	AttachmentIDs []uint      `json:"attachment_ids"`
+}
+
+type CreateEventRequest struct {
+	Content       string      `json:"content" binding:"required,max=2000"`
+	Category      string      `json:"category" binding:"omitempty,max=50"`
+	Coordinate    *Coordinate `json:"coordinate" binding:"required"`
+	Precision     string      `json:"precision" binding:"omitempty,oneof=exact neighborhood city"`
+	Tags          []string    `json:"tags" binding:"max=10,dive,min=1,max=30"`
+	EventDate     *time.Time  `json:"event_date" binding:"required,future"`
+	AttachmentIDs []uint      `json:"attachment_ids"`
+}
+
+type UpdateEventRequest struct {
+	Content       *string     `json:"content" binding:"omitempty,min=1,max=2000"`
+	Category      *string     `json:"category" binding:"omitempty,max=50"`
+	Coordinate    *Coordinate `json:"coordinate"`
+	Precision     *string     `json:"precision" binding:"omitempty,oneof=exact neighborhood city"`
+	Tags          []string    `json:"tags" binding:"omitempty,max=10,dive,min=1,max=30"`
+	EventDate     *time.Time  `json:"event_date" binding:"omitempty,future"`
+	AttachmentIDs []uint      `json:"attachment_ids"`
+}
+
+type CreateCommentRequest struct {
+	// パスに thread_id がある場合はそちらを使う
+	ThreadID      uint        `json:"thread_id"`
+	Content       string      `json:"content" binding:"required,max=2000"`
+	Coordinate    *Coordinate `json:"coordinate"`
+	Precision     string      `json:"precision" binding:"omitempty,oneof=exact neighborhood city"`
+	Tags          []string    `json:"tags" binding:"max=10,dive,min=1,max=30"`
+	AttachmentIDs []uint      `json:"attachment_ids"`
+}
+
+// ListQuery は v2 の一覧のクエリ（?lat=&lng=&radius=&since=）
+// lat・lng を指定した場合のみ地域のカテゴリを含める。radius を省略した場合は各カテゴリの半径
+type ListQuery struct {
+	Lat    *float64 `form:"lat" binding:"omitempty,gte=-90,lte=90"`
+	Lng    *float64 `form:"lng" binding:"omitempty,gte=-180,lte=180"`
+	Radius int      `form:"radius" binding:"omitempty,min=1,max=50000"`
+	Since  int64    `form:"since" binding:"omitempty,min=0"`
+}
+
+// ExportQuery は管理者用のエクスポートのクエリ（type・category はカンマ区切りで複数指定できる）
+type ExportQuery struct {
+	Format        string `form:"format" binding:"omitempty,oneof=geojson ndjson csv"`
+	Type          string `form:"type"`
+	BBox          string `form:"bbox"` // minLng,minLat,maxLng,maxLat
+	From          int64  `form:"from" binding:"omitempty,min=0"`
+	To            int64  `form:"to" binding:"omitempty,min=0"`
+	Category      string `form:"category"`
+	IncludeHidden bool   `form:"include_hidden"`
+}
+
+// ImportQuery は管理者用の取り込みのクエリ
+// format を省略した場合はファイルの拡張子から判定し、user_id を省略した場合は取り込んだ管理者を投稿者にする
+type ImportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=geojson ndjson csv"`
+	Type   string `form:"type" binding:"omitempty,oneof=post thread event"`
+	UserID string `form:"user_id" binding:"omitempty,uuid"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// AdminUserQuery は管理者用のユーザー一覧のクエリ（page・limit は別に読み取る）
+type AdminUserQuery struct {
+	Q      string `form:"q" binding:"max=100"` // 名前・メールアドレスの部分一致
+	Role   string `form:"role" binding:"omitempty,oneof=user moderator admin"`
+	Status string `form:"status" binding:"omitempty,oneof=active suspended banned deleted"`
+}
+
+// AuditLogQuery は管理者用の監査ログ一覧のクエリ（page・limit は別に読み取る）
+// from・to は Unix 時間（秒）で、to の時刻は含まない
+type AuditLogQuery struct {
+	ActorID    string `form:"actor_id" binding:"omitempty,uuid"`
+	Action     string `form:"action" binding:"max=100"` // 完全一致。"post." のように . で終わる場合は前方一致
+	TargetType string `form:"target_type" binding:"max=50"`
+	TargetID   string `form:"target_id" binding:"max=100"`
+	RequestID  string `form:"request_id" binding:"max=100"`
+	From       int64  `form:"from" binding:"omitempty,min=0"`
+	To         int64  `form:"to" binding:"omitempty,min=0"`
+}
+
+// SuspendUserRequest は期限付きの利用停止
+type SuspendUserRequest struct {
+	Reason string    `json:"reason" binding:"required,max=1000"`
+	Until  time.Time `json:"until" binding:"required"`
+}
+
+// BanUserRequest は無期限の利用停止
+type BanUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+// UpdateRoleRequest は権限の変更
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+func (r CreatePostRequest) Post() Post {
+	return Post{
+		Content:       r.Content,
+		Category:      r.Category,
+		Coordinate:    *r.Coordinate,
+		Precision:     r.Precision,
+		Tags:          r.Tags,
+		AttachmentIDs: r.AttachmentIDs,
+	}
+}
+
+// Apply は指定された項目を post に反映する
+func (r UpdatePostRequest) Apply(post *Post) {
+	applyString(&post.Content, r.Content)
+	applyString(&post.Category, r.Category)
+	applyString(&post.Precision, r.Precision)
+	if r.Coordinate != nil {
+		post.Coordinate = *r.Coordinate
+	}
+	if r.Tags != nil {
+		post.Tags = r.Tags
+	}
+	post.AttachmentIDs = r.AttachmentIDs
+}
+
+func (r CreateThreadRequest) Thread() Thread {
+	return Thread{
+		Content:       r.Content,
+		Category:      r.Category,
+		Coordinate:    *r.Coordinate,
+		Precision:     r.Precision,
+		Tags:          r.Tags,
+		AttachmentIDs: r.AttachmentIDs,
+	}
+}
+
+func (r UpdateThreadRequest) Apply(thread *Thread) {
+	applyString(&thread.Content, r.Content)
+	applyString(&thread.Category, r.Category)
+	applyString(&thread.Precision, r.Precision)
+	if r.Coordinate != nil {
+		thread.Coordinate = *r.Coordinate
+	}
+	if r.Tags != nil {
+		thread.Tags = r.Tags
+	}
+	thread.AttachmentIDs = r.AttachmentIDs
+}
+
+func (r CreateEventRequest) Event() Event {
+	return Event{
+		Content:       r.Content,
+		Category:      r.Category,
+		Coordinate:    *r.Coordinate,
+		Precision:     r.Precision,
+		Tags:          r.Tags,
+		EventDate:     *r.EventDate,
+		AttachmentIDs: r.AttachmentIDs,
+	}
+}
+
+func (r UpdateEventRequest) Apply(event *Event) {
+	applyString(&event.Content, r.Content)
+	applyString(&event.Category, r.Category)
+	applyString(&event.Precision, r.Precision)
+	if r.Coordinate != nil {
+		event.Coordinate = *r.Coordinate
+	}
+	if r.Tags != nil {
+		event.Tags = r.Tags
+	}
+	if r.EventDate != nil {
+		event.EventDate = *r.EventDate
+	}
+	event.AttachmentIDs = r.AttachmentIDs
+}
+
+func (r CreateCommentRequest) Comment() Comment {
+	comment := Comment{
+		ThreadID:      r.ThreadID,
+		Content:       r.Content,
+		Precision:     r.Precision,
+		Tags:          r.Tags,
+		AttachmentIDs: r.AttachmentIDs,
+	}
+	if r.Coordinate != nil {
+		comment.Coordinate = *r.Coordinate
+	}
+	return comment
+}
+
+func applyString(dst *string, v *string) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// レスポンス
+// 削除日時・内部の関連（User 等）は含めない
+
+type PostResponse struct {
+	ID          uint         `json:"id"`
+	Type        string       `json:"type"`
+	UserID      uuid.UUID    `json:"user_id"`
+	Username    string       `json:"username"`
+	Content     string       `json:"content"`
+	Category    string       `json:"category"`
+	Coordinate  Coordinate   `json:"coordinate"`
+	Precision   string       `json:"precision"`
+	Tags        []string     `json:"tags"`
+	Like        int          `json:"like"`
+	Valid       bool         `json:"valid"`
+	Attachments []Attachment `json:"attachments"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type ThreadResponse struct {
+	ID          uint         `json:"id"`
+	Type        string       `json:"type"`
+	UserID      uuid.UUID    `json:"user_id"`
+	Username    string       `json:"username"`
+	Content     string       `json:"content"`
+	Category    string       `json:"category"`
+	Coordinate  Coordinate   `json:"coordinate"`
+	Precision   string       `json:"precision"`
+	Tags        []string     `json:"tags"`
+	Like        int          `json:"like"`
+	Valid       bool         `json:"valid"`
+	Attachments []Attachment `json:"attachments"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type EventResponse struct {
+	ID          uint         `json:"id"`
+	Type        string       `json:"type"`
+	UserID      uuid.UUID    `json:"user_id"`
+	Username    string       `json:"username"`
+	Content     string       `json:"content"`
+	Category    string       `json:"category"`
+	Coordinate  Coordinate   `json:"coordinate"`
+	Precision   string       `json:"precision"`
+	Tags        []string     `json:"tags"`
+	Like        int          `json:"like"`
+	Valid       bool         `json:"valid"`
+	EventDate   time.Time    `json:"event_date"`
+	Attachments []Attachment `json:"attachments"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type CategoryResponse struct {
+	ID           string            `json:"id"`
+	Labels       map[string]string `json:"labels"` // 言語コード毎の表示名
+	Icon         string            `json:"icon"`
+	Scope        string            `json:"scope"`
+	RadiusMeters int               `json:"radius_meters,omitempty"`
+	Moderation   string            `json:"moderation"`
+}
+
+type CommentResponse struct {
+	ID          uint         `json:"id"`
+	ThreadID    uint         `json:"thread_id"`
+	UserID      uuid.UUID    `json:"user_id"`
+	Username    string       `json:"username"`
+	Content     string       `json:"content"`
+	Coordinate  Coordinate   `json:"coordinate"`
+	Precision   string       `json:"precision"`
+	Tags        []string     `json:"tags"`
+	Like        int          `json:"like"`
+	Valid       bool         `json:"valid"`
+	Attachments []Attachment `json:"attachments"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// AdminUserResponse は管理者向けのユーザー情報（パスワード等は含めない）
+type AdminUserResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	Email          string     `json:"email"`
+	EmailVerified  bool       `json:"email_verified"`
+	LoginType      string     `json:"login_type"`
+	Role           string     `json:"role"`
+	Status         string     `json:"status"` // active / suspended / banned
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	SuspendReason  string     `json:"suspend_reason"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeletedAt      *time.Time `json:"deleted_at"` // 退会手続き中の場合のみ
+}
+
+// DeletedContentResponse は削除済みの投稿・スレッド・イベント・コメント（管理者向け）
+type DeletedContentResponse struct {
+	Type      string    `json:"type"`
+	ID        uint      `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	Content   string    `json:"content"`
+	Category  string    `json:"category,omitempty"` // コメントには無い
+	Valid     bool      `json:"valid"`
+	CreatedAt time.Time `json:"created_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// AdminStats は管理画面のシステムの統計
+type AdminStats struct {
+	Users UserCounts `json:"users"`
+	// 種類（post / thread / event / comment）毎の件数
+	Content     map[string]ContentCounts `json:"content"`
+	OpenReports int64                    `json:"open_reports"`
+	GeneratedAt time.Time                `json:"generated_at"`
+}
+
+// UserCounts はアカウントの状態・権限毎のユーザー数（退会手続き中は deleting のみに数える）
+type UserCounts struct {
+	Total     int64            `json:"total"`
+	Active    int64            `json:"active"`
+	Suspended int64            `json:"suspended"`
+	Banned    int64            `json:"banned"`
+	Deleting  int64            `json:"deleting"`
+	New7d     int64            `json:"new_7d"` // 直近7日間の登録数
+	Roles     map[string]int64 `json:"roles"`
+}
+
+// ContentCounts は表示中・非表示・削除済みの件数
+type ContentCounts struct {
+	Visible int64 `json:"visible"`
+	Hidden  int64 `json:"hidden"`
+	Deleted int64 `json:"deleted"`
+	New7d   int64 `json:"new_7d"` // 直近7日間の作成数（削除済みを含む）
+}
+
+func (u User) AdminResponse(now time.Time) AdminUserResponse {
+	var deletedAt *time.Time
+	if u.DeletedAt.Valid {
+		deletedAt = &u.DeletedAt.Time
+	}
+	return AdminUserResponse{
+		ID:             u.ID,
+		Name:           u.Name,
+		Email:          u.Email,
+		EmailVerified:  u.EmailVerified,
+		LoginType:      u.LoginType,
+		Role:           u.Role,
+		Status:         u.Status(now),
+		SuspendedUntil: u.SuspendedUntil,
+		SuspendReason:  u.SuspendReason,
+		CreatedAt:      u.CreatedAt,
+		DeletedAt:      deletedAt,
+	}
+}
+
+func (p Post) Response() PostResponse {
+	return PostResponse{
+		ID:          p.ID,
+		Type:        p.Type,
+		UserID:      p.UserID,
+		Username:    p.Username,
+		Content:     p.Content,
+		Category:    p.Category,
+		Coordinate:  p.Coordinate,
+		Precision:   p.Precision,
+		Tags:        orEmptyTags(p.Tags),
+		Like:        p.Like,
+		Valid:       p.Valid,
+		Attachments: orEmptyAttachments(p.Attachments),
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+func (t Thread) Response() ThreadResponse {
+	return ThreadResponse{
+		ID:          t.ID,
+		Type:        t.Type,
+		UserID:      t.UserID,
+		Username:    t.Username,
+		Content:     t.Content,
+		Category:    t.Category,
+		Coordinate:  t.Coordinate,
+		Precision:   t.Precision,
+		Tags:        orEmptyTags(t.Tags),
+		Like:        t.Like,
+		Valid:       t.Valid,
+		Attachments: orEmptyAttachments(t.Attachments),
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+func (e Event) Response() EventResponse {
+	return EventResponse{
+		ID:          e.ID,
+		Type:        e.Type,
+		UserID:      e.UserID,
+		Username:    e.Username,
+		Content:     e.Content,
+		Category:    e.Category,
+		Coordinate:  e.Coordinate,
+		Precision:   e.Precision,
+		Tags:        orEmptyTags(e.Tags),
+		Like:        e.Like,
+		Valid:       e.Valid,
+		EventDate:   e.EventDate,
+		Attachments: orEmptyAttachments(e.Attachments),
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+func (c Comment) Response() CommentResponse {
+	return CommentResponse{
+		ID:          c.ID,
+		ThreadID:    c.ThreadID,
+		UserID:      c.UserID,
+		Username:    c.Username,
+		Content:     c.Content,
+		Coordinate:  c.Coordinate,
+		Precision:   c.Precision,
+		Tags:        orEmptyTags(c.Tags),
+		Like:        c.Like,
+		Valid:       c.Valid,
+		Attachments: orEmptyAttachments(c.Attachments),
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
+
+func (c Category) Response() CategoryResponse {
+	return CategoryResponse{
+		ID:           c.ID,
+		Labels:       map[string]string{"ja": c.LabelJA, "en": c.LabelEN},
+		Icon:         c.Icon,
+		Scope:        c.Scope,
+		RadiusMeters: c.RadiusMeters,
+		Moderation:   c.Moderation,
+	}
+}
+
+// Responses は一覧をレスポンスの形に変換する
+func Responses[M interface{ Response() R }, R any](items []M) []R {
+	res := make([]R, len(items))
+	for i, item := range items {
+		res[i] = item.Response()
+	}
+	return res
+}
+
+func orEmptyTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func orEmptyAttachments(attachments []Attachment) []Attachment {
+	if attachments == nil {
+		return []Attachment{}
+	}
+	return attachments
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,86 +11,269 @@ import (
 
 const AROUND = 0.01 // 検索範囲の定数
 
+// ユーザーの権限
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// 通報の状態
+const (
+	ReportOpen      = "open"      // 未対応
+	ReportActioned  = "actioned"  // 対象を非表示にした
+	ReportDismissed = "dismissed" // 問題なしとして却下
+)
+
 // メッセージ用構造体
 type Post struct {
-	ID         uint           `json:"id" gorm:"primaryKey"`
-	Type       string         `json:"type" gorm:"default:'post'"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	UserID     uuid.UUID      `json:"user_id"`
-	Username   string         `json:"username" gorm:"not null"`
-	User       User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
-	Coordinate Coordinate     `json:"coordinate" gorm:"embedded"`
-	Content    string         `json:"content"`
-	Category   string         `json:"category" gorm:"default:'other'"`
-	Valid      bool           `json:"valid"`
-	Like       int            `json:"like"`
-	Tags       pq.StringArray `json:"tags" gorm:"type:text[]"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	Type          string         `json:"type" gorm:"default:'post'"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	UserID        uuid.UUID      `json:"user_id"`
+	Username      string         `json:"username" gorm:"not null"`
+	User          User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
+	Coordinate    Coordinate     `json:"coordinate" gorm:"embedded"`
+	Precision     string         `json:"precision" gorm:"default:'neighborhood'"` // 座標の公開精度（exact / neighborhood / city）
+	Content       string         `json:"content"`
+	Category      string         `json:"category" gorm:"index"`
+	Valid         bool           `json:"valid"`
+	Like          int            `json:"like"`
+	Tags          pq.StringArray `json:"tags" gorm:"type:text[]"`
+	AttachmentIDs []uint         `json:"attachment_ids,omitempty" gorm:"-"`
+	Attachments   []Attachment   `json:"attachments" gorm:"-"`
 }
 type Comment struct {
-	ID         uint           `json:"id" gorm:"primaryKey"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	UserID     uuid.UUID      `json:"user_id"`
-	Username   string         `json:"username" gorm:"not null"`
-	User       User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
-	Coordinate Coordinate     `json:"coordinate" gorm:"embedded"`
-	Content    string         `json:"content"`
-	Valid      bool           `json:"valid"`
-	Thread     Thread         `json:"thread" gorm:"foreignKey:ThreadID;constraint:OnUpdate:CASCADE;"`
-	ThreadID   uint           `json:"thread_id"`
-	Like       int            `json:"like"`
-	Tags       pq.StringArray `json:"tags" gorm:"type:text[]"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	UserID        uuid.UUID      `json:"user_id"`
+	Username      string         `json:"username" gorm:"not null"`
+	User          User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
+	Coordinate    Coordinate     `json:"coordinate" gorm:"embedded"`
+	Precision     string         `json:"precision" gorm:"default:'neighborhood'"`
+	Content       string         `json:"content"`
+	Valid         bool           `json:"valid"`
+	Thread        Thread         `json:"thread" gorm:"foreignKey:ThreadID;constraint:OnUpdate:CASCADE;"`
+	ThreadID      uint           `json:"thread_id"`
+	Like          int            `json:"like"`
+	Tags          pq.StringArray `json:"tags" gorm:"type:text[]"`
+	AttachmentIDs []uint         `json:"attachment_ids,omitempty" gorm:"-"`
+	Attachments   []Attachment   `json:"attachments" gorm:"-"`
 }
 
 type Thread struct {
-	ID         uint           `json:"id" gorm:"primaryKey"`
-	Type       string         `json:"type" gorm:"default:'thread'"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	Username   string         `json:"username" gorm:"not null"`
-	UserID     uuid.UUID      `json:"user_id"`
-	User       User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
-	Coordinate Coordinate     `json:"coordinate" gorm:"embedded"`
-	Category   string         `json:"category" gorm:"default:'other'"`
-	Content    string         `json:"content"`
-	Valid      bool           `json:"valid"`
-	Like       int            `json:"like"`
-	Tags       pq.StringArray `json:"tags" gorm:"type:text[]"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	Type          string         `json:"type" gorm:"default:'thread'"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Username      string         `json:"username" gorm:"not null"`
+	UserID        uuid.UUID      `json:"user_id"`
+	User          User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
+	Coordinate    Coordinate     `json:"coordinate" gorm:"embedded"`
+	Precision     string         `json:"precision" gorm:"default:'neighborhood'"`
+	Category      string         `json:"category" gorm:"index"`
+	Content       string         `json:"content"`
+	Valid         bool           `json:"valid"`
+	Like          int            `json:"like"`
+	Tags          pq.StringArray `json:"tags" gorm:"type:text[]"`
+	AttachmentIDs []uint         `json:"attachment_ids,omitempty" gorm:"-"`
+	Attachments   []Attachment   `json:"attachments" gorm:"-"`
 }
 type Event struct {
-	ID         uint           `json:"id" gorm:"primaryKey"`
-	Type       string         `json:"type" gorm:"default:'event'"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	Username   string         `json:"username" gorm:"not null"`
-	UserID     uuid.UUID      `json:"user_id"`
-	User       User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
-	Coordinate Coordinate     `json:"coordinate" gorm:"embedded"`
-	Category   string         `json:"category" gorm:"default:'other'"`
-	Content    string         `json:"content"`
-	Valid      bool           `json:"valid"`
-	Like       int            `json:"like"`
-	Tags       pq.StringArray `json:"tags" gorm:"type:text[]"`
-	EventDate  time.Time      `json:"event_date"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	Type          string         `json:"type" gorm:"default:'event'"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Username      string         `json:"username" gorm:"not null"`
+	UserID        uuid.UUID      `json:"user_id"`
+	User          User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
+	Coordinate    Coordinate     `json:"coordinate" gorm:"embedded"`
+	Precision     string         `json:"precision" gorm:"default:'neighborhood'"`
+	Category      string         `json:"category" gorm:"index"`
+	Content       string         `json:"content"`
+	Valid         bool           `json:"valid"`
+	Like          int            `json:"like"`
+	Tags          pq.StringArray `json:"tags" gorm:"type:text[]"`
+	EventDate     time.Time      `json:"event_date"`
+	AttachmentIDs []uint         `json:"attachment_ids,omitempty" gorm:"-"`
+	Attachments   []Attachment   `json:"attachments" gorm:"-"`
 }
 
 type User struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
-	Name      string    `json:"name" gorm:"not null"`
-	Image     string    `json:"image"`
-	Email     string    `json:"email" gorm:"not null;unique"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	Valid     bool      `json:"valid" gorm:"default:true"`
-	Password  string    `json:"password" gorm:"not null"`
-	LoginType string    `json:"login_type" gorm:"default:'email'"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt time.Time `json:"deleted_at" gorm:"index"`
+	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	Name             string         `json:"name" gorm:"not null"`
+	Image            string         `json:"image"`
+	ImageKey         string         `json:"-"` // BlobStore上のアバター画像のキー
+	Bio              string         `json:"bio"`
+	HomeArea         string         `json:"home_area"`
+	DefaultPrecision string         `json:"default_precision" gorm:"default:'neighborhood'"` // 投稿時の座標の公開精度の既定値
+	Email            string         `json:"email" gorm:"not null;unique"`
+	EmailVerified    bool           `json:"email_verified" gorm:"default:false"`
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	Valid            bool           `json:"valid" gorm:"default:true"`
+	Password         string         `json:"password" gorm:"not null"`          // 旧形式。現在のパスワードは Identity に保存する
+	LoginType        string         `json:"login_type" gorm:"default:'email'"` // 登録時のログイン方法
+	Role             string         `json:"role" gorm:"default:'user'"`
+	SuspendedUntil   *time.Time     `json:"suspended_until"`          // 利用停止の期限（Valid が false で nil の場合は無期限）
+	SuspendReason    string         `json:"suspend_reason,omitempty"` // 利用停止の理由（管理者向け）
+	PasswordSetAt    *time.Time     `json:"-"`                        // パスワードの最終変更日時（これより前に発行された JWT は無効）
+	UpdatedAt        time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index"` // 退会手続き中（猶予期間の経過後に削除される）
 }
+
+// アカウントの状態（Valid と SuspendedUntil から決まる）
+const (
+	UserActive    = "active"
+	UserSuspended = "suspended" // 期限付きの利用停止
+	UserBanned    = "banned"    // 無期限の利用停止
+)
+
+// Status は now の時点のアカウントの状態を返す（利用停止の期限を過ぎていれば Valid が false でも active）
+func (u User) Status(now time.Time) string {
+	switch {
+	case u.Valid:
+		return UserActive
+	case u.SuspendedUntil == nil:
+		return UserBanned
+	case now.Before(*u.SuspendedUntil):
+		return UserSuspended
+	}
+	return UserActive
+}
+
+// 退会したユーザーの投稿を匿名化する際の投稿者（マイグレーションで作成する）
+var DeletedUserID = uuid.Nil
+
+const DeletedUserName = "退会したユーザー"
+
+// メール確認・パスワード再設定用のトークン種別
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+)
+
+// メール確認・パスワード再設定用の使い捨てトークン（DBにはハッシュのみ保存する）
+type UserToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	Purpose   string     `json:"purpose" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// データエクスポートの状態
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// 個人データのエクスポート（ZIP は BlobStore の非公開領域に保存する）
+type DataExport struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	Status      string     `json:"status" gorm:"default:'pending'"`
+	Key         string     `json:"-"`
+	Size        int64      `json:"size"`
+	Error       string     `json:"error,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// 添付ファイル（画像）
+type Attachment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time `json:"created_at"`
+	UserID       uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	Key          string    `json:"-" gorm:"not null"`
+	ThumbnailKey string    `json:"-"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+}
+
+// 投稿と添付ファイルの紐付け（TargetType は post / thread / event / comment）
+type ContentAttachment struct {
+	AttachmentID uint       `json:"attachment_id" gorm:"primaryKey"`
+	Attachment   Attachment `gorm:"foreignKey:AttachmentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	TargetType   string     `json:"target_type" gorm:"not null;index:idx_content_attachments_target"`
+	TargetID     uint       `json:"target_id" gorm:"not null;index:idx_content_attachments_target"`
+	Position     int        `json:"position"`
+}
+
+// 投稿・スレッド・イベント・コメントへの通報
+// 同じユーザーは同じ対象を1回だけ通報できる
+type Report struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ReporterID uuid.UUID  `json:"reporter_id" gorm:"type:uuid;not null;uniqueIndex:idx_reports_reporter_target"`
+	TargetType string     `json:"target_type" gorm:"not null;uniqueIndex:idx_reports_reporter_target;index:idx_reports_target"`
+	TargetID   uint       `json:"target_id" gorm:"not null;uniqueIndex:idx_reports_reporter_target;index:idx_reports_target"`
+	Reason     string     `json:"reason" gorm:"not null"`
+	Status     string     `json:"status" gorm:"default:'open';index"`
+	ResolvedBy *uuid.UUID `json:"resolved_by" gorm:"type:uuid"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+}
+
+// コンテンツフィルタの判定記録（モデレーター向け）
+type FilterResult struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"created_at"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	TargetType string    `json:"target_type" gorm:"not null;index:idx_filter_results_target"`
+	TargetID   uint      `json:"target_id" gorm:"index:idx_filter_results_target"` // 拒否して保存しなかった場合は 0
+	Filter     string    `json:"filter" gorm:"not null"`
+	Decision   string    `json:"decision" gorm:"not null;index"`
+	Reason     string    `json:"reason"`
+	Content    string    `json:"content"` // 判定時点の本文
+}
+
+// 監査ログ（状態を変更する操作。追記のみで、UPDATE・DELETE はマイグレーションで作成するトリガーで拒否する）
+type AuditLog struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+	ActorID    *uuid.UUID      `json:"actor_id" gorm:"type:uuid;index"` // CLI・定期実行・自動の処理の場合は nil
+	Action     string          `json:"action" gorm:"not null;index"`    // 例: user.suspend, post.update
+	TargetType string          `json:"target_type" gorm:"not null;index:idx_audit_logs_target"`
+	TargetID   string          `json:"target_id" gorm:"index:idx_audit_logs_target"`
+	Before     json.RawMessage `json:"before" gorm:"type:jsonb"` // 変更前（編集は変更した項目のみ）
+	After      json.RawMessage `json:"after" gorm:"type:jsonb"`  // 変更後（編集は変更した項目のみ）
+	Detail     json.RawMessage `json:"detail" gorm:"type:jsonb"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id" gorm:"index"`
+}
+
+// 公開プロフィール用構造体（メールアドレスやログイン情報は含めない）
+type UserProfile struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Avatar    string    `json:"avatar"`
+	Bio       string    `json:"bio"`
+	HomeArea  string    `json:"home_area"`
+	CreatedAt time.Time `json:"created_at"`
+	Stats     UserStats `json:"stats"`
+}
+type UserStats struct {
+	Posts         int64 `json:"posts"`
+	Threads       int64 `json:"threads"`
+	Events        int64 `json:"events"`
+	LikesReceived int64 `json:"likes_received"`
+}
+
 type PostLikes struct {
 	UserID uuid.UUID `json:"user_id" `
 	PostID uint      `json:"post_id" `
@@ -110,30 +294,81 @@ type EventLikes struct {
 }
 
 type Coordinate struct {
-	Lat float64 `json:"lat"`
-	Lng float64 `json:"lng"`
-}
-type EmailLogin struct {
-	UserID   uuid.UUID `json:"user_id" `
-	User     User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
-	Email    string    `json:"email" gorm:"not null;unique"`
-	Password string    `json:"password" gorm:"not null"`
+	Lat float64 `json:"lat" binding:"gte=-90,lte=90"`
+	Lng float64 `json:"lng" binding:"gte=-180,lte=180"`
 }
-type GoogleLogin struct {
-	User        User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE;"`
-	UserID      uuid.UUID `json:"user_id"`
-	AccessToken string    `json:"access_token" gorm:"not null"`
-	Email       string    `json:"email" gorm:"not null;unique"`
-	Name        string    `json:"name" gorm:"not null"`
+
+// ログイン方法の種類
+const (
+	ProviderEmail  = "email"
+	ProviderGoogle = "google"
+)
+
+// ログイン方法（1ユーザーにつき種類毎に1つまで紐付けられる）
+type Identity struct {
+	ID     uint      `json:"id" gorm:"primaryKey"`
+	UserID uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_identities_user_provider"`
+	User   User      `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	// Provider と Subject の組でログイン方法を一意に識別する
+	// email の場合は小文字にしたメールアドレス、google の場合は Google のユーザーID（sub）
+	Provider     string     `json:"provider" gorm:"not null;uniqueIndex:idx_identities_user_provider;uniqueIndex:idx_identities_provider_subject"`
+	Subject      string     `json:"-" gorm:"not null;uniqueIndex:idx_identities_provider_subject"`
+	Email        string     `json:"email"`
+	PasswordHash string     `json:"-"` // email のみ
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
+
 type ThreadTable struct {
 	ThreadID   uint   `json:"thread_id" gorm:"primaryKey"`
 	Thread     Thread `gorm:"foreignKey:ThreadID;constraint:OnUpdate:CASCADE;"`
 	CommentIDs []uint `json:"comment_ids" gorm:"type:integer[]"`
 }
 
+// 既定のカテゴリ（category パッケージが初回起動時に登録する）
+const (
+	CategoryEntertainment = "entertainment"
+	CategoryCommunity     = "community"
+	CategoryDisaster      = "disaster"
+	CategoryOther         = "other"
+)
+
+// カテゴリの表示範囲
+const (
+	ScopeNationwide = "nationwide" // 位置に関係なく全国に表示する
+	ScopeLocal      = "local"      // 投稿地点から RadiusMeters 以内のユーザーにのみ表示する
+)
+
+// カテゴリのモデレーション方針
+const (
+	ModerationStandard = "standard" // コンテンツフィルタを通過すれば公開する
+	ModerationReview   = "review"   // 常に非表示で保存し、モデレーターの確認を待つ
+)
+
+// 投稿・スレッド・イベントのカテゴリ
+type Category struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	LabelJA      string    `json:"label_ja" gorm:"not null"`
+	LabelEN      string    `json:"label_en" gorm:"not null"`
+	Icon         string    `json:"icon"`
+	Scope        string    `json:"scope" gorm:"not null;default:'local'"`
+	RadiusMeters int       `json:"radius_meters"` // Scope が local の場合の表示範囲
+	Moderation   string    `json:"moderation" gorm:"not null;default:'standard'"`
+	Position     int       `json:"position"` // 一覧での表示順
+	Enabled      bool      `json:"enabled" gorm:"default:true"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
 type HeatmapPoint struct {
 	Lat   float64 `json:"lat"`
 	Lng   float64 `json:"lng"`
 	Value int     `json:"value"`
 }
+
+// 適用済みのスキーマのバージョン（db.SchemaVersion と比べてマイグレーションが最新か確認する）
+type SchemaMigration struct {
+	Version   int       `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	AppliedAt time.Time `json:"applied_at"`
+}
@@ -29,8 +29,8 @@ function AppInitializer({ children }: { children: React.ReactNode }) {
           });
 
           if (response.ok) {
-            const userData = await response.json();
-            dispatch(setUser(userData.user));
+            const { data } = await response.json();
+            dispatch(setUser(data.user));
           } else {
             localStorage.removeItem('authtoken');
           }
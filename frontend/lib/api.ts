@@ -58,6 +58,31 @@ export const API_ENDPOINTS = {
   },
 };
 
+// APIのエラーレスポンス {"error": {"code", "message", "details", "request_id"}}
+export interface ApiErrorBody {
+  code: string;
+  message: string;
+  details?: { field: string; message: string }[];
+  request_id?: string;
+}
+
+// APIがエラーを返した場合に投げる。code で分岐する（message は変わることがある）
+export class ApiError extends Error {
+  status: number;
+  code: string;
+  details: { field: string; message: string }[];
+  requestId?: string;
+
+  constructor(status: number, body: ApiErrorBody) {
+    super(body.message);
+    this.name = 'ApiError';
+    this.status = status;
+    this.code = body.code;
+    this.details = body.details ?? [];
+    this.requestId = body.request_id;
+  }
+}
+
 // Default fetch options for HTTPS
 export const defaultFetchOptions: RequestInit = {
   headers: {
@@ -96,19 +121,21 @@ export class ApiClient {
       const response = await fetch(url, config);
       
       if (!response.ok) {
-        const errorData = await response.json().catch(() => ({ message: 'Network error' }));
+        const errorData = await response.json().catch(() => null);
+        const body: ApiErrorBody = errorData?.error ?? { code: 'NETWORK_ERROR', message: `HTTP ${response.status}` };
         console.error('❌ API Error:', {
           status: response.status,
           statusText: response.statusText,
-          error: errorData,
+          error: body,
           url,
         });
-        throw new Error(errorData.message || `HTTP ${response.status}`);
+        throw new ApiError(response.status, body);
       }
 
-      const data = await response.json();
+      // 成功時は {"data": ...} の data を返す
+      const { data } = await response.json();
       console.log('✅ API Success:', { url, status: response.status });
-      return data;
+      return data as T;
     } catch (error) {
       console.error('💥 API Request failed:', error);
       throw error;